@@ -17,7 +17,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,38 +28,438 @@ import (
 
 // RedisSpec defines the desired state of Redis
 type RedisSpec struct {
-	Mode              string                     `json:"mode"`
-	Size              *int32                     `json:"size,omitempty"`
-	GlobalConfig      GlobalConfig               `json:"global"`
-	Service           Service                    `json:"service"`
-	Master            RedisMaster                `json:"master,omitempty"`
-	Slave             RedisSlave                 `json:"slave,omitempty"`
-	RedisExporter     *RedisExporter             `json:"redisExporter,omitempty"`
-	RedisConfig       map[string]string          `json:"redisConfig"`
-	Resources         *Resources                 `json:"resources,omitempty"`
-	Storage           *Storage                   `json:"storage,omitempty"`
-	NodeSelector      map[string]string          `json:"nodeSelector,omitempty"`
-	SecurityContext   *corev1.PodSecurityContext `json:"securityContext,omitempty"`
-	PriorityClassName string                     `json:"priorityClassName,omitempty"`
-	Affinity          *corev1.Affinity           `json:"affinity,omitempty"`
-	Tolerations       *[]corev1.Toleration       `json:"tolerations,omitempty"`
+	Mode                          string                     `json:"mode"`
+	Size                          *int32                     `json:"size,omitempty"`
+	GlobalConfig                  GlobalConfig               `json:"global"`
+	Service                       Service                    `json:"service"`
+	Master                        RedisMaster                `json:"master,omitempty"`
+	Slave                         RedisSlave                 `json:"slave,omitempty"`
+	RedisExporter                 *RedisExporter             `json:"redisExporter,omitempty"`
+	RedisConfig                   map[string]string          `json:"redisConfig"`
+	Resources                     *Resources                 `json:"resources,omitempty"`
+	Storage                       *Storage                   `json:"storage,omitempty"`
+	NodeSelector                  map[string]string          `json:"nodeSelector,omitempty"`
+	SecurityContext               *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+	PriorityClassName             string                     `json:"priorityClassName,omitempty"`
+	Affinity                      *corev1.Affinity           `json:"affinity,omitempty"`
+	Tolerations                   *[]corev1.Toleration       `json:"tolerations,omitempty"`
+	ACL                           *ACLConfig                 `json:"acl,omitempty"`
+	DNSWait                       *DNSWait                   `json:"dnsWait,omitempty"`
+	Probes                        *Probes                    `json:"probes,omitempty"`
+	PodDisruptionBudget           *RedisPodDisruptionBudget  `json:"podDisruptionBudget,omitempty"`
+	AOF                           *AOFConfig                 `json:"aof,omitempty"`
+	ShutdownTimeout               *int32                     `json:"shutdownTimeout,omitempty"`
+	TerminationGracePeriodSeconds *int64                     `json:"terminationGracePeriodSeconds,omitempty"`
+	MaxClients                    *int32                     `json:"maxClients,omitempty"`
+	Functions                     *FunctionsConfig           `json:"functions,omitempty"`
+	InitContainer                 *InitContainer             `json:"initContainer,omitempty"`
+	ReplicaOf                     *ReplicaOf                 `json:"replicaOf,omitempty"`
+	ShardOverrides                []ShardOverride            `json:"shardOverrides,omitempty"`
+	QuarantinePods                []string                   `json:"quarantinePods,omitempty"`
+	ClientOutputBufferLimit       *ClientOutputBufferLimit   `json:"clientOutputBufferLimit,omitempty"`
+	DegradedGracePeriodSeconds    *int32                     `json:"degradedGracePeriodSeconds,omitempty"`
+	VolumeSnapshot                *VolumeSnapshotConfig      `json:"volumeSnapshot,omitempty"`
+	RestoreFrom                   *RestoreFrom               `json:"restoreFrom,omitempty"`
+	TCPKeepalive                  *int32                     `json:"tcpKeepalive,omitempty"`
+	ClientTimeout                 *int32                     `json:"clientTimeout,omitempty"`
+	Proxy                         *RedisProxy                `json:"proxy,omitempty"`
+	Replication                   *ReplicationConfig         `json:"replication,omitempty"`
+	Finalizer                     *FinalizerConfig           `json:"finalizer,omitempty"`
+	Persistence                   *PersistenceConfig         `json:"persistence,omitempty"`
+	ProtoMaxBulkLen               *string                    `json:"protoMaxBulkLen,omitempty"`
+	ClusterInit                   *ClusterInit               `json:"clusterInit,omitempty"`
+	StartupProbe                  *StartupProbe              `json:"startupProbe,omitempty"`
+	ActiveDefrag                  *ActiveDefragConfig        `json:"activeDefrag,omitempty"`
+	// +kubebuilder:validation:Enum=noeviction;allkeys-lru;allkeys-lfu;allkeys-random;volatile-lru;volatile-lfu;volatile-random;volatile-ttl
+	MaxMemoryPolicy *string             `json:"maxMemoryPolicy,omitempty"`
+	NetworkPolicy   *RedisNetworkPolicy `json:"networkPolicy,omitempty"`
+	// +kubebuilder:validation:Enum=debug;verbose;notice;warning
+	LogLevel             *string `json:"logLevel,omitempty"`
+	Databases            *int32  `json:"databases,omitempty"`
+	NotifyKeyspaceEvents *string `json:"notifyKeyspaceEvents,omitempty"`
+	// +kubebuilder:validation:Enum=no;upstart;systemd;auto
+	Supervised *string `json:"supervised,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	TCPBacklog *int32 `json:"tcpBacklog,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=500
+	Hz        *int32 `json:"hz,omitempty"`
+	DynamicHz *bool  `json:"dynamicHz,omitempty"`
+	// Sidecars are extra containers added to the redis pods after the redis and exporter containers
+	Sidecars          []corev1.Container `json:"sidecars,omitempty"`
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	WriteService      *WriteService      `json:"writeService,omitempty"`
+	Import            *Import            `json:"import,omitempty"`
+	// ZoneTopology places the replicas of each redis cluster shard in other zones than its master with spread, or in
+	// the zone of its master with colocate, while the masters are spread across the zones
+	// +kubebuilder:validation:Enum=spread;colocate
+	ZoneTopology *string `json:"zoneTopology,omitempty"`
+	// +kubebuilder:validation:Enum=OrderedReady;Parallel
+	PodManagementPolicy appsv1.PodManagementPolicyType `json:"podManagementPolicy,omitempty"`
 }
 
 // RedisStatus defines the observed state of Redis
 type RedisStatus struct {
-	Cluster RedisSpec `json:"cluster,omitempty"`
+	Cluster           RedisSpec            `json:"cluster,omitempty"`
+	ShardTopology     []ShardTopology      `json:"shardTopology,omitempty"`
+	Masters           map[string]string    `json:"masters,omitempty"`
+	ACLChecksum       string               `json:"aclChecksum,omitempty"`
+	ACLStatus         []ACLLoadStatus      `json:"aclStatus,omitempty"`
+	TopologyExport    string               `json:"topologyExport,omitempty"`
+	Conditions        []metav1.Condition   `json:"conditions,omitempty"`
+	FunctionsChecksum string               `json:"functionsChecksum,omitempty"`
+	FunctionsStatus   []FunctionLoadStatus `json:"functionsStatus,omitempty"`
+	ReplicaOf         *ReplicaOfStatus     `json:"replicaOf,omitempty"`
+	DegradedSince     *metav1.Time         `json:"degradedSince,omitempty"`
+	LastAOFRewrite    *metav1.Time         `json:"lastAOFRewrite,omitempty"`
+	VolumeSnapshots   []VolumeSnapshotRef  `json:"volumeSnapshots,omitempty"`
+	ReplicationLag    []ReplicationLag     `json:"replicationLag,omitempty"`
+	ClusterInit       *ClusterInitStatus   `json:"clusterInit,omitempty"`
+	LastReconcileTime *metav1.Time         `json:"lastReconcileTime,omitempty"`
+	// +kubebuilder:validation:Enum=Success;Error
+	LastReconcileResult string              `json:"lastReconcileResult,omitempty"`
+	LastError           string              `json:"lastError,omitempty"`
+	ShardRemoval        *ShardRemovalStatus `json:"shardRemoval,omitempty"`
+	Import              *ImportStatus       `json:"import,omitempty"`
+	VolumeSnapshotRun   *VolumeSnapshotRun  `json:"volumeSnapshotRun,omitempty"`
+	PodRestarts         []PodRestartStatus  `json:"podRestarts,omitempty"`
+	AOFRewriteRun       *AOFRewriteRun      `json:"aofRewriteRun,omitempty"`
+}
+
+// ACLLoadStatus is the result of reloading the ACL file on a redis pod
+type ACLLoadStatus struct {
+	PodName string `json:"podName"`
+	Loaded  bool   `json:"loaded"`
+	Message string `json:"message,omitempty"`
+}
+
+// ShardTopology describes where the master and replicas of a redis cluster shard are placed
+type ShardTopology struct {
+	Master   NodePlacement   `json:"master"`
+	Replicas []NodePlacement `json:"replicas,omitempty"`
+}
+
+// NodePlacement describes the pod, node and zone of a redis cluster node
+type NodePlacement struct {
+	PodName  string `json:"podName"`
+	NodeName string `json:"nodeName,omitempty"`
+	Zone     string `json:"zone,omitempty"`
+}
+
+// VolumeSnapshotConfig will take CSI volume snapshots of the redis master volumes on a fixed interval, keeping the
+// last retention snapshots of every volume
+type VolumeSnapshotConfig struct {
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName"`
+	Interval                string `json:"interval,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	Retention *int32 `json:"retention,omitempty"`
+}
+
+// RestoreFrom is the data restored when the redis setup is created, the volume snapshots are listed in the order
+// of the master ordinals so that each shard restores its own data
+type RestoreFrom struct {
+	VolumeSnapshots []string `json:"volumeSnapshots"`
+}
+
+// VolumeSnapshotRef references a volume snapshot taken of the volume of a redis master
+type VolumeSnapshotRef struct {
+	Name         string      `json:"name"`
+	PodName      string      `json:"podName"`
+	PVCName      string      `json:"pvcName"`
+	CreationTime metav1.Time `json:"creationTime"`
+}
+
+// VolumeSnapshotRun is the last run of volume snapshots, the pending pods are snapshotted once their BGSAVE is done
+type VolumeSnapshotRun struct {
+	StartTime   metav1.Time             `json:"startTime"`
+	PendingPods []PendingVolumeSnapshot `json:"pendingPods,omitempty"`
+}
+
+// PendingVolumeSnapshot is a redis master whose volume is snapshotted once it saved its RDB file after lastSave, the
+// unix time of its previous save
+type PendingVolumeSnapshot struct {
+	PodName  string `json:"podName"`
+	LastSave int64  `json:"lastSave"`
+}
+
+// AOFRewriteRun is the scheduled rewrite of the append only files in progress, the redis pods are rewritten one at a
+// time in order and nextPod is the index of the next pod to rewrite
+type AOFRewriteRun struct {
+	StartTime    metav1.Time  `json:"startTime"`
+	NextPod      int32        `json:"nextPod"`
+	RewritingPod string       `json:"rewritingPod,omitempty"`
+	PodStartTime *metav1.Time `json:"podStartTime,omitempty"`
 }
 
 // Storage is the inteface to add pvc and pv support in redis
 type Storage struct {
 	VolumeClaimTemplate corev1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	NearFullThreshold   *int32                       `json:"nearFullThreshold,omitempty"`
+}
+
+// FunctionLoadStatus is the result of loading a redis function library on a redis pod
+type FunctionLoadStatus struct {
+	PodName string `json:"podName"`
+	Library string `json:"library"`
+	Loaded  bool   `json:"loaded"`
+	Message string `json:"message,omitempty"`
+}
+
+// FunctionsConfig is the configmap holding the redis function libraries, one library per key
+type FunctionsConfig struct {
+	ConfigMap string `json:"configMap"`
+}
+
+// ACLConfig is the configmap holding the redis ACL file
+type ACLConfig struct {
+	ConfigMap string `json:"configMap"`
+	Key       string `json:"key,omitempty"`
+}
+
+// ReplicaOf is the external redis primary replicated by a standalone redis
+type ReplicaOf struct {
+	Host           string                  `json:"host"`
+	Port           *int32                  `json:"port,omitempty"`
+	PasswordSecret *ExistingPasswordSecret `json:"passwordSecret,omitempty"`
+}
+
+// ReplicaOfStatus is the replication state of a standalone redis replicating an external primary
+type ReplicaOfStatus struct {
+	LinkStatus       string `json:"linkStatus,omitempty"`
+	LastIOSecondsAgo int64  `json:"lastIOSecondsAgo,omitempty"`
+	LinkDownSeconds  int64  `json:"linkDownSeconds,omitempty"`
+	ReplOffset       int64  `json:"replOffset,omitempty"`
+	// MasterReplOffset is the replication offset of the external primary, read when it is reachable from the operator
+	MasterReplOffset int64 `json:"masterReplOffset,omitempty"`
+	// LagBytes is the number of bytes of the replication stream of the external primary which the redis hasn't
+	// processed yet
+	LagBytes int64 `json:"lagBytes,omitempty"`
+}
+
+// Import is the external redis whose keys are copied into the redis cluster once it is formed
+type Import struct {
+	Host           string                  `json:"host"`
+	Port           *int32                  `json:"port,omitempty"`
+	PasswordSecret *ExistingPasswordSecret `json:"passwordSecret,omitempty"`
+	// Replace overwrites the keys which already exist in the redis cluster, the import fails on them otherwise
+	Replace bool `json:"replace,omitempty"`
+	// Image runs the import job with this image instead of the redis image
+	Image string `json:"image,omitempty"`
+}
+
+// PodRestartStatus is the restartedAt annotation and the checksum of the secrets set on the pod template of the
+// statefulset of the role, which follow the redis resource and its secrets one role at a time
+type PodRestartStatus struct {
+	Role           string `json:"role"`
+	RestartedAt    string `json:"restartedAt,omitempty"`
+	SecretChecksum string `json:"secretChecksum,omitempty"`
+}
+
+// ImportStatus is the progress of importing the keys of the external redis into the redis cluster
+type ImportStatus struct {
+	// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+	Phase          string       `json:"phase"`
+	JobName        string       `json:"jobName,omitempty"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	SourceKeys     int64        `json:"sourceKeys,omitempty"`
+	ImportedKeys   int64        `json:"importedKeys,omitempty"`
+	Message        string       `json:"message,omitempty"`
+}
+
+// ShardOverride is the redis configuration applied with CONFIG SET to the nodes of a single redis cluster shard,
+// the shard index being the ordinal of its initial master pod
+type ShardOverride struct {
+	Shard       int32             `json:"shard"`
+	RedisConfig map[string]string `json:"redisConfig"`
+}
+
+// InitContainer will have the settings shared by the init containers of the redis pods
+type InitContainer struct {
+	Resources *Resources `json:"resources,omitempty"`
+}
+
+// DNSWait is the init container which waits for the headless service DNS to resolve the pod
+type DNSWait struct {
+	Enabled         bool              `json:"enabled,omitempty"`
+	Image           string            `json:"image,omitempty"`
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+}
+
+// Probes overrides the exec command and the timing of the redis liveness and readiness probes
+type Probes struct {
+	Command   []string     `json:"command,omitempty"`
+	Liveness  *ProbeTiming `json:"liveness,omitempty"`
+	Readiness *ProbeTiming `json:"readiness,omitempty"`
+	// ReplicationSync keeps a replica not ready until its sync with its master completed and the link is up, enabled
+	// by default for a standalone redis replicating an external primary
+	ReplicationSync *bool `json:"replicationSync,omitempty"`
+}
+
+// MaintenanceWindow confines the disruptive operations of the operator, like rolling restarts, upgrades and replica
+// rebalances, to the days and time range of the window. Outside of it they are deferred.
+type MaintenanceWindow struct {
+	// Days are the days on which the window starts, every day when empty
+	// +kubebuilder:validation:items:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+	Days []string `json:"days,omitempty"`
+	// Start is the time of day the window opens, as HH:MM
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the time of day the window closes, as HH:MM. An end before the start closes the window the next day.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// TimeZone is the IANA time zone of the start and end, UTC when empty
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ProbeTiming overrides the timing of a redis probe, unset fields keep the default
+type ProbeTiming struct {
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32 `json:"failureThreshold,omitempty"`
+}
+
+// StartupProbe holds the liveness and readiness probes of redis back until it finished loading its data
+type StartupProbe struct {
+	Enabled          *bool  `json:"enabled,omitempty"`
+	PeriodSeconds    *int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds   *int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// RedisPodDisruptionBudget enables the pod disruption budgets for redis cluster masters and slaves, either one per
+// role or a single one across all pods of the cluster
+type RedisPodDisruptionBudget struct {
+	Enabled        bool   `json:"enabled,omitempty"`
+	Scope          string `json:"scope,omitempty"`
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+	// +kubebuilder:validation:Enum=majority;all-but-one;custom
+	QuorumFormula    string                            `json:"quorumFormula,omitempty"`
+	MinAvailable     *int32                            `json:"minAvailable,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// RedisNetworkPolicy generates a network policy only allowing the peers and the redis pods themselves to reach redis
+type RedisNetworkPolicy struct {
+	Enabled bool                             `json:"enabled,omitempty"`
+	From    []networkingv1.NetworkPolicyPeer `json:"from,omitempty"`
+}
+
+// AOFConfig will have the redis append only file settings, directives unsupported by the redis version are skipped
+type AOFConfig struct {
+	TimestampEnabled *bool   `json:"timestampEnabled,omitempty"`
+	UseRDBPreamble   *bool   `json:"useRDBPreamble,omitempty"`
+	DirName          *string `json:"dirName,omitempty"`
+}
+
+// ClusterInit will have how long and how often the operator tries to form the redis cluster before it reports the
+// formation as failed
+type ClusterInit struct {
+	TimeoutSeconds *int32   `json:"timeoutSeconds,omitempty"`
+	MaxRetries     *int32   `json:"maxRetries,omitempty"`
+	Command        []string `json:"command,omitempty"`
+	// Image runs the redis-cli admin commands in jobs with this image instead of in the redis containers
+	Image string `json:"image,omitempty"`
+}
+
+// ClusterInitStatus is the progress of forming the redis cluster
+type ClusterInitStatus struct {
+	StartTime    metav1.Time `json:"startTime"`
+	Attempts     int32       `json:"attempts"`
+	JoinedNodes  int32       `json:"joinedNodes"`
+	DesiredNodes int32       `json:"desiredNodes"`
+}
+
+// ShardRemovalStatus is the progress of removing redis cluster shards after the size is decreased, the statefulsets
+// keep the master and slave replicas they had until the removed shards are drained
+type ShardRemovalStatus struct {
+	StartTime metav1.Time `json:"startTime"`
+	Masters   int32       `json:"masters"`
+	Slaves    int32       `json:"slaves"`
+	// +kubebuilder:validation:Enum=MigratingSlots;ReassigningReplicas;ForgettingNodes
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ActiveDefragConfig will have the redis active defragmentation settings, the thresholds are percentages of
+// fragmentation and the cycle limits are percentages of CPU time
+type ActiveDefragConfig struct {
+	Enabled        *bool   `json:"enabled,omitempty"`
+	IgnoreBytes    *string `json:"ignoreBytes,omitempty"`
+	ThresholdLower *int32  `json:"thresholdLower,omitempty"`
+	ThresholdUpper *int32  `json:"thresholdUpper,omitempty"`
+	CycleMin       *int32  `json:"cycleMin,omitempty"`
+	CycleMax       *int32  `json:"cycleMax,omitempty"`
+}
+
+// PersistenceConfig will have the redis RDB snapshot and append only file settings
+type PersistenceConfig struct {
+	DisableRDB         bool                `json:"disableRDB,omitempty"`
+	AOFRewriteSchedule *AOFRewriteSchedule `json:"aofRewriteSchedule,omitempty"`
+}
+
+// AOFRewriteSchedule will rewrite the append only files on a cron schedule instead of whenever they grow, so that
+// the rewrites run during off-peak hours
+type AOFRewriteSchedule struct {
+	// Schedule is a cron expression with the minute, hour, day of month, month and day of week fields, in UTC
+	Schedule string `json:"schedule"`
+}
+
+// FinalizerConfig will have the steps run in order when the redis resource is deleted, taking a final volume
+// snapshot, shutting down the redis pods gracefully and deleting their persistent volume claims
+type FinalizerConfig struct {
+	FinalSnapshot      bool   `json:"finalSnapshot,omitempty"`
+	DeletePVCs         bool   `json:"deletePVCs,omitempty"`
+	StepTimeoutSeconds *int32 `json:"stepTimeoutSeconds,omitempty"`
+}
+
+// ReplicationConfig will have the redis diskless replication settings and the replication lag reported as high
+type ReplicationConfig struct {
+	DisklessSync      *bool  `json:"disklessSync,omitempty"`
+	DisklessSyncDelay *int32 `json:"disklessSyncDelay,omitempty"`
+	// +kubebuilder:validation:Enum=disabled;on-empty-db;swapdb
+	DisklessLoad      *string `json:"disklessLoad,omitempty"`
+	LagThresholdBytes *int64  `json:"lagThresholdBytes,omitempty"`
+	BacklogSize       *string `json:"backlogSize,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	BacklogTTL *int32 `json:"backlogTTL,omitempty"`
+}
+
+// ReplicationLag is the replication lag in bytes of a redis replica behind its master
+type ReplicationLag struct {
+	PodName       string `json:"podName"`
+	MasterPodName string `json:"masterPodName"`
+	LagBytes      int64  `json:"lagBytes"`
+}
+
+// ClientOutputBufferLimit will have the redis client output buffer limits of each client class,
+// each one formatted as <hard limit> <soft limit> <soft seconds>
+type ClientOutputBufferLimit struct {
+	Normal  *string `json:"normal,omitempty"`
+	Replica *string `json:"replica,omitempty"`
+	PubSub  *string `json:"pubsub,omitempty"`
 }
 
 // RedisMaster interface will have the redis master configuration
 type RedisMaster struct {
-	Resources   Resources         `json:"resources,omitempty"`
-	RedisConfig map[string]string `json:"redisConfig,omitempty"`
-	Service     Service           `json:"service,omitempty"`
+	Resources          Resources         `json:"resources,omitempty"`
+	RedisConfig        map[string]string `json:"redisConfig,omitempty"`
+	Service            Service           `json:"service,omitempty"`
+	ServiceAccountName *string           `json:"serviceAccountName,omitempty"`
+	RuntimeClassName   *string           `json:"runtimeClassName,omitempty"`
+	Probes             *Probes           `json:"probes,omitempty"`
+	StartupProbe       *StartupProbe     `json:"startupProbe,omitempty"`
+	// EntrypointConfigMap is a script run as the command of the redis containers, with the entrypoint of the redis
+	// image as its arguments
+	EntrypointConfigMap *EntrypointConfigMap `json:"entrypointConfigMap,omitempty"`
+}
+
+// EntrypointConfigMap is the configmap holding a custom entrypoint script of the redis containers, the script must
+// end with exec "$@" so that the entrypoint of the redis image starts redis-server
+type EntrypointConfigMap struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
 }
 
 // RedisExporter interface will have the information for redis exporter related stuff
@@ -66,6 +468,24 @@ type RedisExporter struct {
 	Image           string            `json:"image"`
 	Resources       *Resources        `json:"resources,omitempty"`
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	PasswordFile    bool              `json:"passwordFile,omitempty"`
+	Probe           *ExporterProbe    `json:"probe,omitempty"`
+	ExtraArgs       []string          `json:"extraArgs,omitempty"`
+	Env             []corev1.EnvVar   `json:"env,omitempty"`
+	// Port is the port the redis exporter listens on, it defaults to 9121 and must not be the redis or cluster bus port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port *int32 `json:"port,omitempty"`
+}
+
+// ExporterProbe overrides the HTTP liveness and readiness probes of the redis exporter
+type ExporterProbe struct {
+	Disabled            bool   `json:"disabled,omitempty"`
+	Path                string `json:"path,omitempty"`
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32 `json:"failureThreshold,omitempty"`
 }
 
 // GlobalConfig will be the JSON struct for Basic Redis Config
@@ -75,6 +495,14 @@ type GlobalConfig struct {
 	Password               *string                 `json:"password,omitempty"`
 	Resources              *Resources              `json:"resources,omitempty"`
 	ExistingPasswordSecret *ExistingPasswordSecret `json:"existingPasswordSecret,omitempty"`
+	ServiceAccountName     *string                 `json:"serviceAccountName,omitempty"`
+	CreateServiceAccount   bool                    `json:"createServiceAccount,omitempty"`
+	ResourceNamePrefix     string                  `json:"resourceNamePrefix,omitempty"`
+	Labels                 map[string]string       `json:"labels,omitempty"`
+	Annotations            map[string]string       `json:"annotations,omitempty"`
+	StatefulSetAnnotations map[string]string       `json:"statefulSetAnnotations,omitempty"`
+	GeneratePassword       bool                    `json:"generatePassword,omitempty"`
+	RuntimeClassName       *string                 `json:"runtimeClassName,omitempty"`
 }
 
 type ExistingPasswordSecret struct {
@@ -84,9 +512,37 @@ type ExistingPasswordSecret struct {
 
 // RedisSlave interface will have the redis slave configuration
 type RedisSlave struct {
-	Resources   Resources         `json:"resources,omitempty"`
-	RedisConfig map[string]string `json:"redisConfig,omitempty"`
-	Service     Service           `json:"service,omitempty"`
+	Resources             Resources         `json:"resources,omitempty"`
+	RedisConfig           map[string]string `json:"redisConfig,omitempty"`
+	Service               Service           `json:"service,omitempty"`
+	ServiceAccountName    *string           `json:"serviceAccountName,omitempty"`
+	Replicas              *int32            `json:"replicas,omitempty"`
+	ReplicaReadOnly       *bool             `json:"replicaReadOnly,omitempty"`
+	ReplicaServeStaleData *bool             `json:"replicaServeStaleData,omitempty"`
+	RuntimeClassName      *string           `json:"runtimeClassName,omitempty"`
+	ReplicaPriority       *ReplicaPriority  `json:"replicaPriority,omitempty"`
+	Probes                *Probes           `json:"probes,omitempty"`
+	StartupProbe          *StartupProbe     `json:"startupProbe,omitempty"`
+	// EntrypointConfigMap is a script run as the command of the redis containers, with the entrypoint of the redis
+	// image as its arguments
+	EntrypointConfigMap *EntrypointConfigMap `json:"entrypointConfigMap,omitempty"`
+	// ReplicaAssignment picks the master replicated by a slave joining the cluster, the master with the fewest
+	// replicas by default or the master of the same ordinal modulo the size with roundRobin
+	// +kubebuilder:validation:Enum=leastReplicated;roundRobin
+	ReplicaAssignment string `json:"replicaAssignment,omitempty"`
+}
+
+// ReplicaPriority sets the redis replica-priority of the slave pods, replicas with a lower priority are preferred
+// for promotion and a priority of 0 is never promoted
+type ReplicaPriority struct {
+	Default *int32                `json:"default,omitempty"`
+	Rules   []ReplicaPriorityRule `json:"rules,omitempty"`
+}
+
+// ReplicaPriorityRule sets the replica priority of the slave pods with the ordinals
+type ReplicaPriorityRule struct {
+	Ordinals []int32 `json:"ordinals"`
+	Priority int32   `json:"priority"`
 }
 
 // ResourceDescription describes CPU and memory resources defined for a cluster.
@@ -97,7 +553,27 @@ type ResourceDescription struct {
 
 // Service is the struct for service definition
 type Service struct {
-	Type string `json:"type"`
+	Type     string `json:"type"`
+	PortName string `json:"portName,omitempty"`
+}
+
+// WriteService labels the redis cluster pods with their live role and exposes the current masters through a service,
+// which follows the failovers
+type WriteService struct {
+	Enabled bool    `json:"enabled,omitempty"`
+	Service Service `json:"service,omitempty"`
+}
+
+// RedisProxy will deploy a cluster aware proxy like redis-cluster-proxy in front of the redis cluster, for clients
+// which do not support the cluster protocol
+type RedisProxy struct {
+	Image           string            `json:"image"`
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	Replicas        *int32            `json:"replicas,omitempty"`
+	Port            *int32            `json:"port,omitempty"`
+	Args            []string          `json:"args,omitempty"`
+	Resources       *Resources        `json:"resources,omitempty"`
+	Service         Service           `json:"service,omitempty"`
 }
 
 // Resources describes requests and limits for the cluster resouces.
@@ -108,6 +584,9 @@ type Resources struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Last Reconcile",type="string",JSONPath=".status.lastReconcileResult",description="Result of the last reconcile"
+// +kubebuilder:printcolumn:name="Last Reconcile Time",type="date",JSONPath=".status.lastReconcileTime",description="Time of the last reconcile",priority=1
+// +kubebuilder:printcolumn:name="Last Error",type="string",JSONPath=".status.lastError",description="Error of the last failed reconcile",priority=1
 
 // Redis is the Schema for the redis API
 type Redis struct {
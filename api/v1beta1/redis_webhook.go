@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Opstree Solutions.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// DefaultRedisExporterPort is the port the redis exporter listens on when none is set
+	DefaultRedisExporterPort = 9121
+	// redisPort and redisClusterBusPort are the ports of redis and of the redis cluster bus, the exporter can't use them
+	redisPort           = 6379
+	redisClusterBusPort = redisPort + 10000
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks of Redis
+func (r *Redis) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-redis-redis-opstreelabs-in-v1beta1-redis,mutating=true,failurePolicy=fail,sideEffects=None,groups=redis.redis.opstreelabs.in,resources=redis,verbs=create;update,versions=v1beta1,name=mredis.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &Redis{}
+
+// Default implements webhook.Defaulter, it sets the port of the redis exporter when it is enabled without one
+func (r *Redis) Default() {
+	if r.Spec.RedisExporter != nil && r.Spec.RedisExporter.Enabled && r.Spec.RedisExporter.Port == nil {
+		port := int32(DefaultRedisExporterPort)
+		r.Spec.RedisExporter.Port = &port
+	}
+}
+
+// +kubebuilder:webhook:path=/validate-redis-redis-opstreelabs-in-v1beta1-redis,mutating=false,failurePolicy=fail,sideEffects=None,groups=redis.redis.opstreelabs.in,resources=redis,verbs=create;update,versions=v1beta1,name=vredis.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &Redis{}
+
+// ValidateCreate implements webhook.Validator
+func (r *Redis) ValidateCreate() error {
+	return validateExporterPort(r)
+}
+
+// ValidateUpdate implements webhook.Validator, it rejects decreasing the storage size since volumes can only grow
+func (r *Redis) ValidateUpdate(old runtime.Object) error {
+	oldRedis, ok := old.(*Redis)
+	if !ok {
+		return fmt.Errorf("expected a Redis but got a %T", old)
+	}
+	oldSize, oldOK := getStorageSize(oldRedis)
+	newSize, newOK := getStorageSize(r)
+	if oldOK && newOK && newSize.Cmp(oldSize) < 0 {
+		return fmt.Errorf("storage size can't be decreased from %s to %s, persistent volume claims can only grow", oldSize.String(), newSize.String())
+	}
+	return validateExporterPort(r)
+}
+
+// ValidateDelete implements webhook.Validator
+func (r *Redis) ValidateDelete() error {
+	return nil
+}
+
+// getStorageSize returns the storage size requested by the volume claim template
+func getStorageSize(r *Redis) (resource.Quantity, bool) {
+	if r.Spec.Storage == nil {
+		return resource.Quantity{}, false
+	}
+	size, ok := r.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+	return size, ok
+}
+
+// validateExporterPort rejects an exporter port overlapping the redis port or the cluster bus port, the containers of a
+// pod share its ports
+func validateExporterPort(r *Redis) error {
+	if r.Spec.RedisExporter == nil || r.Spec.RedisExporter.Port == nil {
+		return nil
+	}
+	switch port := *r.Spec.RedisExporter.Port; port {
+	case redisPort:
+		return fmt.Errorf("redisExporter.port %d overlaps the redis port", port)
+	case redisClusterBusPort:
+		return fmt.Errorf("redisExporter.port %d overlaps the redis cluster bus port", port)
+	}
+	return nil
+}
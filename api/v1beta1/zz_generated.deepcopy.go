@@ -22,9 +22,242 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACLConfig) DeepCopyInto(out *ACLConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACLConfig.
+func (in *ACLConfig) DeepCopy() *ACLConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ACLConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACLLoadStatus) DeepCopyInto(out *ACLLoadStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACLLoadStatus.
+func (in *ACLLoadStatus) DeepCopy() *ACLLoadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ACLLoadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AOFConfig) DeepCopyInto(out *AOFConfig) {
+	*out = *in
+	if in.TimestampEnabled != nil {
+		in, out := &in.TimestampEnabled, &out.TimestampEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UseRDBPreamble != nil {
+		in, out := &in.UseRDBPreamble, &out.UseRDBPreamble
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DirName != nil {
+		in, out := &in.DirName, &out.DirName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AOFConfig.
+func (in *AOFConfig) DeepCopy() *AOFConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AOFConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AOFRewriteRun) DeepCopyInto(out *AOFRewriteRun) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.PodStartTime != nil {
+		in, out := &in.PodStartTime, &out.PodStartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AOFRewriteRun.
+func (in *AOFRewriteRun) DeepCopy() *AOFRewriteRun {
+	if in == nil {
+		return nil
+	}
+	out := new(AOFRewriteRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveDefragConfig) DeepCopyInto(out *ActiveDefragConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IgnoreBytes != nil {
+		in, out := &in.IgnoreBytes, &out.IgnoreBytes
+		*out = new(string)
+		**out = **in
+	}
+	if in.ThresholdLower != nil {
+		in, out := &in.ThresholdLower, &out.ThresholdLower
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ThresholdUpper != nil {
+		in, out := &in.ThresholdUpper, &out.ThresholdUpper
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CycleMin != nil {
+		in, out := &in.CycleMin, &out.CycleMin
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CycleMax != nil {
+		in, out := &in.CycleMax, &out.CycleMax
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveDefragConfig.
+func (in *ActiveDefragConfig) DeepCopy() *ActiveDefragConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveDefragConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientOutputBufferLimit) DeepCopyInto(out *ClientOutputBufferLimit) {
+	*out = *in
+	if in.Normal != nil {
+		in, out := &in.Normal, &out.Normal
+		*out = new(string)
+		**out = **in
+	}
+	if in.Replica != nil {
+		in, out := &in.Replica, &out.Replica
+		*out = new(string)
+		**out = **in
+	}
+	if in.PubSub != nil {
+		in, out := &in.PubSub, &out.PubSub
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientOutputBufferLimit.
+func (in *ClientOutputBufferLimit) DeepCopy() *ClientOutputBufferLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientOutputBufferLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterInit) DeepCopyInto(out *ClusterInit) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterInit.
+func (in *ClusterInit) DeepCopy() *ClusterInit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterInit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterInitStatus) DeepCopyInto(out *ClusterInitStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterInitStatus.
+func (in *ClusterInitStatus) DeepCopy() *ClusterInitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterInitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSWait) DeepCopyInto(out *DNSWait) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSWait.
+func (in *DNSWait) DeepCopy() *DNSWait {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSWait)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EntrypointConfigMap) DeepCopyInto(out *EntrypointConfigMap) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EntrypointConfigMap.
+func (in *EntrypointConfigMap) DeepCopy() *EntrypointConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(EntrypointConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExistingPasswordSecret) DeepCopyInto(out *ExistingPasswordSecret) {
 	*out = *in
@@ -33,136 +266,1259 @@ func (in *ExistingPasswordSecret) DeepCopyInto(out *ExistingPasswordSecret) {
 		*out = new(string)
 		**out = **in
 	}
-	if in.Key != nil {
-		in, out := &in.Key, &out.Key
-		*out = new(string)
-		**out = **in
+	if in.Key != nil {
+		in, out := &in.Key, &out.Key
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExistingPasswordSecret.
+func (in *ExistingPasswordSecret) DeepCopy() *ExistingPasswordSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(ExistingPasswordSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExporterProbe) DeepCopyInto(out *ExporterProbe) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExporterProbe.
+func (in *ExporterProbe) DeepCopy() *ExporterProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(ExporterProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FinalizerConfig) DeepCopyInto(out *FinalizerConfig) {
+	*out = *in
+	if in.StepTimeoutSeconds != nil {
+		in, out := &in.StepTimeoutSeconds, &out.StepTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FinalizerConfig.
+func (in *FinalizerConfig) DeepCopy() *FinalizerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FinalizerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionLoadStatus) DeepCopyInto(out *FunctionLoadStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionLoadStatus.
+func (in *FunctionLoadStatus) DeepCopy() *FunctionLoadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionLoadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FunctionsConfig) DeepCopyInto(out *FunctionsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FunctionsConfig.
+func (in *FunctionsConfig) DeepCopy() *FunctionsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FunctionsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
+	*out = *in
+	if in.Password != nil {
+		in, out := &in.Password, &out.Password
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		**out = **in
+	}
+	if in.ExistingPasswordSecret != nil {
+		in, out := &in.ExistingPasswordSecret, &out.ExistingPasswordSecret
+		*out = new(ExistingPasswordSecret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StatefulSetAnnotations != nil {
+		in, out := &in.StatefulSetAnnotations, &out.StatefulSetAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalConfig.
+func (in *GlobalConfig) DeepCopy() *GlobalConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GlobalConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InitContainer) DeepCopyInto(out *InitContainer) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InitContainer.
+func (in *InitContainer) DeepCopy() *InitContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(InitContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePlacement) DeepCopyInto(out *NodePlacement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePlacement.
+func (in *NodePlacement) DeepCopy() *NodePlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingVolumeSnapshot) DeepCopyInto(out *PendingVolumeSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingVolumeSnapshot.
+func (in *PendingVolumeSnapshot) DeepCopy() *PendingVolumeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingVolumeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistenceConfig) DeepCopyInto(out *PersistenceConfig) {
+	*out = *in
+	if in.AOFRewriteSchedule != nil {
+		in, out := &in.AOFRewriteSchedule, &out.AOFRewriteSchedule
+		*out = new(AOFRewriteSchedule)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistenceConfig.
+func (in *PersistenceConfig) DeepCopy() *PersistenceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistenceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodRestartStatus) DeepCopyInto(out *PodRestartStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRestartStatus.
+func (in *PodRestartStatus) DeepCopy() *PodRestartStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PodRestartStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Probes) DeepCopyInto(out *Probes) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(ProbeTiming)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ProbeTiming)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicationSync != nil {
+		in, out := &in.ReplicationSync, &out.ReplicationSync
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Probes.
+func (in *Probes) DeepCopy() *Probes {
+	if in == nil {
+		return nil
+	}
+	out := new(Probes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeTiming) DeepCopyInto(out *ProbeTiming) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeTiming.
+func (in *ProbeTiming) DeepCopy() *ProbeTiming {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeTiming)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Redis) DeepCopyInto(out *Redis) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Redis.
+func (in *Redis) DeepCopy() *Redis {
+	if in == nil {
+		return nil
+	}
+	out := new(Redis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Redis) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisExporter) DeepCopyInto(out *RedisExporter) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		**out = **in
+	}
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(ExporterProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisExporter.
+func (in *RedisExporter) DeepCopy() *RedisExporter {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisExporter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisList) DeepCopyInto(out *RedisList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Redis, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisList.
+func (in *RedisList) DeepCopy() *RedisList {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RedisList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisMaster) DeepCopyInto(out *RedisMaster) {
+	*out = *in
+	out.Resources = in.Resources
+	if in.RedisConfig != nil {
+		in, out := &in.RedisConfig, &out.RedisConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Service = in.Service
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(Probes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(StartupProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EntrypointConfigMap != nil {
+		in, out := &in.EntrypointConfigMap, &out.EntrypointConfigMap
+		*out = new(EntrypointConfigMap)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisMaster.
+func (in *RedisMaster) DeepCopy() *RedisMaster {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisMaster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisNetworkPolicy) DeepCopyInto(out *RedisNetworkPolicy) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisNetworkPolicy.
+func (in *RedisNetworkPolicy) DeepCopy() *RedisNetworkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisNetworkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisPodDisruptionBudget) DeepCopyInto(out *RedisPodDisruptionBudget) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisPodDisruptionBudget.
+func (in *RedisPodDisruptionBudget) DeepCopy() *RedisPodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisPodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisProxy) DeepCopyInto(out *RedisProxy) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		**out = **in
+	}
+	out.Service = in.Service
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisProxy.
+func (in *RedisProxy) DeepCopy() *RedisProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisSlave) DeepCopyInto(out *RedisSlave) {
+	*out = *in
+	out.Resources = in.Resources
+	if in.RedisConfig != nil {
+		in, out := &in.RedisConfig, &out.RedisConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Service = in.Service
+	if in.ServiceAccountName != nil {
+		in, out := &in.ServiceAccountName, &out.ServiceAccountName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReplicaReadOnly != nil {
+		in, out := &in.ReplicaReadOnly, &out.ReplicaReadOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReplicaServeStaleData != nil {
+		in, out := &in.ReplicaServeStaleData, &out.ReplicaServeStaleData
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ReplicaPriority != nil {
+		in, out := &in.ReplicaPriority, &out.ReplicaPriority
+		*out = new(ReplicaPriority)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(Probes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(StartupProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EntrypointConfigMap != nil {
+		in, out := &in.EntrypointConfigMap, &out.EntrypointConfigMap
+		*out = new(EntrypointConfigMap)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisSlave.
+func (in *RedisSlave) DeepCopy() *RedisSlave {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisSlave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisSpec) DeepCopyInto(out *RedisSpec) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(int32)
+		**out = **in
+	}
+	in.GlobalConfig.DeepCopyInto(&out.GlobalConfig)
+	out.Service = in.Service
+	in.Master.DeepCopyInto(&out.Master)
+	in.Slave.DeepCopyInto(&out.Slave)
+	if in.RedisExporter != nil {
+		in, out := &in.RedisExporter, &out.RedisExporter
+		*out = new(RedisExporter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RedisConfig != nil {
+		in, out := &in.RedisConfig, &out.RedisConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(Resources)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = new([]v1.Toleration)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make([]v1.Toleration, len(*in))
+			for i := range *in {
+				(*in)[i].DeepCopyInto(&(*out)[i])
+			}
+		}
+	}
+	if in.ACL != nil {
+		in, out := &in.ACL, &out.ACL
+		*out = new(ACLConfig)
+		**out = **in
+	}
+	if in.DNSWait != nil {
+		in, out := &in.DNSWait, &out.DNSWait
+		*out = new(DNSWait)
+		**out = **in
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(Probes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(RedisPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AOF != nil {
+		in, out := &in.AOF, &out.AOF
+		*out = new(AOFConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShutdownTimeout != nil {
+		in, out := &in.ShutdownTimeout, &out.ShutdownTimeout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxClients != nil {
+		in, out := &in.MaxClients, &out.MaxClients
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = new(FunctionsConfig)
+		**out = **in
+	}
+	if in.InitContainer != nil {
+		in, out := &in.InitContainer, &out.InitContainer
+		*out = new(InitContainer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaOf != nil {
+		in, out := &in.ReplicaOf, &out.ReplicaOf
+		*out = new(ReplicaOf)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShardOverrides != nil {
+		in, out := &in.ShardOverrides, &out.ShardOverrides
+		*out = make([]ShardOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.QuarantinePods != nil {
+		in, out := &in.QuarantinePods, &out.QuarantinePods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClientOutputBufferLimit != nil {
+		in, out := &in.ClientOutputBufferLimit, &out.ClientOutputBufferLimit
+		*out = new(ClientOutputBufferLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DegradedGracePeriodSeconds != nil {
+		in, out := &in.DegradedGracePeriodSeconds, &out.DegradedGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.VolumeSnapshot != nil {
+		in, out := &in.VolumeSnapshot, &out.VolumeSnapshot
+		*out = new(VolumeSnapshotConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreFrom != nil {
+		in, out := &in.RestoreFrom, &out.RestoreFrom
+		*out = new(RestoreFrom)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TCPKeepalive != nil {
+		in, out := &in.TCPKeepalive, &out.TCPKeepalive
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ClientTimeout != nil {
+		in, out := &in.ClientTimeout, &out.ClientTimeout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(RedisProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(ReplicationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Finalizer != nil {
+		in, out := &in.Finalizer, &out.Finalizer
+		*out = new(FinalizerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Persistence != nil {
+		in, out := &in.Persistence, &out.Persistence
+		*out = new(PersistenceConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProtoMaxBulkLen != nil {
+		in, out := &in.ProtoMaxBulkLen, &out.ProtoMaxBulkLen
+		*out = new(string)
+		**out = **in
+	}
+	if in.ClusterInit != nil {
+		in, out := &in.ClusterInit, &out.ClusterInit
+		*out = new(ClusterInit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(StartupProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveDefrag != nil {
+		in, out := &in.ActiveDefrag, &out.ActiveDefrag
+		*out = new(ActiveDefragConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxMemoryPolicy != nil {
+		in, out := &in.MaxMemoryPolicy, &out.MaxMemoryPolicy
+		*out = new(string)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(RedisNetworkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(string)
+		**out = **in
+	}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NotifyKeyspaceEvents != nil {
+		in, out := &in.NotifyKeyspaceEvents, &out.NotifyKeyspaceEvents
+		*out = new(string)
+		**out = **in
+	}
+	if in.Supervised != nil {
+		in, out := &in.Supervised, &out.Supervised
+		*out = new(string)
+		**out = **in
+	}
+	if in.TCPBacklog != nil {
+		in, out := &in.TCPBacklog, &out.TCPBacklog
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Hz != nil {
+		in, out := &in.Hz, &out.Hz
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DynamicHz != nil {
+		in, out := &in.DynamicHz, &out.DynamicHz
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WriteService != nil {
+		in, out := &in.WriteService, &out.WriteService
+		*out = new(WriteService)
+		**out = **in
+	}
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(Import)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneTopology != nil {
+		in, out := &in.ZoneTopology, &out.ZoneTopology
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisSpec.
+func (in *RedisSpec) DeepCopy() *RedisSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisStatus) DeepCopyInto(out *RedisStatus) {
+	*out = *in
+	in.Cluster.DeepCopyInto(&out.Cluster)
+	if in.ShardTopology != nil {
+		in, out := &in.ShardTopology, &out.ShardTopology
+		*out = make([]ShardTopology, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ACLStatus != nil {
+		in, out := &in.ACLStatus, &out.ACLStatus
+		*out = make([]ACLLoadStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FunctionsStatus != nil {
+		in, out := &in.FunctionsStatus, &out.FunctionsStatus
+		*out = make([]FunctionLoadStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReplicaOf != nil {
+		in, out := &in.ReplicaOf, &out.ReplicaOf
+		*out = new(ReplicaOfStatus)
+		**out = **in
+	}
+	if in.DegradedSince != nil {
+		in, out := &in.DegradedSince, &out.DegradedSince
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAOFRewrite != nil {
+		in, out := &in.LastAOFRewrite, &out.LastAOFRewrite
+		*out = (*in).DeepCopy()
+	}
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]VolumeSnapshotRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicationLag != nil {
+		in, out := &in.ReplicationLag, &out.ReplicationLag
+		*out = make([]ReplicationLag, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterInit != nil {
+		in, out := &in.ClusterInit, &out.ClusterInit
+		*out = new(ClusterInitStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Masters != nil {
+		in, out := &in.Masters, &out.Masters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ShardRemoval != nil {
+		in, out := &in.ShardRemoval, &out.ShardRemoval
+		*out = new(ShardRemovalStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(ImportStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeSnapshotRun != nil {
+		in, out := &in.VolumeSnapshotRun, &out.VolumeSnapshotRun
+		*out = new(VolumeSnapshotRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodRestarts != nil {
+		in, out := &in.PodRestarts, &out.PodRestarts
+		*out = make([]PodRestartStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.AOFRewriteRun != nil {
+		in, out := &in.AOFRewriteRun, &out.AOFRewriteRun
+		*out = new(AOFRewriteRun)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisStatus.
+func (in *RedisStatus) DeepCopy() *RedisStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaOf) DeepCopyInto(out *ReplicaOf) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(ExistingPasswordSecret)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaOf.
+func (in *ReplicaOf) DeepCopy() *ReplicaOf {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaOf)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaOfStatus) DeepCopyInto(out *ReplicaOfStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaOfStatus.
+func (in *ReplicaOfStatus) DeepCopy() *ReplicaOfStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaOfStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaPriority) DeepCopyInto(out *ReplicaPriority) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ReplicaPriorityRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaPriority.
+func (in *ReplicaPriority) DeepCopy() *ReplicaPriority {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaPriority)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaPriorityRule) DeepCopyInto(out *ReplicaPriorityRule) {
+	*out = *in
+	if in.Ordinals != nil {
+		in, out := &in.Ordinals, &out.Ordinals
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExistingPasswordSecret.
-func (in *ExistingPasswordSecret) DeepCopy() *ExistingPasswordSecret {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaPriorityRule.
+func (in *ReplicaPriorityRule) DeepCopy() *ReplicaPriorityRule {
 	if in == nil {
 		return nil
 	}
-	out := new(ExistingPasswordSecret)
+	out := new(ReplicaPriorityRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
+func (in *ReplicationConfig) DeepCopyInto(out *ReplicationConfig) {
 	*out = *in
-	if in.Password != nil {
-		in, out := &in.Password, &out.Password
+	if in.DisklessSync != nil {
+		in, out := &in.DisklessSync, &out.DisklessSync
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DisklessSyncDelay != nil {
+		in, out := &in.DisklessSyncDelay, &out.DisklessSyncDelay
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DisklessLoad != nil {
+		in, out := &in.DisklessLoad, &out.DisklessLoad
 		*out = new(string)
 		**out = **in
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(Resources)
+	if in.LagThresholdBytes != nil {
+		in, out := &in.LagThresholdBytes, &out.LagThresholdBytes
+		*out = new(int64)
 		**out = **in
 	}
-	if in.ExistingPasswordSecret != nil {
-		in, out := &in.ExistingPasswordSecret, &out.ExistingPasswordSecret
-		*out = new(ExistingPasswordSecret)
-		(*in).DeepCopyInto(*out)
+	if in.BacklogSize != nil {
+		in, out := &in.BacklogSize, &out.BacklogSize
+		*out = new(string)
+		**out = **in
+	}
+	if in.BacklogTTL != nil {
+		in, out := &in.BacklogTTL, &out.BacklogTTL
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalConfig.
-func (in *GlobalConfig) DeepCopy() *GlobalConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationConfig.
+func (in *ReplicationConfig) DeepCopy() *ReplicationConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(GlobalConfig)
+	out := new(ReplicationConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Redis) DeepCopyInto(out *Redis) {
+func (in *ReplicationLag) DeepCopyInto(out *ReplicationLag) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Redis.
-func (in *Redis) DeepCopy() *Redis {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationLag.
+func (in *ReplicationLag) DeepCopy() *ReplicationLag {
 	if in == nil {
 		return nil
 	}
-	out := new(Redis)
+	out := new(ReplicationLag)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Redis) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDescription) DeepCopyInto(out *ResourceDescription) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDescription.
+func (in *ResourceDescription) DeepCopy() *ResourceDescription {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(ResourceDescription)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisExporter) DeepCopyInto(out *RedisExporter) {
+func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(Resources)
-		**out = **in
-	}
+	out.ResourceRequests = in.ResourceRequests
+	out.ResourceLimits = in.ResourceLimits
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisExporter.
-func (in *RedisExporter) DeepCopy() *RedisExporter {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resources.
+func (in *Resources) DeepCopy() *Resources {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisExporter)
+	out := new(Resources)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisList) DeepCopyInto(out *RedisList) {
+func (in *RestoreFrom) DeepCopyInto(out *RestoreFrom) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Redis, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisList.
-func (in *RedisList) DeepCopy() *RedisList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreFrom.
+func (in *RestoreFrom) DeepCopy() *RestoreFrom {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisList)
+	out := new(RestoreFrom)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RedisList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Service) DeepCopyInto(out *Service) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Service.
+func (in *Service) DeepCopy() *Service {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(Service)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisMaster) DeepCopyInto(out *RedisMaster) {
+func (in *ShardOverride) DeepCopyInto(out *ShardOverride) {
 	*out = *in
-	out.Resources = in.Resources
 	if in.RedisConfig != nil {
 		in, out := &in.RedisConfig, &out.RedisConfig
 		*out = make(map[string]string, len(*in))
@@ -170,192 +1526,225 @@ func (in *RedisMaster) DeepCopyInto(out *RedisMaster) {
 			(*out)[key] = val
 		}
 	}
-	out.Service = in.Service
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisMaster.
-func (in *RedisMaster) DeepCopy() *RedisMaster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardOverride.
+func (in *ShardOverride) DeepCopy() *ShardOverride {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisMaster)
+	out := new(ShardOverride)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisSlave) DeepCopyInto(out *RedisSlave) {
+func (in *ShardRemovalStatus) DeepCopyInto(out *ShardRemovalStatus) {
 	*out = *in
-	out.Resources = in.Resources
-	if in.RedisConfig != nil {
-		in, out := &in.RedisConfig, &out.RedisConfig
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardRemovalStatus.
+func (in *ShardRemovalStatus) DeepCopy() *ShardRemovalStatus {
+	if in == nil {
+		return nil
 	}
-	out.Service = in.Service
+	out := new(ShardRemovalStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisSlave.
-func (in *RedisSlave) DeepCopy() *RedisSlave {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardTopology) DeepCopyInto(out *ShardTopology) {
+	*out = *in
+	out.Master = in.Master
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]NodePlacement, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardTopology.
+func (in *ShardTopology) DeepCopy() *ShardTopology {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisSlave)
+	out := new(ShardTopology)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisSpec) DeepCopyInto(out *RedisSpec) {
+func (in *StartupProbe) DeepCopyInto(out *StartupProbe) {
 	*out = *in
-	if in.Size != nil {
-		in, out := &in.Size, &out.Size
-		*out = new(int32)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
 		**out = **in
 	}
-	in.GlobalConfig.DeepCopyInto(&out.GlobalConfig)
-	out.Service = in.Service
-	in.Master.DeepCopyInto(&out.Master)
-	in.Slave.DeepCopyInto(&out.Slave)
-	if in.RedisExporter != nil {
-		in, out := &in.RedisExporter, &out.RedisExporter
-		*out = new(RedisExporter)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.RedisConfig != nil {
-		in, out := &in.RedisConfig, &out.RedisConfig
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(Resources)
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
 		**out = **in
 	}
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = new(Storage)
-		(*in).DeepCopyInto(*out)
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
 	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StartupProbe.
+func (in *StartupProbe) DeepCopy() *StartupProbe {
+	if in == nil {
+		return nil
 	}
-	if in.SecurityContext != nil {
-		in, out := &in.SecurityContext, &out.SecurityContext
-		*out = new(v1.PodSecurityContext)
-		(*in).DeepCopyInto(*out)
+	out := new(StartupProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storage) DeepCopyInto(out *Storage) {
+	*out = *in
+	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+	if in.NearFullThreshold != nil {
+		in, out := &in.NearFullThreshold, &out.NearFullThreshold
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Affinity != nil {
-		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
+func (in *Storage) DeepCopy() *Storage {
+	if in == nil {
+		return nil
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = new([]v1.Toleration)
-		if **in != nil {
-			in, out := *in, *out
-			*out = make([]v1.Toleration, len(*in))
-			for i := range *in {
-				(*in)[i].DeepCopyInto(&(*out)[i])
-			}
-		}
+	out := new(Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotConfig) DeepCopyInto(out *VolumeSnapshotConfig) {
+	*out = *in
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisSpec.
-func (in *RedisSpec) DeepCopy() *RedisSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotConfig.
+func (in *VolumeSnapshotConfig) DeepCopy() *VolumeSnapshotConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisSpec)
+	out := new(VolumeSnapshotConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RedisStatus) DeepCopyInto(out *RedisStatus) {
+func (in *VolumeSnapshotRef) DeepCopyInto(out *VolumeSnapshotRef) {
 	*out = *in
-	in.Cluster.DeepCopyInto(&out.Cluster)
+	in.CreationTime.DeepCopyInto(&out.CreationTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisStatus.
-func (in *RedisStatus) DeepCopy() *RedisStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRef.
+func (in *VolumeSnapshotRef) DeepCopy() *VolumeSnapshotRef {
 	if in == nil {
 		return nil
 	}
-	out := new(RedisStatus)
+	out := new(VolumeSnapshotRef)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourceDescription) DeepCopyInto(out *ResourceDescription) {
+func (in *VolumeSnapshotRun) DeepCopyInto(out *VolumeSnapshotRun) {
 	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.PendingPods != nil {
+		in, out := &in.PendingPods, &out.PendingPods
+		*out = make([]PendingVolumeSnapshot, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDescription.
-func (in *ResourceDescription) DeepCopy() *ResourceDescription {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRun.
+func (in *VolumeSnapshotRun) DeepCopy() *VolumeSnapshotRun {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceDescription)
+	out := new(VolumeSnapshotRun)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Resources) DeepCopyInto(out *Resources) {
+func (in *WriteService) DeepCopyInto(out *WriteService) {
 	*out = *in
-	out.ResourceRequests = in.ResourceRequests
-	out.ResourceLimits = in.ResourceLimits
+	out.Service = in.Service
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resources.
-func (in *Resources) DeepCopy() *Resources {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WriteService.
+func (in *WriteService) DeepCopy() *WriteService {
 	if in == nil {
 		return nil
 	}
-	out := new(Resources)
+	out := new(WriteService)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Service) DeepCopyInto(out *Service) {
+func (in *Import) DeepCopyInto(out *Import) {
 	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(ExistingPasswordSecret)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Service.
-func (in *Service) DeepCopy() *Service {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Import.
+func (in *Import) DeepCopy() *Import {
 	if in == nil {
 		return nil
 	}
-	out := new(Service)
+	out := new(Import)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Storage) DeepCopyInto(out *Storage) {
+func (in *ImportStatus) DeepCopyInto(out *ImportStatus) {
 	*out = *in
-	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
-func (in *Storage) DeepCopy() *Storage {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportStatus.
+func (in *ImportStatus) DeepCopy() *ImportStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Storage)
+	out := new(ImportStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Opstree Solutions.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// replicationLagBytes is the replication lag of every redis replica, served on
+// the metrics endpoint of the operator along with the controller-runtime metrics.
+var replicationLagBytes = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "redis_operator_replication_lag_bytes",
+		Help: "Replication lag in bytes of a redis replica behind its master",
+	},
+	[]string{"namespace", "redis", "pod"},
+)
+
+// duplicateSlotFixes counts the slots claimed by more than one redis master
+// which have been reassigned to the master with the higher config epoch.
+var duplicateSlotFixes = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "redis_operator_duplicate_slot_fixes_total",
+		Help: "Number of slots claimed by more than one redis master which have been reassigned",
+	},
+	[]string{"namespace", "redis"},
+)
+
+// workqueueDepthCollector serves the depth of the controller workqueues as
+// redis_operator_workqueue_depth, labelled with the controller. The depth is
+// read from the workqueue_depth gauge controller-runtime keeps for its queues.
+type workqueueDepthCollector struct {
+	depth prometheus.Collector
+	desc  *prometheus.Desc
+}
+
+// newWorkqueueDepthCollector returns the collector of the workqueue depth, it
+// shares the workqueue_depth gauge registered by controller-runtime.
+func newWorkqueueDepthCollector() (*workqueueDepthCollector, error) {
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.WorkQueueSubsystem,
+		Name:      metrics.DepthKey,
+		Help:      "Current depth of workqueue",
+	}, []string{"name"})
+	if err := metrics.Registry.Register(depth); err != nil {
+		registered, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			return nil, err
+		}
+		depth = registered.ExistingCollector.(*prometheus.GaugeVec)
+	}
+	return &workqueueDepthCollector{
+		depth: depth,
+		desc: prometheus.NewDesc(
+			"redis_operator_workqueue_depth",
+			"Number of redis resources waiting in the workqueue of the controller to be reconciled",
+			[]string{"controller"}, nil,
+		),
+	}, nil
+}
+
+// Describe sends the description of the workqueue depth metric
+func (c *workqueueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect sends the depth of every controller workqueue
+func (c *workqueueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	depths := make(chan prometheus.Metric)
+	go func() {
+		c.depth.Collect(depths)
+		close(depths)
+	}()
+	for depth := range depths {
+		var metric dto.Metric
+		if err := depth.Write(&metric); err != nil || metric.Gauge == nil {
+			continue
+		}
+		for _, label := range metric.Label {
+			if label.GetName() == "name" {
+				ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, metric.Gauge.GetValue(), label.GetValue())
+			}
+		}
+	}
+}
+
+func init() {
+	metrics.Registry.MustRegister(replicationLagBytes, duplicateSlotFixes)
+	workqueueDepth, err := newWorkqueueDepthCollector()
+	if err != nil {
+		ctrl.Log.WithName("metrics").Error(err, "Could not register the workqueue depth metric")
+		return
+	}
+	metrics.Registry.MustRegister(workqueueDepth)
+}
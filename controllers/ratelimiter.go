@@ -0,0 +1,44 @@
+/*
+Copyright 2020 Opstree Solutions.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// jitterRateLimiter wraps an exponential per-item backoff and spreads each
+// delay randomly between half and the full backoff, so that many Redis
+// objects failing together do not requeue at the same instant.
+type jitterRateLimiter struct {
+	workqueue.RateLimiter
+}
+
+// NewJitterRateLimiter returns an exponential failure rate limiter with jitter
+func NewJitterRateLimiter(baseDelay time.Duration, maxDelay time.Duration) workqueue.RateLimiter {
+	return &jitterRateLimiter{
+		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+	}
+}
+
+// When returns the jittered delay before the item should be processed again
+func (r *jitterRateLimiter) When(item interface{}) time.Duration {
+	delay := r.RateLimiter.When(item)
+	return wait.Jitter(delay/2, 1.0)
+}
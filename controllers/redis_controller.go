@@ -18,33 +18,109 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"redis-operator/k8sutils"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	redisv1beta1 "redis-operator/api/v1beta1"
 )
 
+const (
+	reconcileResultSuccess = "Success"
+	reconcileResultError   = "Error"
+	// maxLastErrorLength keeps the error recorded in the status short enough for a printer column
+	maxLastErrorLength = 256
+)
+
+// invalidSpecError is an error of the redis spec, which is recorded in the status without retrying the reconcile
+// since only a change of the spec can fix it
+type invalidSpecError struct {
+	error
+}
+
 // RedisReconciler reconciles a Redis object
 type RedisReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	RateLimiter workqueue.RateLimiter
+	Recorder    record.EventRecorder
+	// WatchNodes enqueues the redis clusters when a node is cordoned, which needs the operator to read nodes
+	WatchNodes bool
 }
 
+const (
+	// conditionSlotsConsistent reports whether slot migrations of the redis cluster were left open
+	conditionSlotsConsistent = "SlotsConsistent"
+	// conditionStorageNearFull reports whether the data directory usage of a redis pod exceeds the threshold
+	conditionStorageNearFull = "StorageNearFull"
+	// conditionUpgradeAllowed reports whether the redis image is a supported upgrade of the running redis version
+	conditionUpgradeAllowed = "UpgradeAllowed"
+	// conditionHighReplicationLag reports whether a redis replica lags behind its master by more than the threshold
+	conditionHighReplicationLag = "HighReplicationLag"
+	// conditionClusterFormed reports whether the redis cluster has been formed, or why forming it failed
+	conditionClusterFormed = "ClusterFormed"
+	// conditionDegraded reports whether the redis setup has been unhealthy for longer than the degraded grace period
+	conditionDegraded = "Degraded"
+	// conditionMaintenancePending reports whether disruptive operations are waiting for the maintenance window
+	conditionMaintenancePending = "MaintenancePending"
+	// conditionDataImported reports whether the keys of the external redis have been imported into the redis cluster
+	conditionDataImported = "DataImported"
+	// conditionPersistenceEnabled reports whether redis keeps its data across restarts
+	conditionPersistenceEnabled = "PersistenceEnabled"
+	// defaultDegradedGracePeriod is how long the redis setup may be unhealthy before it is reported as degraded
+	defaultDegradedGracePeriod = time.Minute * 5
+)
+
 // +kubebuilder:rbac:groups=redis.redis.opstreelabs.in,resources=redis,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=redis.redis.opstreelabs.in,resources=redis/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=redis.redis.opstreelabs.in,resources=redis/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;create;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=limitranges,verbs=get;list
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;create;delete
+// +kubebuilder:rbac:groups="",resources=services;secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=node.k8s.io,resources=runtimeclasses,verbs=get
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;create;delete
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -63,55 +139,245 @@ func (r *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	err := r.Client.Get(context.TODO(), req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			k8sutils.CloseRedisClients(req.Namespace, req.Name)
+			k8sutils.ForgetRedisPodDisruptionBudgets(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
+	if instance.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(instance, k8sutils.RedisFinalizer) {
+			r.finalizeRedis(ctx, instance)
+			patch := client.MergeFrom(instance.DeepCopy())
+			controllerutil.RemoveFinalizer(instance, k8sutils.RedisFinalizer)
+			if err := r.Client.Patch(context.TODO(), instance, patch); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	result, err := r.reconcileRedis(ctx, instance)
+	r.reportDeferredOperations(instance)
+	r.recordReconcileResult(instance, err)
+	if _, ok := err.(invalidSpecError); ok {
+		return result, nil
+	}
+	return result, err
+}
+
+// reconcileRedis reconciles the redis resources of a redis which isn't being deleted
+func (r *RedisReconciler) reconcileRedis(ctx context.Context, instance *redisv1beta1.Redis) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	if err := r.reconcileFinalizer(instance); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err := controllerutil.SetControllerReference(instance, instance, r.Scheme); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := k8sutils.ValidateRedisSpec(instance); err != nil {
+		reqLogger.Error(err, "Redis spec is invalid, waiting for it to be fixed")
+		return ctrl.Result{}, invalidSpecError{err}
+	}
+	for _, warning := range k8sutils.GetRedisSpecWarnings(instance) {
+		reqLogger.Info(warning)
+	}
+
+	if !r.validateRedisUpgrade(ctx, instance) {
+		return ctrl.Result{}, invalidSpecError{fmt.Errorf("redis image upgrade is blocked, see the UpgradeAllowed condition")}
+	}
+
+	// the configmap may be created after the redis, so a missing one is retried instead of waiting for a spec change
+	if err := k8sutils.CheckEntrypointConfigMaps(instance); err != nil {
+		reqLogger.Error(err, "Entrypoint script of redis is missing, the redis pods can't start without it")
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "EntrypointConfigMapMissing", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.checkRedisPersistence(instance)
+
 	found := &appsv1.StatefulSet{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		if instance.Spec.GlobalConfig.Password != nil && instance.Spec.GlobalConfig.ExistingPasswordSecret == nil {
 			k8sutils.CreateRedisSecret(instance)
 		}
+		if instance.Spec.RedisExporter != nil && instance.Spec.RedisExporter.Enabled && instance.Spec.RedisExporter.PasswordFile {
+			k8sutils.CreateRedisExporterPasswordSecret(instance)
+		}
+		if instance.Spec.GlobalConfig.CreateServiceAccount {
+			k8sutils.CreateRedisServiceAccount(instance)
+		}
+		if instance.Spec.ACL != nil {
+			r.reconcileRedisACL(ctx, instance)
+		}
+		if instance.Spec.Functions != nil {
+			r.reconcileRedisFunctions(ctx, instance)
+		}
+		if export, ok := instance.Annotations[k8sutils.RedisTopologyExportAnnotation]; ok && export != instance.Status.TopologyExport {
+			r.exportRedisTopology(ctx, instance, export)
+		}
+		if roles := k8sutils.GetLegacyServiceRoles(instance); len(roles) > 0 {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "LegacyServiceDeprecated", fmt.Sprintf("Statefulsets of roles %v are governed by the regular service, migrating them to the headless service", roles))
+		}
+		// the statefulsets are generated with the planned restarts
+		k8sutils.UpdateRedisPodRestarts(ctx, instance)
 		if instance.Spec.Mode == "cluster" {
+			r.startShardRemoval(instance)
+			k8sutils.CreateRedisConfigMap(ctx, instance, "master")
+			k8sutils.CreateRedisConfigMap(ctx, instance, "slave")
+			// the headless services govern the statefulsets, so they are created first
+			k8sutils.CreateMasterHeadlessService(instance)
 			k8sutils.CreateRedisMaster(instance)
 			k8sutils.CreateMasterService(instance)
-			k8sutils.CreateMasterHeadlessService(instance)
+			k8sutils.CreateSlaveHeadlessService(instance)
 			k8sutils.CreateRedisSlave(instance)
 			k8sutils.CreateSlaveService(instance)
-			k8sutils.CreateSlaveHeadlessService(instance)
-			redisMasterInfo, err := k8sutils.GenerateK8sClient().AppsV1().StatefulSets(instance.Namespace).Get(context.TODO(), instance.ObjectMeta.Name+"-master", metav1.GetOptions{})
+			k8sutils.CreateWriteService(instance)
+			k8sutils.CreateRedisPodDisruptionBudget(instance, "master")
+			k8sutils.CreateRedisPodDisruptionBudget(instance, "slave")
+			k8sutils.CreateRedisClusterPodDisruptionBudget(instance)
+			k8sutils.CreateRedisNetworkPolicy(instance)
+			if instance.Spec.Proxy != nil {
+				k8sutils.CreateRedisProxyService(instance)
+			}
+			redisMasterInfo, err := k8sutils.GenerateK8sClient().AppsV1().StatefulSets(instance.Namespace).Get(context.TODO(), k8sutils.GetRedisName(instance)+"-master", metav1.GetOptions{})
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			redisSlaveInfo, err := k8sutils.GenerateK8sClient().AppsV1().StatefulSets(instance.Namespace).Get(context.TODO(), instance.ObjectMeta.Name+"-slave", metav1.GetOptions{})
+			redisSlaveInfo, err := k8sutils.GenerateK8sClient().AppsV1().StatefulSets(instance.Namespace).Get(context.TODO(), k8sutils.GetRedisName(instance)+"-slave", metav1.GetOptions{})
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			if int(redisMasterInfo.Status.ReadyReplicas) != int(*instance.Spec.Size) && int(redisSlaveInfo.Status.ReadyReplicas) != int(*instance.Spec.Size) {
+			masters := int(k8sutils.GetMasterReplicas(instance))
+			followers := int(k8sutils.GetSlaveReplicas(instance))
+			if int(redisMasterInfo.Status.ReadyReplicas) != masters || int(redisSlaveInfo.Status.ReadyReplicas) != followers {
 				reqLogger.Info("Redis master and slave nodes are not ready yet", "Ready.Replicas", strconv.Itoa(int(redisMasterInfo.Status.ReadyReplicas)))
-				return ctrl.Result{RequeueAfter: time.Second * 120}, nil
+				r.markDegraded(instance, "PodsNotReady", "Redis master and slave pods are not ready")
+				return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+			}
+			if err := k8sutils.CheckRedisAdminConnection(ctx, instance); err != nil {
+				reqLogger.Info("Redis nodes are not reachable yet, skipping cluster operations", "Reason", err.Error())
+				r.markDegraded(instance, "PodsUnreachable", err.Error())
+				return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+			}
+			if instance.Spec.Storage != nil {
+				r.checkStorageUsage(instance)
 			}
+			// the statefulsets, services and pod disruption budgets are reconciled above, only the cluster
+			// operations wait for a failover to settle
+			if k8sutils.IsRedisFailoverInProgress(ctx, instance) {
+				reqLogger.Info("Redis cluster failover is in progress, skipping cluster operations until it settles")
+				// the roles change with the failover, so the topology is still recorded
+				instance.Status.ShardTopology = k8sutils.GetShardTopology(ctx, instance)
+				instance.Status.Masters = k8sutils.GetShardMasters(ctx, instance)
+				r.updateRedisStatus(instance)
+				// and the write service follows the promoted masters right away
+				k8sutils.LabelRedisPodRoles(ctx, instance)
+				r.markDegraded(instance, "FailoverInProgress", "A redis cluster failover is in progress")
+				return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+			}
+			if !r.recoverOpenSlots(ctx, instance) {
+				r.markDegraded(instance, "OpenSlots", "Slot migrations of the redis cluster are left open")
+				return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+			}
+			r.fixDuplicateSlots(ctx, instance)
+			if !k8sutils.QuarantineRedisPods(ctx, instance) {
+				reqLogger.Info("Quarantined redis masters are being failed over")
+				return ctrl.Result{RequeueAfter: time.Second * 30}, nil
+			}
+			if instance.Status.ShardRemoval != nil {
+				r.removeRedisShards(ctx, instance)
+				return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+			}
+			nodes := masters + followers - len(instance.Spec.QuarantinePods)
 			reqLogger.Info("Creating redis cluster by executing cluster creation command", "Ready.Replicas", strconv.Itoa(int(redisMasterInfo.Status.ReadyReplicas)))
-			if k8sutils.CheckRedisNodeCount(instance) != int(*instance.Spec.Size)*2 {
-				k8sutils.ExecuteRedisClusterCommand(instance)
-				k8sutils.ExecuteRedisReplicationCommand(instance)
+			if joined := k8sutils.CheckRedisNodeCount(ctx, instance); joined != nodes {
+				r.formRedisCluster(ctx, instance, joined, nodes)
 			} else {
 				reqLogger.Info("Redis master count is desired")
-				if k8sutils.CheckRedisClusterState(instance) >= int(*instance.Spec.Size)*2-1 {
-					k8sutils.ExecuteFaioverOperation(instance)
+				if instance.Status.ClusterInit != nil {
+					r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ClusterFormed", "Redis cluster formed with %d nodes after %d attempts", nodes, instance.Status.ClusterInit.Attempts)
+					r.setCondition(instance, conditionClusterFormed, metav1.ConditionTrue, "ClusterFormed", fmt.Sprintf("Redis cluster formed with %d nodes", nodes))
+					instance.Status.ClusterInit = nil
+				}
+				// the redis cluster isn't ready for clients until the keys of the external redis are imported
+				if instance.Spec.Import != nil && !r.importRedisData(ctx, instance) {
+					return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+				}
+				instance.Status.ShardTopology = k8sutils.GetShardTopology(ctx, instance)
+				instance.Status.Masters = k8sutils.GetShardMasters(ctx, instance)
+				if len(instance.Spec.ShardOverrides) > 0 {
+					k8sutils.ApplyShardOverrides(ctx, instance)
+				}
+				k8sutils.ApplyReplicaPriorities(ctx, instance)
+				if instance.Spec.Proxy != nil {
+					k8sutils.CreateRedisProxy(ctx, instance)
+				}
+				k8sutils.FailoverRedisMastersForRestart(ctx, instance)
+				for _, master := range k8sutils.FailoverRedisMastersOnDrainingNodes(ctx, instance) {
+					r.Recorder.Eventf(instance, corev1.EventTypeNormal, "DrainFailover", "Redis master %s failed over to a replica before its node is drained", master)
+				}
+				r.updateRedisStatus(instance)
+				failedNodes := k8sutils.CheckRedisClusterState(ctx, instance)
+				if failedNodes >= nodes-1 {
+					k8sutils.ExecuteFaioverOperation(ctx, instance)
+				}
+				// the failovers above change the roles, so the pods are labeled afterwards
+				k8sutils.LabelRedisPodRoles(ctx, instance)
+				r.checkReplicationLag(ctx, instance)
+				if failedNodes == 0 {
+					r.rebalanceRedisReplicas(ctx, instance)
+				}
+				snapshotPending := false
+				if instance.Spec.VolumeSnapshot != nil {
+					snapshotPending = r.snapshotRedisVolumes(ctx, instance)
+				}
+				aofRewritePending := false
+				if instance.Spec.Persistence != nil && instance.Spec.Persistence.AOFRewriteSchedule != nil {
+					aofRewritePending = r.rewriteRedisAOF(ctx, instance)
+				}
+				if failedNodes > 0 {
+					r.markDegraded(instance, "ClusterNodesFailed", fmt.Sprintf("%d redis cluster nodes are failing", failedNodes))
+				} else if reason, message := r.checkSlotCoverage(ctx, instance); reason != "" {
+					r.markDegraded(instance, reason, message)
+				} else {
+					r.clearDegraded(instance)
+				}
+				if snapshotPending || aofRewritePending {
+					return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 				}
 				return ctrl.Result{RequeueAfter: time.Second * 120}, nil
 			}
 		} else if instance.Spec.Mode == "standalone" {
+			if instance.Spec.ReplicaOf != nil && instance.Spec.ReplicaOf.PasswordSecret != nil {
+				k8sutils.CreateRedisReplicaOfAuthSecret(ctx, instance)
+			}
+			k8sutils.CreateRedisConfigMap(ctx, instance, "standalone")
+			k8sutils.CreateStandaloneHeadlessService(instance)
 			k8sutils.CreateRedisStandalone(instance)
 			k8sutils.CreateStandaloneService(instance)
-			k8sutils.CreateStandaloneHeadlessService(instance)
+			k8sutils.CreateRedisNetworkPolicy(instance)
+			if instance.Spec.ReplicaOf != nil {
+				r.updateReplicaOfStatus(ctx, instance)
+			}
+			if instance.Spec.Storage != nil {
+				r.checkStorageUsage(instance)
+			}
+			if err := k8sutils.CheckRedisAdminConnection(ctx, instance); err != nil {
+				r.markDegraded(instance, "PodsUnreachable", err.Error())
+			} else {
+				r.clearDegraded(instance)
+				if instance.Spec.VolumeSnapshot != nil {
+					r.snapshotRedisVolumes(ctx, instance)
+				}
+				if instance.Spec.Persistence != nil && instance.Spec.Persistence.AOFRewriteSchedule != nil {
+					r.rewriteRedisAOF(ctx, instance)
+				}
+			}
 		}
 	} else if err != nil {
 		return ctrl.Result{}, err
@@ -121,9 +387,755 @@ func (r *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	return ctrl.Result{RequeueAfter: time.Second * 10}, nil
 }
 
+// reconcileRedisACL reloads the ACL file on the redis pods when the ACL configmap has changed
+func (r *RedisReconciler) reconcileRedisACL(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	checksum, err := k8sutils.GetACLChecksum(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed in reading ACL configmap for redis")
+		return
+	}
+	if checksum == instance.Status.ACLChecksum {
+		return
+	}
+	reqLogger.Info("ACL file has changed, reloading it on redis pods", "Checksum", checksum)
+	aclStatus, loaded := k8sutils.ReloadRedisACL(ctx, instance, checksum)
+	instance.Status.ACLStatus = aclStatus
+	if loaded {
+		instance.Status.ACLChecksum = checksum
+	}
+	r.updateRedisStatus(instance)
+}
+
+// reconcileRedisFunctions loads the redis function libraries on the redis pods which don't have the libraries of the
+// functions configmap, because it has changed or because the pod restarted without them
+func (r *RedisReconciler) reconcileRedisFunctions(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	checksum, err := k8sutils.GetFunctionsChecksum(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed in reading functions configmap for redis")
+		return
+	}
+	functionsStatus, reloaded, loaded := k8sutils.LoadRedisFunctions(ctx, instance)
+	if reloaded == 0 && loaded && checksum == instance.Status.FunctionsChecksum {
+		return
+	}
+	reqLogger.Info("Function libraries were missing or outdated on redis pods, loaded them", "Checksum", checksum, "Libraries", reloaded)
+	instance.Status.FunctionsStatus = functionsStatus
+	if loaded {
+		instance.Status.FunctionsChecksum = checksum
+	}
+	r.updateRedisStatus(instance)
+}
+
+// updateReplicaOfStatus records the replication state of the standalone redis replicating an external primary
+func (r *RedisReconciler) updateReplicaOfStatus(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	replicaOfStatus, err := k8sutils.GetReplicaOfStatus(ctx, instance)
+	if err != nil {
+		reqLogger.Info("Could not read the replication state of redis", "Reason", err.Error())
+		return
+	}
+	instance.Status.ReplicaOf = replicaOfStatus
+	r.updateRedisStatus(instance)
+}
+
+// fixDuplicateSlots reassigns the slots claimed by more than one redis master, for example after a network partition
+// healed, to the master with the higher config epoch
+func (r *RedisReconciler) fixDuplicateSlots(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	fixed, err := k8sutils.FixDuplicateSlots(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Could not check the slot owners of the redis cluster")
+		return
+	}
+	if fixed == 0 {
+		return
+	}
+	duplicateSlotFixes.WithLabelValues(instance.Namespace, instance.Name).Add(float64(fixed))
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "DuplicateSlotsFixed", "%d slots claimed by more than one redis master have been reassigned", fixed)
+}
+
+// startShardRemoval records the replicas of the redis statefulsets once the size of the cluster is decreased, so that
+// the statefulsets keep their pods until the removed shards are drained. Raising the size back cancels the removal.
+func (r *RedisReconciler) startShardRemoval(instance *redisv1beta1.Redis) {
+	if removal := instance.Status.ShardRemoval; removal != nil {
+		if removal.Masters <= *instance.Spec.Size {
+			r.Recorder.Event(instance, corev1.EventTypeNormal, "ShardRemovalCancelled", "Redis cluster size was raised back, the shards are kept")
+			instance.Status.ShardRemoval = nil
+			r.updateRedisStatus(instance)
+		}
+		return
+	}
+	removal := k8sutils.NewShardRemoval(instance)
+	if removal == nil {
+		return
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ShardRemovalStarted", "Removing redis cluster shards, draining %d masters", removal.Masters-*instance.Spec.Size)
+	instance.Status.ShardRemoval = removal
+	r.updateRedisStatus(instance)
+}
+
+// removeRedisShards runs the next step of draining the removed shards, the statefulsets are scaled down once the
+// removed nodes are out of the cluster
+func (r *RedisReconciler) removeRedisShards(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	removal := instance.Status.ShardRemoval
+	phase, message := removal.Phase, removal.Message
+	done, err := k8sutils.RemoveRedisShards(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Redis cluster shards can't be removed safely")
+		if err.Error() != message {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "ShardRemovalBlocked", err.Error())
+		}
+		removal.Message = err.Error()
+	} else {
+		removal.Message = ""
+	}
+	if done {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ShardsRemoved", "Redis cluster shards are drained, scaling down to %d masters", *instance.Spec.Size)
+		instance.Status.ShardRemoval = nil
+		r.updateRedisStatus(instance)
+		k8sutils.CreateRedisMaster(instance)
+		k8sutils.CreateRedisSlave(instance)
+		return
+	}
+	if removal.Phase != phase || removal.Message != message {
+		r.updateRedisStatus(instance)
+	}
+}
+
+// rebalanceRedisReplicas moves a replica from a master with more replicas than its share to a master with less, like
+// after failovers left a master without replicas
+func (r *RedisReconciler) rebalanceRedisReplicas(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	move, err := k8sutils.RebalanceRedisReplicas(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Could not rebalance the replicas of the redis cluster")
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "ReplicaRebalanceFailed", err.Error())
+		return
+	}
+	if move != nil {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ReplicaMoved", "Redis replica %s moved from master %s to master %s to balance the replicas", move.Replica, move.From, move.To)
+	}
+}
+
+// reportDeferredOperations reports the disruptive operations of the reconcile which wait for the maintenance window in
+// the MaintenancePending condition, which is saved along with the reconcile result
+func (r *RedisReconciler) reportDeferredOperations(instance *redisv1beta1.Redis) {
+	operations := k8sutils.TakeDeferredOperations(instance)
+	if len(operations) == 0 {
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, conditionMaintenancePending) {
+			r.setCondition(instance, conditionMaintenancePending, metav1.ConditionFalse, "NoPendingOperations", "No disruptive operation is waiting for the maintenance window")
+		}
+		return
+	}
+	next := k8sutils.GetNextMaintenanceWindow(instance, time.Now())
+	message := fmt.Sprintf("Waiting for the maintenance window opening at %s for the %s", next.Format(time.RFC3339), strings.Join(operations, ", "))
+	condition := meta.FindStatusCondition(instance.Status.Conditions, conditionMaintenancePending)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Message != message {
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "WaitingForMaintenanceWindow", message)
+	}
+	r.setCondition(instance, conditionMaintenancePending, metav1.ConditionTrue, "WaitingForMaintenanceWindow", message)
+}
+
+// recordReconcileResult records the time and the result of the reconcile in the status, along with the error of a
+// failed reconcile truncated to maxLastErrorLength
+func (r *RedisReconciler) recordReconcileResult(instance *redisv1beta1.Redis, err error) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	now := metav1.Now()
+	instance.Status.LastReconcileTime = &now
+	instance.Status.LastReconcileResult = reconcileResultSuccess
+	instance.Status.LastError = ""
+	if err != nil {
+		instance.Status.LastReconcileResult = reconcileResultError
+		instance.Status.LastError = err.Error()
+		if len(instance.Status.LastError) > maxLastErrorLength {
+			instance.Status.LastError = instance.Status.LastError[:maxLastErrorLength-3] + "..."
+		}
+	}
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		reqLogger.Error(err, "Failed in recording the reconcile result in the status of redis")
+	}
+}
+
+// recoverOpenSlots resumes or rolls back the slot migrations left open, for example by an operator restart during a
+// rebalance. It returns false while the cluster still has open slots.
+func (r *RedisReconciler) recoverOpenSlots(ctx context.Context, instance *redisv1beta1.Redis) bool {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	openSlots, err := k8sutils.GetOpenSlots(ctx, instance)
+	if err != nil {
+		reqLogger.Error(err, "Could not check the open slots of the redis cluster")
+		return true
+	}
+	if len(openSlots) == 0 {
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, conditionSlotsConsistent) {
+			r.setCondition(instance, conditionSlotsConsistent, metav1.ConditionTrue, "NoOpenSlots", "No slot migration is in progress")
+			r.updateRedisStatus(instance)
+		}
+		return true
+	}
+
+	reqLogger.Info("Redis cluster has open slots, fixing them", "Slots", openSlots)
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, "OpenSlotsDetected", "Slots left in migrating or importing state: %v", openSlots)
+	k8sutils.FixRedisClusterSlots(ctx, instance)
+	openSlots, err = k8sutils.GetOpenSlots(ctx, instance)
+	if err == nil && len(openSlots) == 0 {
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "OpenSlotsRecovered", "Open slots of the redis cluster have been fixed")
+		r.setCondition(instance, conditionSlotsConsistent, metav1.ConditionTrue, "OpenSlotsRecovered", "Open slots of the redis cluster have been fixed")
+		r.updateRedisStatus(instance)
+		return true
+	}
+	message := fmt.Sprintf("Slots still in migrating or importing state: %v", openSlots)
+	if err != nil {
+		message = err.Error()
+	}
+	r.Recorder.Event(instance, corev1.EventTypeWarning, "OpenSlotsRecoveryFailed", message)
+	r.setCondition(instance, conditionSlotsConsistent, metav1.ConditionFalse, "OpenSlotsRecoveryFailed", message)
+	r.updateRedisStatus(instance)
+	return false
+}
+
+// checkStorageUsage warns when the data directory of a redis pod is nearly full, before persistence starts failing
+func (r *RedisReconciler) checkStorageUsage(instance *redisv1beta1.Redis) {
+	threshold := k8sutils.GetStorageNearFullThreshold(instance)
+	usage := k8sutils.GetStorageUsage(instance)
+	if len(usage) == 0 {
+		return
+	}
+	var nearFull []string
+	for podName, used := range usage {
+		if used >= threshold {
+			nearFull = append(nearFull, fmt.Sprintf("%s (%d%%)", podName, used))
+		}
+	}
+	if len(nearFull) == 0 {
+		if !meta.IsStatusConditionFalse(instance.Status.Conditions, conditionStorageNearFull) {
+			r.setCondition(instance, conditionStorageNearFull, metav1.ConditionFalse, "StorageUsageBelowThreshold", fmt.Sprintf("Storage usage of all redis pods is below %d%%", threshold))
+			r.updateRedisStatus(instance)
+		}
+		return
+	}
+	sort.Strings(nearFull)
+	message := fmt.Sprintf("Storage usage is above %d%%: %s", threshold, strings.Join(nearFull, ", "))
+	r.Recorder.Event(instance, corev1.EventTypeWarning, conditionStorageNearFull, message)
+	r.setCondition(instance, conditionStorageNearFull, metav1.ConditionTrue, "StorageUsageAboveThreshold", message)
+	r.updateRedisStatus(instance)
+}
+
+// checkReplicationLag records the replication lag of every replica in the status and the metrics, and sets the
+// HighReplicationLag condition when a replica lags behind its master by more than the threshold
+func (r *RedisReconciler) checkReplicationLag(ctx context.Context, instance *redisv1beta1.Redis) {
+	threshold := k8sutils.GetReplicationLagThreshold(instance)
+	lags := k8sutils.GetReplicationLag(ctx, instance)
+	current := map[string]bool{}
+	var lagging []string
+	for _, lag := range lags {
+		current[lag.PodName] = true
+		replicationLagBytes.WithLabelValues(instance.Namespace, instance.Name, lag.PodName).Set(float64(lag.LagBytes))
+		if lag.LagBytes > threshold {
+			lagging = append(lagging, fmt.Sprintf("%s (%d bytes behind %s)", lag.PodName, lag.LagBytes, lag.MasterPodName))
+		}
+	}
+	for _, lag := range instance.Status.ReplicationLag {
+		if !current[lag.PodName] {
+			replicationLagBytes.DeleteLabelValues(instance.Namespace, instance.Name, lag.PodName)
+		}
+	}
+	instance.Status.ReplicationLag = lags
+	if len(lagging) == 0 {
+		r.setCondition(instance, conditionHighReplicationLag, metav1.ConditionFalse, "ReplicationLagBelowThreshold", fmt.Sprintf("Replication lag of all redis replicas is below %d bytes", threshold))
+	} else {
+		message := fmt.Sprintf("Replication lag is above %d bytes: %s", threshold, strings.Join(lagging, ", "))
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, conditionHighReplicationLag) {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, conditionHighReplicationLag, message)
+		}
+		r.setCondition(instance, conditionHighReplicationLag, metav1.ConditionTrue, "ReplicationLagAboveThreshold", message)
+	}
+	r.updateRedisStatus(instance)
+}
+
+// validateRedisUpgrade blocks changing the redis image to a version which can't load the data of the running redis,
+// unless the skip upgrade validation annotation is set
+func (r *RedisReconciler) validateRedisUpgrade(ctx context.Context, instance *redisv1beta1.Redis) bool {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	if err := k8sutils.ValidateRedisUpgrade(ctx, instance); err != nil {
+		reqLogger.Error(err, "Redis upgrade is blocked, waiting for the image to be fixed or the upgrade validation to be skipped")
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "UpgradeBlocked", err.Error())
+		r.setCondition(instance, conditionUpgradeAllowed, metav1.ConditionFalse, "UnsupportedUpgradePath", err.Error())
+		// the spec is not recorded in the status, since it isn't applied
+		if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+			reqLogger.Error(err, "Failed in updating status for redis")
+		}
+		return false
+	}
+	if meta.IsStatusConditionFalse(instance.Status.Conditions, conditionUpgradeAllowed) {
+		r.setCondition(instance, conditionUpgradeAllowed, metav1.ConditionTrue, "SupportedUpgradePath", "The redis image is a supported upgrade of the running redis version")
+		r.updateRedisStatus(instance)
+	}
+	return true
+}
+
+// checkRedisPersistence warns once when redis is set up without any persistence, instead of on every reconcile
+func (r *RedisReconciler) checkRedisPersistence(instance *redisv1beta1.Redis) {
+	if k8sutils.IsPersistenceDisabled(instance) {
+		if meta.IsStatusConditionFalse(instance.Status.Conditions, conditionPersistenceEnabled) {
+			return
+		}
+		message := "RDB snapshots and the append only file are both disabled, redis loses its data whenever it restarts"
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "NoPersistence", message)
+		r.setCondition(instance, conditionPersistenceEnabled, metav1.ConditionFalse, "NoPersistence", message)
+		r.updateRedisStatus(instance)
+		return
+	}
+	if meta.IsStatusConditionFalse(instance.Status.Conditions, conditionPersistenceEnabled) {
+		r.setCondition(instance, conditionPersistenceEnabled, metav1.ConditionTrue, "PersistenceEnabled", "Redis persists its data with RDB snapshots, the append only file or storage")
+		r.updateRedisStatus(instance)
+	}
+}
+
+// snapshotRedisVolumes takes volume snapshots of the redis masters once the snapshot interval has elapsed. A run
+// starts with a BGSAVE on every master, and the volume of each master is snapshotted on a later reconcile once its
+// RDB file is written, so that the reconcile never waits for the BGSAVE. It returns whether the run is pending.
+func (r *RedisReconciler) snapshotRedisVolumes(ctx context.Context, instance *redisv1beta1.Redis) bool {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	run := instance.Status.VolumeSnapshotRun
+	if run == nil || len(run.PendingPods) == 0 {
+		var lastRun time.Time
+		if run != nil {
+			lastRun = run.StartTime.Time
+		} else if len(instance.Status.VolumeSnapshots) > 0 {
+			lastRun = instance.Status.VolumeSnapshots[0].CreationTime.Time
+		}
+		if time.Since(lastRun) < k8sutils.GetVolumeSnapshotInterval(instance) {
+			return false
+		}
+		run, err := k8sutils.StartRedisVolumeSnapshots(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed in starting volume snapshots of redis, will retry")
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "VolumeSnapshotFailed", err.Error())
+			return false
+		}
+		instance.Status.VolumeSnapshotRun = run
+		instance.Status.VolumeSnapshots = nil
+		r.updateRedisStatus(instance)
+		return true
+	}
+	snapshots, err := k8sutils.CreateSavedRedisVolumeSnapshots(ctx, instance, run)
+	for _, snapshot := range snapshots {
+		r.Recorder.Eventf(instance, corev1.EventTypeNormal, "VolumeSnapshotCreated", "Took volume snapshot %s of redis pod %s", snapshot.Name, snapshot.PodName)
+	}
+	instance.Status.VolumeSnapshots = append(instance.Status.VolumeSnapshots, snapshots...)
+	if err != nil {
+		reqLogger.Error(err, "Failed in taking volume snapshots of redis, will retry")
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "VolumeSnapshotFailed", err.Error())
+	}
+	if len(run.PendingPods) > 0 && time.Since(run.StartTime.Time) > k8sutils.VolumeSnapshotTimeout {
+		r.Recorder.Eventf(instance, corev1.EventTypeWarning, "VolumeSnapshotFailed", "%d redis masters did not save their RDB file within %s and are not snapshotted", len(run.PendingPods), k8sutils.VolumeSnapshotTimeout)
+		run.PendingPods = nil
+	}
+	if len(run.PendingPods) == 0 {
+		deleted, err := k8sutils.PruneRedisVolumeSnapshots(instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed in deleting the volume snapshots of redis beyond the retention")
+		}
+		if len(deleted) > 0 {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "VolumeSnapshotsPruned", "Deleted %d volume snapshots beyond the retention: %s", len(deleted), strings.Join(deleted, ", "))
+		}
+	}
+	r.updateRedisStatus(instance)
+	return len(run.PendingPods) > 0
+}
+
+// rewriteRedisAOF rewrites the append only files of the redis pods once the next time of the rewrite schedule has
+// passed, the first rewrite is scheduled after the creation of the redis resource. The run is recorded in the status
+// and advanced by one pod at a time on every reconcile, so that a failed rewrite is retried from the failed pod. It
+// returns whether the run is pending.
+func (r *RedisReconciler) rewriteRedisAOF(ctx context.Context, instance *redisv1beta1.Redis) bool {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	run := instance.Status.AOFRewriteRun
+	if run == nil {
+		lastRewrite := instance.CreationTimestamp.Time
+		if instance.Status.LastAOFRewrite != nil {
+			lastRewrite = instance.Status.LastAOFRewrite.Time
+		}
+		next, err := k8sutils.GetNextAOFRewriteTime(instance, lastRewrite)
+		if err != nil || next.IsZero() || time.Now().Before(next) {
+			return false
+		}
+		run = &redisv1beta1.AOFRewriteRun{StartTime: metav1.Now()}
+		instance.Status.AOFRewriteRun = run
+	}
+	done, err := k8sutils.ContinueRedisAOFRewrite(ctx, instance, run)
+	if err != nil {
+		reqLogger.Error(err, "Failed in rewriting the append only files of redis, will retry")
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "AOFRewriteFailed", err.Error())
+	}
+	if done {
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "AOFRewritten", "Rewrote the append only files of the redis pods one at a time")
+		instance.Status.LastAOFRewrite = &run.StartTime
+		instance.Status.AOFRewriteRun = nil
+	}
+	r.updateRedisStatus(instance)
+	return !done
+}
+
+// reconcileFinalizer adds the finalizer when finalizer steps are configured and removes it otherwise
+func (r *RedisReconciler) reconcileFinalizer(instance *redisv1beta1.Redis) error {
+	wanted := instance.Spec.Finalizer != nil
+	if wanted == controllerutil.ContainsFinalizer(instance, k8sutils.RedisFinalizer) {
+		return nil
+	}
+	patch := client.MergeFrom(instance.DeepCopy())
+	if wanted {
+		controllerutil.AddFinalizer(instance, k8sutils.RedisFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(instance, k8sutils.RedisFinalizer)
+	}
+	return r.Client.Patch(context.TODO(), instance, patch)
+}
+
+// finalizeRedis runs the finalizer steps in order, taking a final volume snapshot, shutting down the redis pods and
+// deleting their volumes. Every step is best effort and bounded by the step timeout, so that a hung step never
+// blocks the deletion.
+func (r *RedisReconciler) finalizeRedis(ctx context.Context, instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	if instance.Spec.Finalizer == nil {
+		return
+	}
+	type finalizerStep struct {
+		name string
+		run  func(ctx context.Context) error
+	}
+	var steps []finalizerStep
+	if instance.Spec.Finalizer.FinalSnapshot && instance.Spec.VolumeSnapshot != nil {
+		steps = append(steps, finalizerStep{"FinalSnapshot", func(ctx context.Context) error {
+			snapshots, err := k8sutils.CreateRedisVolumeSnapshots(ctx, instance)
+			if err == nil {
+				r.Recorder.Eventf(instance, corev1.EventTypeNormal, "VolumeSnapshotCreated", "Took %d final volume snapshots of the redis masters", len(snapshots))
+			}
+			return err
+		}})
+	}
+	steps = append(steps, finalizerStep{"Shutdown", func(ctx context.Context) error {
+		return k8sutils.ShutdownRedisPods(ctx, instance)
+	}})
+	if instance.Spec.Finalizer.DeletePVCs {
+		steps = append(steps, finalizerStep{"DeletePVCs", func(ctx context.Context) error {
+			return k8sutils.DeleteRedisPVCs(ctx, instance)
+		}})
+	}
+	timeout := k8sutils.GetFinalizerStepTimeout(instance)
+	for _, step := range steps {
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := step.run(stepCtx)
+		cancel()
+		if err != nil {
+			reqLogger.Error(err, "Finalizer step of redis failed, continuing the deletion", "Step", step.name)
+			r.Recorder.Eventf(instance, corev1.EventTypeWarning, "FinalizerStepFailed", "Finalizer step %s failed: %s", step.name, err.Error())
+			continue
+		}
+		reqLogger.Info("Finalizer step of redis is done", "Step", step.name)
+	}
+}
+
+// formRedisCluster runs a single attempt of forming the redis cluster and records its progress in the status, the
+// next attempt runs on the next reconcile. Once the formation exceeds its timeout or maximum retries it is reported
+// as failed and no more attempts are made, until the limits are raised.
+func (r *RedisReconciler) formRedisCluster(ctx context.Context, instance *redisv1beta1.Redis, joined int, desired int) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	progress := instance.Status.ClusterInit
+	if progress == nil {
+		progress = &redisv1beta1.ClusterInitStatus{StartTime: metav1.Now()}
+		instance.Status.ClusterInit = progress
+	}
+	progress.JoinedNodes = int32(joined)
+	progress.DesiredNodes = int32(desired)
+	timeout := k8sutils.GetClusterInitTimeout(instance)
+	maxRetries := k8sutils.GetClusterInitMaxRetries(instance)
+	elapsed := time.Since(progress.StartTime.Time).Round(time.Second)
+	if progress.Attempts >= maxRetries || elapsed > timeout {
+		message := fmt.Sprintf("Redis cluster formation failed after %d attempts in %s, %d of %d nodes joined", progress.Attempts, elapsed, joined, desired)
+		condition := meta.FindStatusCondition(instance.Status.Conditions, conditionClusterFormed)
+		if condition == nil || condition.Reason != "FormationFailed" {
+			reqLogger.Info("Giving up forming the redis cluster", "Attempts", progress.Attempts, "Elapsed", elapsed)
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "ClusterFormationFailed", message)
+		}
+		r.setCondition(instance, conditionClusterFormed, metav1.ConditionFalse, "FormationFailed", message)
+		r.updateRedisStatus(instance)
+		return
+	}
+	progress.Attempts++
+	r.setCondition(instance, conditionClusterFormed, metav1.ConditionFalse, "Forming", fmt.Sprintf("Forming redis cluster, attempt %d of %d, %d of %d nodes joined", progress.Attempts, maxRetries, joined, desired))
+	r.updateRedisStatus(instance)
+	if instance.Spec.RestoreFrom != nil {
+		k8sutils.RecoverRestoredRedisCluster(ctx, instance)
+	} else {
+		k8sutils.ExecuteRedisClusterCommand(ctx, instance)
+	}
+	k8sutils.ExecuteRedisReplicationCommand(ctx, instance)
+	// the built-in formation is checked by redis-cli, a custom command is trusted only once redis reports it healthy
+	if instance.Spec.ClusterInit != nil && len(instance.Spec.ClusterInit.Command) > 0 && instance.Spec.RestoreFrom == nil {
+		if ok, err := k8sutils.CheckRedisClusterStateOK(ctx, instance); err != nil || !ok {
+			message := fmt.Sprintf("Custom cluster init command didn't leave the redis cluster in cluster_state:ok, attempt %d of %d", progress.Attempts, maxRetries)
+			if err != nil {
+				message = fmt.Sprintf("%s: %v", message, err)
+			}
+			reqLogger.Info(message)
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "ClusterInitCommandFailed", message)
+		}
+	}
+}
+
+// importRedisData starts importing the keys of the external redis once and tracks the progress of the import job in
+// the status. It reports whether the import succeeded, a failed import is retried only once its job is deleted and
+// keeps the redis cluster from being ready until then.
+func (r *RedisReconciler) importRedisData(ctx context.Context, instance *redisv1beta1.Redis) bool {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	progress := instance.Status.Import
+	if progress != nil && progress.Phase == "Succeeded" {
+		return true
+	}
+	if progress == nil || progress.Phase == "Failed" {
+		jobName, started, err := k8sutils.StartRedisImport(instance)
+		if err != nil {
+			reqLogger.Error(err, "Could not start importing the keys of the external redis")
+			r.Recorder.Event(instance, corev1.EventTypeWarning, "ImportFailed", err.Error())
+			return false
+		}
+		if progress != nil && !started {
+			// the failed import job is kept for inspection until it is deleted
+			return false
+		}
+		progress = &redisv1beta1.ImportStatus{Phase: "Running", JobName: jobName, StartTime: metav1.Now()}
+		instance.Status.Import = progress
+		if started {
+			r.Recorder.Eventf(instance, corev1.EventTypeNormal, "ImportStarted", "Importing the keys of the external redis in job %s", jobName)
+		}
+	}
+	if sourceKeys, importedKeys, err := k8sutils.GetRedisImportProgress(ctx, instance); err != nil {
+		reqLogger.Info("Could not count the imported keys", "Reason", err.Error())
+	} else {
+		progress.SourceKeys = sourceKeys
+		progress.ImportedKeys = importedKeys
+	}
+	done, err := k8sutils.GetRedisImportResult(instance, progress.JobName)
+	if errors.IsNotFound(err) {
+		// the import job was deleted while running, it is started again on the next reconcile
+		instance.Status.Import = nil
+		r.updateRedisStatus(instance)
+		return false
+	}
+	switch {
+	case !done:
+		progress.Message = ""
+		if err != nil {
+			progress.Message = err.Error()
+		}
+		r.setCondition(instance, conditionDataImported, metav1.ConditionFalse, "Importing", fmt.Sprintf("Importing the keys of the external redis, %d of %d keys imported", progress.ImportedKeys, progress.SourceKeys))
+	case err != nil:
+		now := metav1.Now()
+		progress.Phase = "Failed"
+		progress.CompletionTime = &now
+		progress.Message = err.Error()
+		r.Recorder.Event(instance, corev1.EventTypeWarning, "ImportFailed", err.Error())
+		r.setCondition(instance, conditionDataImported, metav1.ConditionFalse, "ImportFailed", err.Error())
+	default:
+		now := metav1.Now()
+		progress.Phase = "Succeeded"
+		progress.CompletionTime = &now
+		progress.Message = ""
+		message := fmt.Sprintf("Imported the keys of the external redis in %s", now.Sub(progress.StartTime.Time).Round(time.Second))
+		r.Recorder.Event(instance, corev1.EventTypeNormal, "ImportSucceeded", message)
+		r.setCondition(instance, conditionDataImported, metav1.ConditionTrue, "ImportSucceeded", message)
+	}
+	r.updateRedisStatus(instance)
+	return done && err == nil
+}
+
+// checkSlotCoverage returns the reason and message of a degraded redis cluster when its slots aren't served. A cluster
+// with cluster-require-full-coverage no keeps serving the covered slots, so it is only degraded when no slot is
+// served at all.
+func (r *RedisReconciler) checkSlotCoverage(ctx context.Context, instance *redisv1beta1.Redis) (string, string) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	covered, err := k8sutils.GetRedisClusterSlotCoverage(ctx, instance)
+	if err != nil {
+		return "SlotCoverageUnknown", err.Error()
+	}
+	if covered == k8sutils.RedisClusterSlots {
+		return "", ""
+	}
+	message := fmt.Sprintf("%d of %d slots of the redis cluster are served", covered, k8sutils.RedisClusterSlots)
+	if k8sutils.IsRedisFullCoverageRequired(ctx, instance) {
+		return "SlotsNotCovered", message
+	}
+	if covered == 0 {
+		return "NoSlotsCovered", message
+	}
+	reqLogger.Info("Redis cluster serves part of the slots, full coverage isn't required", "Slots.Covered", covered)
+	return "", ""
+}
+
+// markDegraded records that the redis setup is unhealthy, it is only reported as degraded once it stays unhealthy
+// for longer than the degraded grace period, so that transient failures don't page anyone
+func (r *RedisReconciler) markDegraded(instance *redisv1beta1.Redis, reason string, message string) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	gracePeriod := defaultDegradedGracePeriod
+	if instance.Spec.DegradedGracePeriodSeconds != nil {
+		gracePeriod = time.Second * time.Duration(*instance.Spec.DegradedGracePeriodSeconds)
+	}
+	changed := false
+	if instance.Status.DegradedSince == nil {
+		now := metav1.Now()
+		instance.Status.DegradedSince = &now
+		changed = true
+	}
+	if time.Since(instance.Status.DegradedSince.Time) >= gracePeriod {
+		condition := meta.FindStatusCondition(instance.Status.Conditions, conditionDegraded)
+		if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != reason || condition.Message != message {
+			reqLogger.Info("Redis is degraded", "Reason", reason, "Since", instance.Status.DegradedSince.Time)
+			r.Recorder.Event(instance, corev1.EventTypeWarning, conditionDegraded, message)
+			r.setCondition(instance, conditionDegraded, metav1.ConditionTrue, reason, message)
+			changed = true
+		}
+	}
+	if changed {
+		r.updateRedisStatus(instance)
+	}
+}
+
+// clearDegraded records that the redis setup is healthy again
+func (r *RedisReconciler) clearDegraded(instance *redisv1beta1.Redis) {
+	if instance.Status.DegradedSince == nil && !meta.IsStatusConditionTrue(instance.Status.Conditions, conditionDegraded) {
+		return
+	}
+	instance.Status.DegradedSince = nil
+	r.setCondition(instance, conditionDegraded, metav1.ConditionFalse, "Healthy", "Redis is healthy")
+	r.updateRedisStatus(instance)
+}
+
+// setCondition sets a status condition of the Redis observed at its current generation
+func (r *RedisReconciler) setCondition(instance *redisv1beta1.Redis, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// exportRedisTopology exports the redis topology once for every new value of the export annotation
+func (r *RedisReconciler) exportRedisTopology(ctx context.Context, instance *redisv1beta1.Redis, export string) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	if err := k8sutils.ExportRedisTopology(ctx, instance); err != nil {
+		reqLogger.Error(err, "Failed in exporting topology for redis, will retry")
+		return
+	}
+	instance.Status.TopologyExport = export
+	r.updateRedisStatus(instance)
+}
+
+// updateRedisStatus records the applied spec and observed state in the Redis status
+func (r *RedisReconciler) updateRedisStatus(instance *redisv1beta1.Redis) {
+	reqLogger := r.Log.WithValues("Request.Namespace", instance.Namespace, "Request.Name", instance.Name)
+	instance.Spec.DeepCopyInto(&instance.Status.Cluster)
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		reqLogger.Error(err, "Failed in updating status for redis")
+	}
+}
+
+// mapSecretToRedis enqueues the redis resources of the namespace referencing the secret, so that credential
+// rotations done outside of the operator roll the redis pods without waiting for the next resync
+func (r *RedisReconciler) mapSecretToRedis(secret client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	redisList := &redisv1beta1.RedisList{}
+	if err := r.Client.List(context.TODO(), redisList, client.InNamespace(secret.GetNamespace())); err != nil {
+		r.Log.Error(err, "Could not list redis resources referencing the secret", "Secret.Name", secret.GetName())
+		return nil
+	}
+	for i := range redisList.Items {
+		for _, name := range k8sutils.GetReferencedSecretNames(&redisList.Items[i]) {
+			if name == secret.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: secret.GetNamespace(), Name: redisList.Items[i].Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// mapNodeToRedis enqueues the redis clusters when a node is cordoned, so that the masters on it are failed over
+// before the drain evicts them instead of waiting for the next resync
+func (r *RedisReconciler) mapNodeToRedis(object client.Object) []reconcile.Request {
+	node, ok := object.(*corev1.Node)
+	if !ok || !k8sutils.IsNodeDraining(node) {
+		return nil
+	}
+	// only the redis resources with pods on the node have masters to fail over
+	pods, err := k8sutils.GenerateK8sClient().CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node.Name,
+		LabelSelector: "role in (master,slave)",
+	})
+	if err != nil {
+		r.Log.Error(err, "Could not list the redis pods of the draining node", "Node.Name", node.Name)
+		return nil
+	}
+	apps := map[string]bool{}
+	for _, pod := range pods.Items {
+		apps[pod.Namespace+"/"+pod.Labels["app"]] = true
+	}
+	var requests []reconcile.Request
+	redisList := &redisv1beta1.RedisList{}
+	if err := r.Client.List(context.TODO(), redisList); err != nil {
+		r.Log.Error(err, "Could not list redis resources for the draining node", "Node.Name", node.Name)
+		return nil
+	}
+	for i, redis := range redisList.Items {
+		name := redis.Namespace + "/" + k8sutils.GetRedisName(&redisList.Items[i])
+		if redis.Spec.Mode == "cluster" && (apps[name+"-master"] || apps[name+"-slave"]) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: redis.Namespace, Name: redis.Name}})
+		}
+	}
+	return requests
+}
+
+// podDisruptionBudgetHandler enqueues the redis owning a pod disruption budget, and drops a pod disruption budget
+// changed or deleted directly from the reconcile cache, so that the reconcile reverts the change
+type podDisruptionBudgetHandler struct {
+	*handler.EnqueueRequestForOwner
+}
+
+// Update forgets the pod disruption budget when its spec or metadata changed, status updates are ignored
+func (h podDisruptionBudgetHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if evt.ObjectOld.GetGeneration() != evt.ObjectNew.GetGeneration() ||
+		!apiequality.Semantic.DeepEqual(evt.ObjectOld.GetLabels(), evt.ObjectNew.GetLabels()) ||
+		!apiequality.Semantic.DeepEqual(evt.ObjectOld.GetAnnotations(), evt.ObjectNew.GetAnnotations()) {
+		k8sutils.ForgetPodDisruptionBudget(evt.ObjectNew.GetNamespace(), evt.ObjectNew.GetName())
+	}
+	h.EnqueueRequestForOwner.Update(evt, q)
+}
+
+// Delete forgets the deleted pod disruption budget
+func (h podDisruptionBudgetHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	k8sutils.ForgetPodDisruptionBudget(evt.Object.GetNamespace(), evt.Object.GetName())
+	h.EnqueueRequestForOwner.Delete(evt, q)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *RedisReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&redisv1beta1.Redis{}).
+	builder := ctrl.NewControllerManagedBy(mgr).
+		// status updates don't trigger a reconcile, since every reconcile records its result in the status
+		For(&redisv1beta1.Redis{}, ctrlbuilder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&source.Kind{Type: &policyv1beta1.PodDisruptionBudget{}}, podDisruptionBudgetHandler{
+			EnqueueRequestForOwner: &handler.EnqueueRequestForOwner{OwnerType: &redisv1beta1.Redis{}, IsController: true},
+		}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToRedis))
+	if r.WatchNodes {
+		builder = builder.Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.mapNodeToRedis))
+	}
+	return builder.
+		WithOptions(controller.Options{RateLimiter: r.RateLimiter}).
 		Complete(r)
 }
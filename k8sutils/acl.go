@@ -0,0 +1,85 @@
+package k8sutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/go-redis/redis"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+)
+
+const (
+	aclMountPath  = "/etc/redis/acl"
+	aclDefaultKey = "user.acl"
+)
+
+// getACLKey returns the key of the ACL file inside the configmap
+func getACLKey(cr *redisv1beta1.Redis) string {
+	if cr.Spec.ACL.Key != "" {
+		return cr.Spec.ACL.Key
+	}
+	return aclDefaultKey
+}
+
+// getACLVolume returns the volume mounting the ACL configmap
+func getACLVolume(cr *redisv1beta1.Redis) corev1.Volume {
+	return corev1.Volume{
+		Name: GetRedisName(cr) + "-acl",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: cr.Spec.ACL.ConfigMap,
+				},
+			},
+		},
+	}
+}
+
+// GetACLChecksum returns the checksum of the ACL file stored in the configmap
+func GetACLChecksum(cr *redisv1beta1.Redis) (string, error) {
+	configMap, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Get(context.TODO(), cr.Spec.ACL.ConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	aclFile, ok := configMap.Data[getACLKey(cr)]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in configmap %s", getACLKey(cr), cr.Spec.ACL.ConfigMap)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(aclFile))), nil
+}
+
+// ReloadRedisACL runs ACL LOAD on all redis pods once the mounted ACL file matches the checksum.
+// It returns the per pod result and whether every pod loaded the file.
+func ReloadRedisACL(ctx context.Context, cr *redisv1beta1.Redis, checksum string) ([]redisv1beta1.ACLLoadStatus, bool) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	var results []redisv1beta1.ACLLoadStatus
+	loaded := true
+	for _, pod := range getRedisPods(cr) {
+		result := redisv1beta1.ACLLoadStatus{PodName: pod.Name}
+		cmd := []string{"sha256sum", aclMountPath + "/" + getACLKey(cr)}
+		output, err := executeCommandOutput(cr, cmd, pod.Name, GetRedisName(cr)+"-"+pod.Role)
+		if err != nil {
+			result.Message = "Failed to read ACL file: " + strings.TrimSpace(output+" "+err.Error())
+		} else if !strings.HasPrefix(output, checksum) {
+			result.Message = "ACL file is not yet synced in the pod"
+		} else {
+			client := configureRedisClient(ctx, cr, pod.Name)
+			aclCmd := redis.NewStatusCmd("acl", "load")
+			if err := client.Process(aclCmd); err != nil {
+				reqLogger.Error(err, "Redis ACL load failed", "Pod.Name", pod.Name)
+				result.Message = err.Error()
+			} else {
+				result.Loaded = true
+			}
+			client.Close()
+		}
+		if !result.Loaded {
+			loaded = false
+		}
+		results = append(results, result)
+	}
+	return results, loaded
+}
@@ -0,0 +1,176 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"time"
+)
+
+// redisAdminJobTimeout is how long the reconcile waits for an admin job, a job still running then is left running and
+// collected by a later reconcile
+const redisAdminJobTimeout = time.Second * 30
+
+// getAdminJobImage returns the image of the jobs running the redis-cli admin commands, none when the commands are
+// run in the redis containers
+func getAdminJobImage(cr *redisv1beta1.Redis) string {
+	if cr.Spec.ClusterInit == nil {
+		return ""
+	}
+	return cr.Spec.ClusterInit.Image
+}
+
+// getAdminJobLabels returns the labels of the pods of the redis admin jobs
+func getAdminJobLabels(cr *redisv1beta1.Redis) map[string]string {
+	return map[string]string{
+		"app": GetRedisName(cr) + "-admin",
+	}
+}
+
+// withoutPasswordArgs returns the redis-cli command without its -a password arguments, the admin jobs pass the
+// password through REDISCLI_AUTH instead so that it doesn't show in the job spec
+func withoutPasswordArgs(cmd []string) []string {
+	var args []string
+	for i := 0; i < len(cmd); i++ {
+		if cmd[i] == "-a" && i+1 < len(cmd) {
+			i++
+			continue
+		}
+		args = append(args, cmd[i])
+	}
+	return args
+}
+
+// generateAdminJobDef generates the job running a redis admin command with the admin image, as the service account of
+// the redis masters so that its image pull secrets apply. The pod runs with the security context, resources, node
+// selector and tolerations of the redis pods, so that it is admitted and scheduled wherever they are.
+func generateAdminJobDef(cr *redisv1beta1.Redis, cmd []string) *batchv1.Job {
+	backoffLimit := int32(0)
+	labels := getAdminJobLabels(cr)
+	env := append(getRedisPasswordEnv(cr, "REDIS_PASSWORD"), getRedisPasswordEnv(cr, "REDISCLI_AUTH")...)
+	objectMeta := GenerateRedisObjectMetaInformation(cr, "", labels, nil)
+	objectMeta.GenerateName = GetRedisName(cr) + "-admin-"
+	job := &batchv1.Job{
+		TypeMeta:   GenerateMetaInformation("Job", "batch/v1"),
+		ObjectMeta: objectMeta,
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mergeStringMaps(cr.Spec.GlobalConfig.Labels, labels),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: getServiceAccountName(cr, "master"),
+					NodeSelector:       cr.Spec.NodeSelector,
+					SecurityContext:    cr.Spec.SecurityContext,
+					Containers: []corev1.Container{{
+						Name:            "redis-admin",
+						Image:           getAdminJobImage(cr),
+						ImagePullPolicy: cr.Spec.GlobalConfig.ImagePullPolicy,
+						Command:         withoutPasswordArgs(cmd),
+						Env:             env,
+						Resources:       getRedisResources(cr),
+					}},
+				},
+			},
+		},
+	}
+	if cr.Spec.Tolerations != nil {
+		job.Spec.Template.Spec.Tolerations = *cr.Spec.Tolerations
+	}
+	AddOwnerRefToObject(job, AsOwner(cr))
+	return job
+}
+
+// runAdminJob will run the redis admin command in a job with the admin image, wait up to redisAdminJobTimeout for it
+// to complete and log the output of its pod. The job is deleted once it is done, a job still running is left running
+// and no other admin command is run until a later reconcile collects it, so that a long reshard is never interrupted.
+func runAdminJob(ctx context.Context, cr *redisv1beta1.Redis, cmd []string) error {
+	jobs := GenerateK8sClient().BatchV1().Jobs(cr.Namespace)
+	running, err := collectAdminJobs(cr)
+	if err != nil {
+		return err
+	}
+	if running != "" {
+		return fmt.Errorf("redis admin job %s is still running", running)
+	}
+	created, err := jobs.Create(context.TODO(), generateAdminJobDef(cr, cmd), metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	name := created.Name
+	deadline := time.Now().Add(redisAdminJobTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second * 2):
+		}
+		job, err := jobs.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+			continue
+		}
+		finishAdminJob(cr, job)
+		if job.Status.Failed > 0 {
+			return fmt.Errorf("redis admin job %s failed", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("redis admin job %s did not complete within %s, it is left running", name, redisAdminJobTimeout)
+}
+
+// collectAdminJobs will log the output of the finished redis admin jobs and delete them, it returns the name of an
+// admin job still running. The import job is left alone, since its status is the result of the import.
+func collectAdminJobs(cr *redisv1beta1.Redis) (string, error) {
+	jobs, err := GenerateK8sClient().BatchV1().Jobs(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=" + GetRedisName(cr) + "-admin",
+	})
+	if err != nil {
+		return "", err
+	}
+	running := ""
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Name == getImportJobName(cr) {
+			continue
+		}
+		if job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+			running = job.Name
+			continue
+		}
+		finishAdminJob(cr, job)
+	}
+	return running, nil
+}
+
+// finishAdminJob will log the output of the finished redis admin job and delete it
+func finishAdminJob(cr *redisv1beta1.Redis, job *batchv1.Job) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	reqLogger.Info("Redis admin job is done", "Job.Name", job.Name, "Failed", job.Status.Failed > 0, "Output", getAdminJobOutput(cr, job.Name))
+	propagation := metav1.DeletePropagationBackground
+	if err := GenerateK8sClient().BatchV1().Jobs(cr.Namespace).Delete(context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+		reqLogger.Error(err, "Could not delete the redis admin job", "Job.Name", job.Name)
+	}
+}
+
+// getAdminJobOutput returns the logs of the pod of the redis admin job
+func getAdminJobOutput(cr *redisv1beta1.Redis, jobName string) string {
+	pods, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "job-name=" + jobName,
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+	output, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{}).Do(context.TODO()).Raw()
+	if err != nil {
+		return ""
+	}
+	return string(output)
+}
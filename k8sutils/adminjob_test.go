@@ -0,0 +1,65 @@
+package k8sutils
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateAdminJobDefFollowsTheRedisPods(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	serviceAccount := "redis-master"
+	cr.Spec.Master.ServiceAccountName = &serviceAccount
+	cr.Spec.NodeSelector = map[string]string{"pool": "redis"}
+	cr.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: &[]bool{true}[0]}
+	tolerations := []corev1.Toleration{{Key: "dedicated", Value: "redis", Effect: corev1.TaintEffectNoSchedule}}
+	cr.Spec.Tolerations = &tolerations
+
+	spec := generateAdminJobDef(cr, []string{"redis-cli", "--cluster", "check"}).Spec.Template.Spec
+	if spec.ServiceAccountName != serviceAccount {
+		t.Errorf("expected the admin job to run as the service account of the masters, got %q", spec.ServiceAccountName)
+	}
+	if spec.NodeSelector["pool"] != "redis" || spec.SecurityContext != cr.Spec.SecurityContext || len(spec.Tolerations) != 1 {
+		t.Errorf("expected the admin job to be scheduled like the redis pods, got %+v", spec)
+	}
+	if !apiequality.Semantic.DeepEqual(spec.Containers[0].Resources, getRedisResources(cr)) {
+		t.Errorf("expected the admin job to get the resources of the redis containers, got %+v", spec.Containers[0].Resources)
+	}
+}
+
+func TestCollectAdminJobsKeepsRunningJobs(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	jobs := client.BatchV1().Jobs("default")
+	for name, status := range map[string]batchv1.JobStatus{
+		"redis-admin-done":    {Succeeded: 1},
+		"redis-admin-running": {Active: 1},
+		"redis-import":        {Failed: 1},
+	} {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: getAdminJobLabels(cr)},
+			Status:     status,
+		}
+		if _, err := jobs.Create(context.TODO(), job, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	running, err := collectAdminJobs(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running != "redis-admin-running" {
+		t.Errorf("expected the running admin job to be reported, got %q", running)
+	}
+	if _, err := jobs.Get(context.TODO(), "redis-admin-done", metav1.GetOptions{}); err == nil {
+		t.Error("expected the finished admin job to be deleted")
+	}
+	if _, err := jobs.Get(context.TODO(), "redis-import", metav1.GetOptions{}); err != nil {
+		t.Error("expected the import job to be kept")
+	}
+}
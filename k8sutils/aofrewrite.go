@@ -0,0 +1,104 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+	"time"
+)
+
+// redisAOFRewriteTimeout is the time a redis pod is given to rewrite its append only file
+const redisAOFRewriteTimeout = time.Minute * 5
+
+// GetNextAOFRewriteTime returns when the append only files are rewritten next, the first time of the schedule after
+// the last rewrite. The time is in UTC.
+func GetNextAOFRewriteTime(cr *redisv1beta1.Redis, lastRewrite time.Time) (time.Time, error) {
+	schedule, err := parseCronSchedule(cr.Spec.Persistence.AOFRewriteSchedule.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.next(lastRewrite.UTC()), nil
+}
+
+// ContinueRedisAOFRewrite will advance the rewrite of the append only files of the redis pods with BGREWRITEAOF, one
+// pod at a time so that only one pod pays the cost of the rewrite at once. The rewrite of the current pod is polled
+// and the next pod is only started once it is done, so that the reconcile never waits for a rewrite. Pods without an
+// append only file are skipped. It returns whether the run is done.
+func ContinueRedisAOFRewrite(ctx context.Context, cr *redisv1beta1.Redis, run *redisv1beta1.AOFRewriteRun) (bool, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if run.RewritingPod != "" {
+		done, err := isRedisAOFRewriteDone(ctx, cr, run.RewritingPod)
+		if err == nil && !done && run.PodStartTime != nil && time.Since(run.PodStartTime.Time) > redisAOFRewriteTimeout {
+			err = fmt.Errorf("redis BGREWRITEAOF did not finish on pod %s within %s", run.RewritingPod, redisAOFRewriteTimeout)
+		}
+		if err != nil {
+			// the pod is rewritten again on the next reconcile
+			run.RewritingPod = ""
+			run.PodStartTime = nil
+			return false, err
+		}
+		if !done {
+			return false, nil
+		}
+		reqLogger.Info("Rewrote the append only file of redis", "Pod.Name", run.RewritingPod)
+		run.RewritingPod = ""
+		run.PodStartTime = nil
+		run.NextPod++
+	}
+	pods := getRedisPods(cr)
+	for ; int(run.NextPod) < len(pods); run.NextPod++ {
+		pod := pods[run.NextPod]
+		if isPodQuarantined(cr, pod.Name) {
+			continue
+		}
+		started, err := startRedisAOFRewrite(ctx, cr, pod.Name)
+		if err != nil {
+			return false, err
+		}
+		if started {
+			now := metav1.Now()
+			run.RewritingPod = pod.Name
+			run.PodStartTime = &now
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// startRedisAOFRewrite will run BGREWRITEAOF on the redis pod, it returns false when the pod has no append only file
+func startRedisAOFRewrite(ctx context.Context, cr *redisv1beta1.Redis, podName string) (bool, error) {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	output, err := client.Info("persistence").Result()
+	if err != nil {
+		return false, err
+	}
+	if parseRedisInfo(output)["aof_enabled"] != "1" {
+		return false, nil
+	}
+	if err := client.BgRewriteAOF().Err(); err != nil && !strings.Contains(err.Error(), "in progress") {
+		return false, err
+	}
+	return true, nil
+}
+
+// isRedisAOFRewriteDone returns whether the append only file of the redis pod is rewritten, and an error when the
+// rewrite failed
+func isRedisAOFRewriteDone(ctx context.Context, cr *redisv1beta1.Redis, podName string) (bool, error) {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	output, err := client.Info("persistence").Result()
+	if err != nil {
+		return false, err
+	}
+	info := parseRedisInfo(output)
+	if info["aof_rewrite_in_progress"] != "0" || info["aof_rewrite_scheduled"] != "0" {
+		return false, nil
+	}
+	if info["aof_last_bgrewrite_status"] != "ok" {
+		return false, fmt.Errorf("redis BGREWRITEAOF failed on pod %s", podName)
+	}
+	return true, nil
+}
@@ -1,13 +1,21 @@
 package k8sutils
 
 import (
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-// GenerateK8sClient create client for kubernetes
-func GenerateK8sClient() *kubernetes.Clientset {
+// GenerateK8sClient create client for kubernetes, it is a variable so that tests can use a fake client
+var GenerateK8sClient = func() kubernetes.Interface {
 	config, _ := rest.InClusterConfig()
 	clientset, _ := kubernetes.NewForConfig(config)
 	return clientset
 }
+
+// GenerateK8sDynamicClient create dynamic client for kubernetes, used for the resources without typed clients
+var GenerateK8sDynamicClient = func() dynamic.Interface {
+	config, _ := rest.InClusterConfig()
+	client, _ := dynamic.NewForConfig(config)
+	return client
+}
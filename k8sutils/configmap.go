@@ -0,0 +1,435 @@
+package k8sutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	externalConfigMountPath  = "/etc/redis/external.conf.d"
+	externalConfigFile       = "redis-additional.conf"
+	configChecksumAnnotation = "redis.opstreelabs.in/config-checksum"
+	// pendingConfigAnnotation records on the configmap the dynamic directives which could not be set on every running
+	// redis pod, so that they are set again on the next reconcile
+	pendingConfigAnnotation = "redis.opstreelabs.in/pending-config"
+)
+
+// redisConfigMinVersion is the major and minor redis version introducing the configuration directive
+var redisConfigMinVersion = map[string][2]int{
+	"aof-use-rdb-preamble":          {4, 0},
+	"aof-timestamp-enabled":         {7, 0},
+	"appenddirname":                 {7, 0},
+	"shutdown-timeout":              {7, 0},
+	"repl-diskless-load":            {6, 0},
+	"activedefrag":                  {4, 0},
+	"active-defrag-ignore-bytes":    {4, 0},
+	"active-defrag-threshold-lower": {4, 0},
+	"active-defrag-threshold-upper": {4, 0},
+	"active-defrag-cycle-min":       {4, 0},
+	"active-defrag-cycle-max":       {4, 0},
+	"dynamic-hz":                    {5, 0},
+}
+
+// dynamicRedisConfig are the configuration directives applied with CONFIG SET instead of restarting redis
+var dynamicRedisConfig = map[string]bool{
+	"activedefrag":                  true,
+	"active-defrag-ignore-bytes":    true,
+	"active-defrag-threshold-lower": true,
+	"active-defrag-threshold-upper": true,
+	"active-defrag-cycle-min":       true,
+	"active-defrag-cycle-max":       true,
+	"auto-aof-rewrite-percentage":   true,
+	"cluster-replica-no-failover":   true,
+	"cluster-slave-no-failover":     true,
+	"dynamic-hz":                    true,
+	"hz":                            true,
+	"loglevel":                      true,
+	"maxclients":                    true,
+	"maxmemory-policy":              true,
+	"notify-keyspace-events":        true,
+	"proto-max-bulk-len":            true,
+	"repl-backlog-size":             true,
+	"repl-backlog-ttl":              true,
+	"replica-priority":              true,
+	"slave-priority":                true,
+	"tcp-keepalive":                 true,
+	"timeout":                       true,
+}
+
+// redisMemoryUnits are the multipliers of the memory units accepted in redis configuration values
+var redisMemoryUnits = map[string]int64{
+	"":   1,
+	"k":  1000,
+	"kb": 1024,
+	"m":  1000 * 1000,
+	"mb": 1024 * 1024,
+	"g":  1000 * 1000 * 1000,
+	"gb": 1024 * 1024 * 1024,
+}
+
+// parseRedisMemory parses a redis memory value like 512mb into bytes
+func parseRedisMemory(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	digits := strings.TrimRightFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	multiplier, ok := redisMemoryUnits[value[len(digits):]]
+	if !ok {
+		return 0, fmt.Errorf("unknown memory unit in %q", value)
+	}
+	number, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return number * multiplier, nil
+}
+
+// yesNo converts a boolean into a redis configuration value
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// generateRedisConfig returns the redis configuration directives for the role
+func generateRedisConfig(cr *redisv1beta1.Redis, role string) map[string]string {
+	config := map[string]string{}
+	for key, value := range cr.Spec.RedisConfig {
+		config[key] = value
+	}
+	roleConfig := map[string]string{}
+	if role == "master" {
+		roleConfig = cr.Spec.Master.RedisConfig
+	} else if role == "slave" {
+		roleConfig = cr.Spec.Slave.RedisConfig
+	}
+	for key, value := range roleConfig {
+		config[key] = value
+	}
+
+	if role == "slave" {
+		if cr.Spec.Slave.ReplicaReadOnly != nil {
+			config["replica-read-only"] = yesNo(*cr.Spec.Slave.ReplicaReadOnly)
+		}
+		if cr.Spec.Slave.ReplicaServeStaleData != nil {
+			config["replica-serve-stale-data"] = yesNo(*cr.Spec.Slave.ReplicaServeStaleData)
+		}
+		if cr.Spec.Slave.ReplicaPriority != nil && cr.Spec.Slave.ReplicaPriority.Default != nil {
+			for key, value := range generateReplicaPriorityConfig(cr, *cr.Spec.Slave.ReplicaPriority.Default) {
+				config[key] = value
+			}
+		}
+	}
+
+	if cr.Spec.AOF != nil {
+		if cr.Spec.AOF.UseRDBPreamble != nil {
+			config["aof-use-rdb-preamble"] = yesNo(*cr.Spec.AOF.UseRDBPreamble)
+		}
+		if cr.Spec.AOF.TimestampEnabled != nil {
+			config["aof-timestamp-enabled"] = yesNo(*cr.Spec.AOF.TimestampEnabled)
+		}
+		if cr.Spec.AOF.DirName != nil {
+			config["appenddirname"] = *cr.Spec.AOF.DirName
+		}
+	}
+	if role == "standalone" && cr.Spec.ReplicaOf != nil {
+		config["replicaof"] = getReplicaOfAddress(cr)
+		if cr.Spec.ReplicaOf.PasswordSecret != nil {
+			config["include"] = replicaOfAuthMountPath + "/" + replicaOfAuthFile
+		}
+	}
+	if cr.Spec.ClientOutputBufferLimit != nil {
+		// the class is part of the key, since redis 6 only accepts a single class per directive
+		replicaClass := "replica"
+		if !redisVersionAtLeast(cr, 5, 0) {
+			replicaClass = "slave"
+		}
+		limits := map[string]*string{
+			"normal":     cr.Spec.ClientOutputBufferLimit.Normal,
+			replicaClass: cr.Spec.ClientOutputBufferLimit.Replica,
+			"pubsub":     cr.Spec.ClientOutputBufferLimit.PubSub,
+		}
+		for class, limit := range limits {
+			if limit != nil {
+				config["client-output-buffer-limit "+class] = *limit
+			}
+		}
+	}
+	if cr.Spec.Persistence != nil && cr.Spec.Persistence.DisableRDB {
+		config["save"] = `""`
+	}
+	if cr.Spec.Persistence != nil && cr.Spec.Persistence.AOFRewriteSchedule != nil {
+		config["auto-aof-rewrite-percentage"] = "0"
+	}
+	if cr.Spec.Replication != nil {
+		if cr.Spec.Replication.DisklessSync != nil {
+			config["repl-diskless-sync"] = yesNo(*cr.Spec.Replication.DisklessSync)
+		}
+		if cr.Spec.Replication.DisklessSyncDelay != nil {
+			config["repl-diskless-sync-delay"] = strconv.Itoa(int(*cr.Spec.Replication.DisklessSyncDelay))
+		}
+		if cr.Spec.Replication.DisklessLoad != nil {
+			config["repl-diskless-load"] = *cr.Spec.Replication.DisklessLoad
+		}
+		if cr.Spec.Replication.BacklogSize != nil {
+			config["repl-backlog-size"] = *cr.Spec.Replication.BacklogSize
+		}
+		if cr.Spec.Replication.BacklogTTL != nil {
+			config["repl-backlog-ttl"] = strconv.Itoa(int(*cr.Spec.Replication.BacklogTTL))
+		}
+	}
+	if defrag := cr.Spec.ActiveDefrag; defrag != nil {
+		if defrag.Enabled != nil {
+			config["activedefrag"] = yesNo(*defrag.Enabled)
+		}
+		if defrag.IgnoreBytes != nil {
+			config["active-defrag-ignore-bytes"] = *defrag.IgnoreBytes
+		}
+		if defrag.ThresholdLower != nil {
+			config["active-defrag-threshold-lower"] = strconv.Itoa(int(*defrag.ThresholdLower))
+		}
+		if defrag.ThresholdUpper != nil {
+			config["active-defrag-threshold-upper"] = strconv.Itoa(int(*defrag.ThresholdUpper))
+		}
+		if defrag.CycleMin != nil {
+			config["active-defrag-cycle-min"] = strconv.Itoa(int(*defrag.CycleMin))
+		}
+		if defrag.CycleMax != nil {
+			config["active-defrag-cycle-max"] = strconv.Itoa(int(*defrag.CycleMax))
+		}
+	}
+	if cr.Spec.MaxClients != nil {
+		config["maxclients"] = strconv.Itoa(int(*cr.Spec.MaxClients))
+	}
+	if cr.Spec.Databases != nil {
+		config["databases"] = strconv.Itoa(int(*cr.Spec.Databases))
+	}
+	if cr.Spec.NotifyKeyspaceEvents != nil {
+		config["notify-keyspace-events"] = strconv.Quote(*cr.Spec.NotifyKeyspaceEvents)
+	}
+	if cr.Spec.LogLevel != nil {
+		config["loglevel"] = *cr.Spec.LogLevel
+	}
+	if cr.Spec.Hz != nil {
+		config["hz"] = strconv.Itoa(int(*cr.Spec.Hz))
+	}
+	if cr.Spec.DynamicHz != nil {
+		config["dynamic-hz"] = yesNo(*cr.Spec.DynamicHz)
+	}
+	if cr.Spec.Supervised != nil {
+		config["supervised"] = *cr.Spec.Supervised
+	}
+	if cr.Spec.MaxMemoryPolicy != nil {
+		config["maxmemory-policy"] = *cr.Spec.MaxMemoryPolicy
+	}
+	if cr.Spec.ProtoMaxBulkLen != nil {
+		config["proto-max-bulk-len"] = *cr.Spec.ProtoMaxBulkLen
+	}
+	if cr.Spec.TCPKeepalive != nil {
+		config["tcp-keepalive"] = strconv.Itoa(int(*cr.Spec.TCPKeepalive))
+	}
+	if cr.Spec.ClientTimeout != nil {
+		config["timeout"] = strconv.Itoa(int(*cr.Spec.ClientTimeout))
+	}
+	if cr.Spec.TCPBacklog != nil {
+		config["tcp-backlog"] = strconv.Itoa(int(*cr.Spec.TCPBacklog))
+	}
+	if cr.Spec.ShutdownTimeout != nil {
+		config["shutdown-timeout"] = strconv.Itoa(int(*cr.Spec.ShutdownTimeout))
+	}
+	removeUnsupportedRedisConfig(cr, config)
+	return config
+}
+
+// isAOFEnabled reports whether redis writes an append only file, the redis image turns it on when storage is
+// configured unless the redis configuration turns it off
+func isAOFEnabled(cr *redisv1beta1.Redis) bool {
+	role := "standalone"
+	if cr.Spec.Mode == "cluster" {
+		role = "master"
+	}
+	if appendOnly, ok := generateRedisConfig(cr, role)["appendonly"]; ok {
+		return appendOnly == "yes"
+	}
+	return cr.Spec.Storage != nil
+}
+
+// IsPersistenceDisabled reports whether redis neither takes RDB snapshots nor writes an append only file, so its
+// data is lost whenever it restarts
+func IsPersistenceDisabled(cr *redisv1beta1.Redis) bool {
+	return cr.Spec.Persistence != nil && cr.Spec.Persistence.DisableRDB && !isAOFEnabled(cr)
+}
+
+// removeUnsupportedRedisConfig removes the directives which the redis version of the image does not support
+func removeUnsupportedRedisConfig(cr *redisv1beta1.Redis, config map[string]string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	for key, version := range redisConfigMinVersion {
+		if _, ok := config[key]; ok && !redisVersionAtLeast(cr, version[0], version[1]) {
+			reqLogger.Info("Skipping redis configuration directive unsupported by the redis image", "Directive", key, "Redis.Image", cr.Spec.GlobalConfig.Image)
+			delete(config, key)
+		}
+	}
+}
+
+// renderRedisConfig renders the redis configuration directives as a redis.conf file
+func renderRedisConfig(config map[string]string) string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var rendered strings.Builder
+	for _, key := range keys {
+		rendered.WriteString(key + " " + config[key] + "\n")
+	}
+	return rendered.String()
+}
+
+// parseRedisConfig parses the redis configuration directives of a rendered redis.conf file
+func parseRedisConfig(rendered string) map[string]string {
+	config := map[string]string{}
+	for _, line := range strings.Split(rendered, "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) == 2 {
+			config[fields[0]] = fields[1]
+		}
+	}
+	return config
+}
+
+// getRedisConfigChecksum returns the checksum of the rendered redis configuration of the role, dynamic directives
+// are left out so that changing them does not restart redis
+func getRedisConfigChecksum(cr *redisv1beta1.Redis, role string) string {
+	config := generateRedisConfig(cr, role)
+	for key := range dynamicRedisConfig {
+		delete(config, key)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(renderRedisConfig(config))))
+}
+
+// getChangedDynamicRedisConfig returns the dynamic directives which differ between the previous and the desired configuration
+func getChangedDynamicRedisConfig(previous map[string]string, desired map[string]string) map[string]string {
+	changed := map[string]string{}
+	for key := range dynamicRedisConfig {
+		if value, ok := desired[key]; ok && previous[key] != value {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// setDynamicRedisConfig will apply the dynamic directives on the running redis pods of the role with CONFIG SET, it
+// returns the sorted directives which failed on a pod
+func setDynamicRedisConfig(ctx context.Context, cr *redisv1beta1.Redis, role string, config map[string]string) []string {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	failed := map[string]bool{}
+	for _, pod := range getRedisPods(cr) {
+		if pod.Role != role {
+			continue
+		}
+		client := configureRedisClient(ctx, cr, pod.Name)
+		for key, value := range config {
+			// quoted values of the configuration file are sent without their quotes
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if err := client.ConfigSet(key, value).Err(); err != nil {
+				reqLogger.Error(err, "Failed in setting redis configuration, it will be retried", "Pod.Name", pod.Name, "Directive", key)
+				failed[key] = true
+				continue
+			}
+			reqLogger.Info("Redis configuration is set", "Pod.Name", pod.Name, "Directive", key, "Value", value)
+		}
+		client.Close()
+	}
+	keys := make([]string, 0, len(failed))
+	for key := range failed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getExternalConfigVolume returns the volume mounting the redis configuration of the role
+func getExternalConfigVolume(cr *redisv1beta1.Redis, role string) corev1.Volume {
+	return corev1.Volume{
+		Name: "external-config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetRedisName(cr) + "-" + role + "-config",
+				},
+			},
+		},
+	}
+}
+
+// GenerateConfigMapDef generates the configmap holding the redis configuration of the role
+func GenerateConfigMapDef(cr *redisv1beta1.Redis, role string) *corev1.ConfigMap {
+	labels := map[string]string{
+		"app":  GetRedisName(cr) + "-" + role,
+		"role": role,
+	}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   GenerateMetaInformation("ConfigMap", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-"+role+"-config", labels, GenerateSecretAnots()),
+		Data: map[string]string{
+			externalConfigFile: renderRedisConfig(generateRedisConfig(cr, role)),
+		},
+	}
+	AddOwnerRefToObject(configMap, AsOwner(cr))
+	return configMap
+}
+
+// getPendingDynamicRedisConfig returns the desired value of the dynamic directives recorded as pending on the configmap
+func getPendingDynamicRedisConfig(existing *corev1.ConfigMap, desired map[string]string) map[string]string {
+	pending := map[string]string{}
+	for _, key := range strings.Split(existing.Annotations[pendingConfigAnnotation], ",") {
+		if value, ok := desired[key]; ok && dynamicRedisConfig[key] {
+			pending[key] = value
+		}
+	}
+	return pending
+}
+
+// CreateRedisConfigMap will create or update the configmap holding the redis configuration of the role. The changed
+// dynamic directives, and the ones which failed before, are set on the running redis pods, the ones failing again are
+// recorded on the configmap.
+func CreateRedisConfigMap(ctx context.Context, cr *redisv1beta1.Redis, role string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	configMapBody := GenerateConfigMapDef(cr, role)
+	existingConfigMap, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Get(ctx, configMapBody.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating configmap for redis", "ConfigMap.Name", configMapBody.Name)
+		_, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Create(ctx, configMapBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating configmap for redis")
+		}
+		return
+	}
+	desired := parseRedisConfig(configMapBody.Data[externalConfigFile])
+	changed := getPendingDynamicRedisConfig(existingConfigMap, desired)
+	for key, value := range getChangedDynamicRedisConfig(parseRedisConfig(existingConfigMap.Data[externalConfigFile]), desired) {
+		changed[key] = value
+	}
+	if len(changed) > 0 {
+		if failed := setDynamicRedisConfig(ctx, cr, role, changed); len(failed) > 0 {
+			configMapBody.Annotations[pendingConfigAnnotation] = strings.Join(failed, ",")
+		}
+	}
+	if mergeObjectMeta(&existingConfigMap.ObjectMeta, configMapBody.ObjectMeta) || !apiequality.Semantic.DeepEqual(existingConfigMap.Data, configMapBody.Data) {
+		reqLogger.Info("Reconciling configmap for redis", "ConfigMap.Name", configMapBody.Name)
+		existingConfigMap.Data = configMapBody.Data
+		_, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Update(ctx, existingConfigMap, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating configmap for redis")
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package k8sutils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPendingDynamicRedisConfig(t *testing.T) {
+	existing := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{pendingConfigAnnotation: "maxclients,appendonly,hz"},
+	}}
+	desired := map[string]string{"maxclients": "20000", "appendonly": "yes"}
+	pending := getPendingDynamicRedisConfig(existing, desired)
+	if len(pending) != 1 || pending["maxclients"] != "20000" {
+		t.Errorf("expected only the desired dynamic directive to be retried, got %v", pending)
+	}
+	if pending := getPendingDynamicRedisConfig(&corev1.ConfigMap{}, desired); len(pending) != 0 {
+		t.Errorf("expected nothing to retry without the annotation, got %v", pending)
+	}
+}
@@ -0,0 +1,41 @@
+package k8sutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	after := time.Date(2021, time.May, 7, 12, 34, 56, 0, time.UTC) // a friday
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"* * * * *", time.Date(2021, time.May, 7, 12, 35, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, time.May, 7, 12, 45, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2021, time.May, 8, 3, 30, 0, 0, time.UTC)},
+		{"30 3 * * 1-5", time.Date(2021, time.May, 10, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 0", time.Date(2021, time.May, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 4 * * 7", time.Date(2021, time.May, 9, 4, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := parseCronSchedule(test.schedule)
+		if err != nil {
+			t.Fatalf("schedule %q: %v", test.schedule, err)
+		}
+		if got := schedule.next(after); !got.Equal(test.want) {
+			t.Errorf("schedule %q: expected the next time %s, got %s", test.schedule, test.want, got)
+		}
+	}
+}
+
+func TestParseCronScheduleRejectsInvalidExpressions(t *testing.T) {
+	for _, schedule := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := parseCronSchedule(schedule); err == nil {
+			t.Errorf("schedule %q: expected an error", schedule)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package k8sutils
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+	"sync/atomic"
+)
+
+// IsNodeDraining checks if the node is cordoned, which is the first step of draining it
+func IsNodeDraining(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// getDrainingNodes returns the names of the draining nodes among the nodes running the redis pods
+func getDrainingNodes(cr *redisv1beta1.Redis, podsByIP map[string]corev1.Pod) map[string]bool {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	draining := map[string]bool{}
+	checked := map[string]bool{}
+	for _, pod := range podsByIP {
+		if pod.Spec.NodeName == "" || checked[pod.Spec.NodeName] || atomic.LoadInt32(&nodeLookupForbidden) == 1 {
+			continue
+		}
+		checked[pod.Spec.NodeName] = true
+		node, err := GenerateK8sClient().CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+		if errors.IsForbidden(err) {
+			if atomic.CompareAndSwapInt32(&nodeLookupForbidden, 0, 1) {
+				reqLogger.Info("Operator is not allowed to read nodes, redis masters are not failed over before node drains", "Reason", err.Error())
+			}
+			return draining
+		}
+		if err != nil {
+			reqLogger.Error(err, "Could not get node info", "Node.Name", pod.Spec.NodeName)
+			continue
+		}
+		if IsNodeDraining(node) {
+			draining[node.Name] = true
+		}
+	}
+	return draining
+}
+
+// FailoverRedisMastersOnDrainingNodes will fail over the redis masters running on cordoned nodes to a healthy
+// replica on another node, so that the slots are served elsewhere before the pods are evicted. It returns the
+// masters which were failed over.
+func FailoverRedisMastersOnDrainingNodes(ctx context.Context, cr *redisv1beta1.Redis) []string {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return nil
+	}
+	draining := getDrainingNodes(cr, podsByIP)
+	if len(draining) == 0 {
+		return nil
+	}
+	var failedOver []string
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	for _, master := range nodes {
+		pod, ok := podsByIP[master.IP]
+		if !ok || !draining[pod.Spec.NodeName] || !strings.Contains(master.Flags, "master") || len(master.Slots) == 0 {
+			continue
+		}
+		replicaName := ""
+		for _, node := range nodes {
+			if replica, ok := podsByIP[node.IP]; ok && node.MasterID == master.ID && !strings.Contains(node.Flags, "fail") && !draining[replica.Spec.NodeName] {
+				replicaName = replica.Name
+				break
+			}
+		}
+		if replicaName == "" {
+			reqLogger.Info("Redis master on a draining node has no healthy replica on another node to fail over to", "Pod.Name", pod.Name, "Node.Name", pod.Spec.NodeName)
+			continue
+		}
+		reqLogger.Info("Failing over redis master before its node is drained", "Pod.Name", pod.Name, "Node.Name", pod.Spec.NodeName, "Replica", replicaName)
+		if err := runClusterCommand(ctx, cr, replicaName, "failover"); err != nil {
+			reqLogger.Error(err, "Redis failover before node drain failed", "Pod.Name", pod.Name)
+			continue
+		}
+		failedOver = append(failedOver, pod.Name)
+	}
+	return failedOver
+}
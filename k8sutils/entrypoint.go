@@ -0,0 +1,72 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+const (
+	entrypointMountPath  = "/etc/redis/entrypoint"
+	entrypointDefaultKey = "entrypoint.sh"
+	// redisImageEntrypoint is the entrypoint of the redis image, which renders the redis configuration and starts
+	// redis-server. The custom entrypoint scripts get it as their arguments.
+	redisImageEntrypoint = "/usr/bin/entrypoint.sh"
+)
+
+// getEntrypointConfigMap returns the configmap of the custom entrypoint script of the redis containers of the role
+func getEntrypointConfigMap(cr *redisv1beta1.Redis, role string) *redisv1beta1.EntrypointConfigMap {
+	if role == "master" {
+		return cr.Spec.Master.EntrypointConfigMap
+	}
+	if role == "slave" {
+		return cr.Spec.Slave.EntrypointConfigMap
+	}
+	return nil
+}
+
+// getEntrypointKey returns the key of the entrypoint script inside the configmap
+func getEntrypointKey(entrypoint *redisv1beta1.EntrypointConfigMap) string {
+	if entrypoint.Key != "" {
+		return entrypoint.Key
+	}
+	return entrypointDefaultKey
+}
+
+// getEntrypointVolume returns the volume mounting the entrypoint script, executable so that it can be the command
+// of the redis container
+func getEntrypointVolume(entrypoint *redisv1beta1.EntrypointConfigMap) corev1.Volume {
+	mode := int32(0755)
+	return corev1.Volume{
+		Name: "entrypoint",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: entrypoint.Name,
+				},
+				DefaultMode: &mode,
+			},
+		},
+	}
+}
+
+// CheckEntrypointConfigMaps returns an error when the configmap of a custom entrypoint script or its key doesn't exist,
+// the redis pods would otherwise fail to start
+func CheckEntrypointConfigMaps(cr *redisv1beta1.Redis) error {
+	for _, role := range []string{"master", "slave"} {
+		entrypoint := getEntrypointConfigMap(cr, role)
+		if entrypoint == nil {
+			continue
+		}
+		configMap, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Get(context.TODO(), entrypoint.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("%s.entrypointConfigMap can't be read: %v", role, err)
+		}
+		if _, ok := configMap.Data[getEntrypointKey(entrypoint)]; !ok {
+			return fmt.Errorf("key %s not found in configmap %s of %s.entrypointConfigMap", getEntrypointKey(entrypoint), entrypoint.Name, role)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sigs.k8s.io/yaml"
+	"time"
+)
+
+// RedisTopologyExportAnnotation requests an export of the redis topology, every new value triggers a new export
+const RedisTopologyExportAnnotation = "redis.opstreelabs.in/export-topology"
+
+// topologySnapshot is the live state of the redis setup stored by the topology export
+type topologySnapshot struct {
+	ExportedAt string                       `json:"exportedAt"`
+	Config     map[string]map[string]string `json:"config"`
+	Nodes      []topologySnapshotNode       `json:"nodes,omitempty"`
+}
+
+// topologySnapshotNode is a redis cluster node of the topology export
+type topologySnapshotNode struct {
+	ID       string   `json:"id"`
+	PodName  string   `json:"podName,omitempty"`
+	Role     string   `json:"role"`
+	MasterID string   `json:"masterID,omitempty"`
+	Slots    []string `json:"slots,omitempty"`
+}
+
+// getTopologyExportName returns the name of the configmap holding the topology export
+func getTopologyExportName(cr *redisv1beta1.Redis) string {
+	return GetRedisName(cr) + "-topology-export"
+}
+
+// generateTopologySnapshot reads the live topology and configuration of the redis setup
+func generateTopologySnapshot(ctx context.Context, cr *redisv1beta1.Redis) (*topologySnapshot, error) {
+	snapshot := &topologySnapshot{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Config:     map[string]map[string]string{},
+	}
+	if cr.Spec.Mode != "cluster" {
+		snapshot.Config["standalone"] = generateRedisConfig(cr, "standalone")
+		return snapshot, nil
+	}
+	snapshot.Config["master"] = generateRedisConfig(cr, "master")
+	snapshot.Config["slave"] = generateRedisConfig(cr, "slave")
+
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		return nil, err
+	}
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no redis cluster nodes found")
+	}
+	for _, node := range nodes {
+		snapshotNode := topologySnapshotNode{
+			ID:    node.ID,
+			Role:  "master",
+			Slots: node.Slots,
+		}
+		if node.MasterID != "-" {
+			snapshotNode.Role = "slave"
+			snapshotNode.MasterID = node.MasterID
+		}
+		if pod, ok := podsByIP[node.IP]; ok {
+			snapshotNode.PodName = pod.Name
+		}
+		snapshot.Nodes = append(snapshot.Nodes, snapshotNode)
+	}
+	return snapshot, nil
+}
+
+// GenerateTopologyExportDef generates the configmap holding the redis manifest and the live topology of the redis setup
+func GenerateTopologyExportDef(cr *redisv1beta1.Redis, snapshot *topologySnapshot) (*corev1.ConfigMap, error) {
+	manifest := redisv1beta1.Redis{
+		TypeMeta: GenerateMetaInformation("Redis", redisv1beta1.GroupVersion.String()),
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    cr.Labels,
+		},
+		Spec: cr.Spec,
+	}
+	manifestYAML, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	snapshotYAML, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   GenerateMetaInformation("ConfigMap", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, getTopologyExportName(cr), labels, GenerateSecretAnots()),
+		Data: map[string]string{
+			"redis.yaml":    string(manifestYAML),
+			"topology.yaml": string(snapshotYAML),
+		},
+	}
+	AddOwnerRefToObject(configMap, AsOwner(cr))
+	return configMap, nil
+}
+
+// ExportRedisTopology will store the redis manifest and the live topology of the redis setup in a configmap
+func ExportRedisTopology(ctx context.Context, cr *redisv1beta1.Redis) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	snapshot, err := generateTopologySnapshot(ctx, cr)
+	if err != nil {
+		return err
+	}
+	configMapBody, err := GenerateTopologyExportDef(cr, snapshot)
+	if err != nil {
+		return err
+	}
+	existingConfigMap, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Get(context.TODO(), configMapBody.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating topology export for redis", "ConfigMap.Name", configMapBody.Name)
+		_, err = GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Create(context.TODO(), configMapBody, metav1.CreateOptions{})
+		return err
+	}
+	reqLogger.Info("Updating topology export for redis", "ConfigMap.Name", configMapBody.Name)
+	mergeObjectMeta(&existingConfigMap.ObjectMeta, configMapBody.ObjectMeta)
+	existingConfigMap.Data = configMapBody.Data
+	_, err = GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Update(context.TODO(), existingConfigMap, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,92 @@
+package k8sutils
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"time"
+)
+
+const (
+	// RedisFinalizer holds the deletion of the redis resource until the finalizer steps ran
+	RedisFinalizer              = "redis.opstreelabs.in/finalizer"
+	defaultFinalizerStepTimeout = time.Minute
+)
+
+// GetFinalizerStepTimeout returns how long each finalizer step may run before it is given up
+func GetFinalizerStepTimeout(cr *redisv1beta1.Redis) time.Duration {
+	if cr.Spec.Finalizer.StepTimeoutSeconds == nil {
+		return defaultFinalizerStepTimeout
+	}
+	return time.Duration(*cr.Spec.Finalizer.StepTimeoutSeconds) * time.Second
+}
+
+// getRedisRoles returns the roles of the statefulsets of the redis setup
+func getRedisRoles(cr *redisv1beta1.Redis) []string {
+	if cr.Spec.Mode == "cluster" {
+		return []string{"master", "slave"}
+	}
+	return []string{"standalone"}
+}
+
+// ShutdownRedisPods will scale the redis statefulsets down to zero and wait for the pods to stop, so that every redis
+// shuts down gracefully before its statefulset is garbage collected
+func ShutdownRedisPods(ctx context.Context, cr *redisv1beta1.Redis) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	for _, role := range getRedisRoles(cr) {
+		statefulSet, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas == 0 {
+			continue
+		}
+		reqLogger.Info("Shutting down redis pods", "StatefulSet.Name", statefulSet.Name)
+		replicas := int32(0)
+		statefulSet.Spec.Replicas = &replicas
+		if _, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Update(context.TODO(), statefulSet, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	for _, role := range getRedisRoles(cr) {
+		for {
+			pods, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+				LabelSelector: "app=" + GetRedisName(cr) + "-" + role,
+			})
+			if err != nil {
+				return err
+			}
+			if len(pods.Items) == 0 {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second * 2):
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteRedisPVCs will delete the persistent volume claims of the redis pods, the statefulset controller labels
+// them with the pod selector of the statefulset
+func DeleteRedisPVCs(ctx context.Context, cr *redisv1beta1.Redis) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	for _, role := range getRedisRoles(cr) {
+		pvcs, err := GenerateK8sClient().CoreV1().PersistentVolumeClaims(cr.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "app=" + GetRedisName(cr) + "-" + role + ",role=" + role,
+		})
+		if err != nil {
+			return err
+		}
+		for _, pvc := range pvcs.Items {
+			reqLogger.Info("Deleting persistent volume claim of redis", "PVC.Name", pvc.Name)
+			err := GenerateK8sClient().CoreV1().PersistentVolumeClaims(cr.Namespace).Delete(ctx, pvc.Name, metav1.DeleteOptions{})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package k8sutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+)
+
+// getFunctionLibraries returns the redis function libraries stored in the configmap, by key
+func getFunctionLibraries(cr *redisv1beta1.Redis) (map[string]string, error) {
+	configMap, err := GenerateK8sClient().CoreV1().ConfigMaps(cr.Namespace).Get(context.TODO(), cr.Spec.Functions.ConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(configMap.Data) == 0 {
+		return nil, fmt.Errorf("configmap %s holds no function library", cr.Spec.Functions.ConfigMap)
+	}
+	return configMap.Data, nil
+}
+
+// GetFunctionsChecksum returns the checksum of the redis function libraries stored in the configmap
+func GetFunctionsChecksum(cr *redisv1beta1.Redis) (string, error) {
+	libraries, err := getFunctionLibraries(cr)
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(libraries))
+	for key := range libraries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key + "\x00" + libraries[key] + "\x00"))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// getLoadedFunctionCode returns the code of the function libraries loaded on the redis pod of the client
+func getLoadedFunctionCode(client *redisAdminClient) (map[string]bool, error) {
+	reply, err := client.Do("function", "list", "withcode").Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseFunctionList(reply), nil
+}
+
+// parseFunctionList returns the library_code of every library of the FUNCTION LIST WITHCODE reply, each library being
+// a list of field names followed by their value
+func parseFunctionList(reply interface{}) map[string]bool {
+	code := map[string]bool{}
+	libraries, _ := reply.([]interface{})
+	for _, library := range libraries {
+		fields, _ := library.([]interface{})
+		for i := 0; i+1 < len(fields); i += 2 {
+			if name, _ := fields[i].(string); name == "library_code" {
+				if value, ok := fields[i+1].(string); ok {
+					code[value] = true
+				}
+			}
+		}
+	}
+	return code
+}
+
+// LoadRedisFunctions runs FUNCTION LOAD REPLACE for every library of the configmap which isn't loaded with the same
+// code on the redis pods accepting writes, so that a pod restarted without its libraries gets them back. Replicas get
+// the libraries through replication. It returns the per pod and library result, the number of libraries loaded and
+// whether every library is loaded.
+func LoadRedisFunctions(ctx context.Context, cr *redisv1beta1.Redis) ([]redisv1beta1.FunctionLoadStatus, int, bool) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	libraries, err := getFunctionLibraries(cr)
+	if err != nil {
+		reqLogger.Error(err, "Failed in reading function libraries for redis")
+		return nil, 0, false
+	}
+	keys := make([]string, 0, len(libraries))
+	for key := range libraries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var results []redisv1beta1.FunctionLoadStatus
+	reloaded := 0
+	loaded := true
+	for _, pod := range getRedisPods(cr) {
+		if pod.Role == "slave" {
+			continue
+		}
+		client := configureRedisClient(ctx, cr, pod.Name)
+		current, err := getLoadedFunctionCode(client)
+		if err != nil {
+			reqLogger.Error(err, "Failed in listing the functions of redis", "Pod.Name", pod.Name)
+			current = map[string]bool{}
+		}
+		for _, key := range keys {
+			result := redisv1beta1.FunctionLoadStatus{PodName: pod.Name, Library: key, Loaded: true}
+			if !current[libraries[key]] {
+				reloaded++
+				if err := client.Do("function", "load", "replace", libraries[key]).Err(); err != nil {
+					reqLogger.Error(err, "Redis function load failed", "Pod.Name", pod.Name, "Library", key)
+					result.Message = err.Error()
+					result.Loaded = false
+					loaded = false
+				}
+			}
+			results = append(results, result)
+		}
+		client.Close()
+	}
+	return results, reloaded, loaded
+}
@@ -0,0 +1,151 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+)
+
+// importPasswordEnv is the environment variable of the import job holding the password of the external redis
+const importPasswordEnv = "REDIS_IMPORT_PASSWORD"
+
+// getImportJobName returns the name of the job importing the keys of the external redis
+func getImportJobName(cr *redisv1beta1.Redis) string {
+	return GetRedisName(cr) + "-import"
+}
+
+// getImportSourceAddress returns the host:port of the external redis the keys are imported from
+func getImportSourceAddress(cr *redisv1beta1.Redis) string {
+	port := int32(6379)
+	if cr.Spec.Import.Port != nil {
+		port = *cr.Spec.Import.Port
+	}
+	return cr.Spec.Import.Host + ":" + strconv.Itoa(int(port))
+}
+
+// getImportImage returns the image of the import job, which needs redis-cli
+func getImportImage(cr *redisv1beta1.Redis) string {
+	if cr.Spec.Import.Image != "" {
+		return cr.Spec.Import.Image
+	}
+	return cr.Spec.GlobalConfig.Image
+}
+
+// getImportCommand returns the shell command of the import job. The keys are copied, so that the external redis keeps
+// them, and the password of the external redis is read from the environment so that it doesn't show in the job spec.
+func getImportCommand(cr *redisv1beta1.Redis, target string) []string {
+	script := "exec redis-cli --cluster import " + target + " --cluster-from " + getImportSourceAddress(cr) + " --cluster-copy"
+	if cr.Spec.Import.Replace {
+		script += " --cluster-replace"
+	}
+	if cr.Spec.Import.PasswordSecret != nil {
+		script += " --cluster-from-pass \"$" + importPasswordEnv + "\""
+	}
+	return []string{"sh", "-c", script}
+}
+
+// generateImportJobDef generates the job importing the keys of the external redis into the redis cluster through the
+// node at the target address
+func generateImportJobDef(cr *redisv1beta1.Redis, target string) *batchv1.Job {
+	job := generateAdminJobDef(cr, getImportCommand(cr, target))
+	job.ObjectMeta.GenerateName = ""
+	job.ObjectMeta.Name = getImportJobName(cr)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Name = "redis-import"
+	container.Image = getImportImage(cr)
+	if passwordSecret := cr.Spec.Import.PasswordSecret; passwordSecret != nil {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: importPasswordEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: *passwordSecret.Name},
+					Key:                  *passwordSecret.Key,
+				},
+			},
+		})
+	}
+	return job
+}
+
+// StartRedisImport will create the job importing the keys of the external redis into the redis cluster, it returns the
+// name of the job and whether it was created. An existing import job is kept, so that a failed import is only retried
+// once its job is deleted.
+func StartRedisImport(cr *redisv1beta1.Redis) (string, bool, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	jobs := GenerateK8sClient().BatchV1().Jobs(cr.Namespace)
+	if job, err := jobs.Get(context.TODO(), getImportJobName(cr), metav1.GetOptions{}); err == nil {
+		return job.Name, false, nil
+	}
+	target := getRedisServerIP(RedisDetails{PodName: GetRedisName(cr) + "-master-0", Namespace: cr.Namespace})
+	if target == "" {
+		return "", false, fmt.Errorf("redis pod %s-master-0 has no IP yet", GetRedisName(cr))
+	}
+	job, err := jobs.Create(context.TODO(), generateImportJobDef(cr, target+":6379"), metav1.CreateOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	reqLogger.Info("Started importing the keys of the external redis", "Job.Name", job.Name, "Source", getImportSourceAddress(cr))
+	return job.Name, true, nil
+}
+
+// GetRedisImportResult returns whether the import job is done and the error it failed with, the last line of its
+// output being the error message
+func GetRedisImportResult(cr *redisv1beta1.Redis, jobName string) (bool, error) {
+	job, err := GenerateK8sClient().BatchV1().Jobs(cr.Namespace).Get(context.TODO(), jobName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	if job.Status.Succeeded > 0 {
+		return true, nil
+	}
+	if job.Status.Failed == 0 {
+		return false, nil
+	}
+	if output := strings.TrimSpace(getAdminJobOutput(cr, jobName)); output != "" {
+		lines := strings.Split(output, "\n")
+		return true, fmt.Errorf("redis import job %s failed: %s", jobName, lines[len(lines)-1])
+	}
+	return true, fmt.Errorf("redis import job %s failed", jobName)
+}
+
+// GetRedisImportProgress returns the number of keys of the external redis and the number of keys the redis cluster
+// masters hold, which approaches it while the keys are imported
+func GetRedisImportProgress(ctx context.Context, cr *redisv1beta1.Redis) (int64, int64, error) {
+	password := ""
+	if cr.Spec.Import.PasswordSecret != nil {
+		var err error
+		if password, err = readPasswordSecret(cr, cr.Spec.Import.PasswordSecret); err != nil {
+			return 0, 0, err
+		}
+	}
+	source := redis.NewClient(&redis.Options{
+		Addr:     getImportSourceAddress(cr),
+		Password: password,
+	}).WithContext(ctx)
+	defer source.Close()
+	sourceKeys, err := source.DBSize().Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not count the keys of the external redis: %v", err)
+	}
+	masters, err := getRedisMasterPods(ctx, cr)
+	if err != nil {
+		return 0, 0, err
+	}
+	var importedKeys int64
+	for _, pod := range masters {
+		client := configureRedisClient(ctx, cr, pod.Name)
+		keys, err := client.DBSize().Result()
+		client.Close()
+		if err != nil {
+			return 0, 0, err
+		}
+		importedKeys += keys
+	}
+	return sourceKeys, importedKeys, nil
+}
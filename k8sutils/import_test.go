@@ -0,0 +1,60 @@
+package k8sutils
+
+import (
+	"strings"
+	"testing"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+func TestGenerateImportJobDef(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	name, key := "legacy-redis", "password"
+	cr.Spec.Import = &redisv1beta1.Import{
+		Host:           "legacy-redis.default.svc",
+		PasswordSecret: &redisv1beta1.ExistingPasswordSecret{Name: &name, Key: &key},
+	}
+	job := generateImportJobDef(cr, "10.0.0.1:6379")
+	if job.Name != "redis-import" {
+		t.Errorf("expected the import job to be named redis-import, got %s", job.Name)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if container.Image != cr.Spec.GlobalConfig.Image {
+		t.Errorf("expected the import job to run the redis image, got %s", container.Image)
+	}
+	script := container.Command[len(container.Command)-1]
+	want := `exec redis-cli --cluster import 10.0.0.1:6379 --cluster-from legacy-redis.default.svc:6379 --cluster-copy --cluster-from-pass "$REDIS_IMPORT_PASSWORD"`
+	if script != want {
+		t.Errorf("unexpected import command %q", script)
+	}
+	found := false
+	for _, env := range container.Env {
+		if env.Name == importPasswordEnv && env.ValueFrom.SecretKeyRef.Name == name {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the password of the external redis to be read from its secret")
+	}
+	cr.Spec.Import.Replace = true
+	if script := generateImportJobDef(cr, "10.0.0.1:6379").Spec.Template.Spec.Containers[0].Command[2]; !strings.Contains(script, "--cluster-replace") {
+		t.Errorf("expected the import to replace the existing keys, got %q", script)
+	}
+}
+
+func TestNetworkPolicyAllowsTheImportJob(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.NetworkPolicy = &redisv1beta1.RedisNetworkPolicy{Enabled: true}
+	cr.Spec.Import = &redisv1beta1.Import{Host: "legacy-redis.default.svc"}
+	allowed := false
+	for _, rule := range generateNetworkPolicyDef(cr).Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.PodSelector != nil && peer.PodSelector.MatchLabels["app"] == "redis-admin" {
+				allowed = true
+			}
+		}
+	}
+	if !allowed {
+		t.Error("expected the import job to be allowed to reach redis without an admin image")
+	}
+}
@@ -2,10 +2,26 @@ package k8sutils
 
 import (
 	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// managedLabelsAnnotation records the label keys set by the operator, so that the ones dropped from the spec are
+	// removed without touching the labels set by others
+	managedLabelsAnnotation = "redis.opstreelabs.in/managed-labels"
+	// managedAnnotationsAnnotation records the annotation keys set by the operator
+	managedAnnotationsAnnotation = "redis.opstreelabs.in/managed-annotations"
+)
+
+// GetRedisName returns the base name of the resources generated for the redis setup
+func GetRedisName(cr *redisv1beta1.Redis) string {
+	return cr.Spec.GlobalConfig.ResourceNamePrefix + cr.ObjectMeta.Name
+}
+
 // GenerateMetaInformation generates the meta information
 func GenerateMetaInformation(resourceKind string, apiVersion string) metav1.TypeMeta {
 	return metav1.TypeMeta{
@@ -24,6 +40,80 @@ func GenerateObjectMetaInformation(name string, namespace string, labels map[str
 	}
 }
 
+// GenerateRedisObjectMetaInformation generates the object meta information with the extra labels and annotations of the redis setup
+func GenerateRedisObjectMetaInformation(cr *redisv1beta1.Redis, name string, labels map[string]string, annotations map[string]string) metav1.ObjectMeta {
+	return GenerateObjectMetaInformation(name, cr.Namespace, mergeStringMaps(cr.Spec.GlobalConfig.Labels, labels), mergeStringMaps(cr.Spec.GlobalConfig.Annotations, annotations))
+}
+
+// mergeStringMaps returns a new map with the entries of both maps, the ones of base taking precedence
+func mergeStringMaps(extra map[string]string, base map[string]string) map[string]string {
+	merged := make(map[string]string, len(extra)+len(base))
+	for key, value := range extra {
+		merged[key] = value
+	}
+	for key, value := range base {
+		merged[key] = value
+	}
+	return merged
+}
+
+// mergeObjectMeta adds the desired labels and annotations to the existing object meta and reports if it changed. The
+// keys set by the operator are recorded on the object, and the recorded keys which are no longer desired are removed.
+func mergeObjectMeta(existing *metav1.ObjectMeta, desired metav1.ObjectMeta) bool {
+	managedAnnotations := getManagedKeys(desired.Annotations, managedLabelsAnnotation, managedAnnotationsAnnotation)
+	changed := mergeManagedKeys(&existing.Labels, desired.Labels, existing.Annotations[managedLabelsAnnotation])
+	if mergeManagedKeys(&existing.Annotations, desired.Annotations, existing.Annotations[managedAnnotationsAnnotation]) {
+		changed = true
+	}
+	if mergeManagedKeys(&existing.Annotations, map[string]string{
+		managedLabelsAnnotation:      getManagedKeys(desired.Labels),
+		managedAnnotationsAnnotation: managedAnnotations,
+	}, "") {
+		changed = true
+	}
+	return changed
+}
+
+// mergeManagedKeys sets the desired entries in the existing map and removes the previously managed keys, a comma
+// separated list, which are not desired anymore
+func mergeManagedKeys(existing *map[string]string, desired map[string]string, previous string) bool {
+	changed := false
+	for key, value := range desired {
+		if current, ok := (*existing)[key]; !ok || current != value {
+			if *existing == nil {
+				*existing = map[string]string{}
+			}
+			(*existing)[key] = value
+			changed = true
+		}
+	}
+	for _, key := range strings.Split(previous, ",") {
+		if _, ok := (*existing)[key]; ok && key != "" {
+			if _, desired := desired[key]; !desired {
+				delete(*existing, key)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// getManagedKeys returns the sorted keys of the map as a comma separated list, leaving out the excluded keys
+func getManagedKeys(values map[string]string, excluded ...string) string {
+	skip := map[string]bool{}
+	for _, key := range excluded {
+		skip[key] = true
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !skip[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
 // AddOwnerRefToObject adds the owner references to object
 func AddOwnerRefToObject(obj metav1.Object, ownerRef metav1.OwnerReference) {
 	obj.SetOwnerReferences(append(obj.GetOwnerReferences(), ownerRef))
@@ -42,20 +132,20 @@ func AsOwner(cr *redisv1beta1.Redis) metav1.OwnerReference {
 }
 
 // GenerateStatefulSetsAnots generates and returns statefulsets annotations
-func GenerateStatefulSetsAnots() map[string]string {
+func GenerateStatefulSetsAnots(cr *redisv1beta1.Redis) map[string]string {
 	return map[string]string{
 		"redis.opstreelabs.in": "true",
 		"prometheus.io/scrape": "true",
-		"prometheus.io/port":   "9121",
+		"prometheus.io/port":   strconv.Itoa(getRedisExporterPort(cr)),
 	}
 }
 
 // GenerateServiceAnots generates and returns service annotations
-func GenerateServiceAnots() map[string]string {
+func GenerateServiceAnots(cr *redisv1beta1.Redis) map[string]string {
 	return map[string]string{
 		"redis.opstreelabs.in": "true",
 		"prometheus.io/scrape": "true",
-		"prometheus.io/port":   "9121",
+		"prometheus.io/port":   strconv.Itoa(getRedisExporterPort(cr)),
 	}
 }
 
@@ -70,3 +160,10 @@ func GenerateSecretAnots() map[string]string {
 func LabelSelectors(labels map[string]string) *metav1.LabelSelector {
 	return &metav1.LabelSelector{MatchLabels: labels}
 }
+
+// LabelSelectorsWithExpressions generates object for label selection matching both the labels and the expressions
+func LabelSelectorsWithExpressions(labels map[string]string, expressions []metav1.LabelSelectorRequirement) *metav1.LabelSelector {
+	selector := LabelSelectors(labels)
+	selector.MatchExpressions = append(selector.MatchExpressions, expressions...)
+	return selector
+}
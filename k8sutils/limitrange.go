@@ -0,0 +1,39 @@
+package k8sutils
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+// checkLimitRanges will warn about the containers of the statefulset whose resources fall outside the limit ranges of the namespace
+func checkLimitRanges(cr *redisv1beta1.Redis, statefulset *appsv1.StatefulSet) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	limitRanges, err := GenerateK8sClient().CoreV1().LimitRanges(cr.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		reqLogger.Info("Could not list limit ranges, skipping the resources check", "Reason", err.Error())
+		return
+	}
+	containers := append(append([]corev1.Container{}, statefulset.Spec.Template.Spec.InitContainers...), statefulset.Spec.Template.Spec.Containers...)
+	for _, limitRange := range limitRanges.Items {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for _, container := range containers {
+				for name, min := range item.Min {
+					if request, ok := container.Resources.Requests[name]; ok && request.Cmp(min) < 0 {
+						reqLogger.Info("Container resource request is below the minimum of the namespace limit range, pods will be rejected", "Container", container.Name, "Resource", name, "Request", request.String(), "LimitRange", limitRange.Name, "Min", min.String())
+					}
+				}
+				for name, max := range item.Max {
+					if limit, ok := container.Resources.Limits[name]; ok && limit.Cmp(max) > 0 {
+						reqLogger.Info("Container resource limit is above the maximum of the namespace limit range, pods will be rejected", "Container", container.Name, "Resource", name, "Limit", limit.String(), "LimitRange", limitRange.Name, "Max", max.String())
+					}
+				}
+			}
+		}
+	}
+}
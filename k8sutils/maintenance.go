@@ -0,0 +1,162 @@
+package k8sutils
+
+import (
+	"fmt"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maintenanceDays are the days of the week accepted in a maintenance window
+var maintenanceDays = map[string]time.Weekday{
+	"Sunday":    time.Sunday,
+	"Monday":    time.Monday,
+	"Tuesday":   time.Tuesday,
+	"Wednesday": time.Wednesday,
+	"Thursday":  time.Thursday,
+	"Friday":    time.Friday,
+	"Saturday":  time.Saturday,
+}
+
+// deferredOperations remembers the disruptive operations of each redis deferred until its maintenance window
+type deferredOperations struct {
+	mu         sync.Mutex
+	operations map[string]map[string]bool
+}
+
+var maintenanceDeferrals = &deferredOperations{operations: map[string]map[string]bool{}}
+
+// parseTimeOfDay returns the minutes since midnight of a HH:MM time of day
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time of day", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// parseMaintenanceWindow returns the start and end time of day of the maintenance window and its time zone
+func parseMaintenanceWindow(window *redisv1beta1.MaintenanceWindow) (time.Duration, time.Duration, *time.Location, error) {
+	start, err := parseTimeOfDay(window.Start)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("start is invalid: %v", err)
+	}
+	end, err := parseTimeOfDay(window.End)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("end is invalid: %v", err)
+	}
+	location, err := time.LoadLocation(window.TimeZone)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("timeZone is invalid: %v", err)
+	}
+	for _, day := range window.Days {
+		if _, ok := maintenanceDays[day]; !ok {
+			return 0, 0, nil, fmt.Errorf("day %q is not a day of the week", day)
+		}
+	}
+	return start, end, location, nil
+}
+
+// isMaintenanceDay reports whether the maintenance window starts on the day of the week
+func isMaintenanceDay(window *redisv1beta1.MaintenanceWindow, day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, name := range window.Days {
+		if maintenanceDays[name] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// getMaintenanceWindowBounds returns when the maintenance window starting on the day of the date opens and closes. A
+// window whose end is not after its start closes the next day.
+func getMaintenanceWindowBounds(date time.Time, start time.Duration, end time.Duration, location *time.Location) (time.Time, time.Time) {
+	year, month, day := date.Date()
+	opens := time.Date(year, month, day, int(start.Hours()), int(start.Minutes())%60, 0, 0, location)
+	if end <= start {
+		day++
+	}
+	closes := time.Date(year, month, day, int(end.Hours()), int(end.Minutes())%60, 0, 0, location)
+	return opens, closes
+}
+
+// IsMaintenanceWindowOpen reports whether the disruptive operations of the redis are allowed at the time, which is
+// always the case without a maintenance window
+func IsMaintenanceWindowOpen(cr *redisv1beta1.Redis, now time.Time) bool {
+	window := cr.Spec.MaintenanceWindow
+	if window == nil {
+		return true
+	}
+	start, end, location, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return true
+	}
+	now = now.In(location)
+	// the window of the day before may still be open past midnight
+	for _, date := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if !isMaintenanceDay(window, date.Weekday()) {
+			continue
+		}
+		opens, closes := getMaintenanceWindowBounds(date, start, end, location)
+		if !now.Before(opens) && now.Before(closes) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNextMaintenanceWindow returns when the next maintenance window of the redis opens after the time
+func GetNextMaintenanceWindow(cr *redisv1beta1.Redis, now time.Time) time.Time {
+	window := cr.Spec.MaintenanceWindow
+	if window == nil {
+		return now
+	}
+	start, end, location, err := parseMaintenanceWindow(window)
+	if err != nil {
+		return now
+	}
+	now = now.In(location)
+	for days := 0; days <= 7; days++ {
+		date := now.AddDate(0, 0, days)
+		if !isMaintenanceDay(window, date.Weekday()) {
+			continue
+		}
+		if opens, _ := getMaintenanceWindowBounds(date, start, end, location); opens.After(now) {
+			return opens
+		}
+	}
+	return now
+}
+
+// DeferDisruptiveOperation reports whether the disruptive operation has to wait for the maintenance window of the
+// redis, and remembers it so that the deferral shows in the status
+func DeferDisruptiveOperation(cr *redisv1beta1.Redis, operation string) bool {
+	if IsMaintenanceWindowOpen(cr, time.Now()) {
+		return false
+	}
+	maintenanceDeferrals.mu.Lock()
+	defer maintenanceDeferrals.mu.Unlock()
+	key := cr.Namespace + "/" + cr.Name
+	if maintenanceDeferrals.operations[key] == nil {
+		maintenanceDeferrals.operations[key] = map[string]bool{}
+	}
+	maintenanceDeferrals.operations[key][operation] = true
+	return true
+}
+
+// TakeDeferredOperations returns the disruptive operations of the redis deferred since the last call, sorted
+func TakeDeferredOperations(cr *redisv1beta1.Redis) []string {
+	maintenanceDeferrals.mu.Lock()
+	defer maintenanceDeferrals.mu.Unlock()
+	key := cr.Namespace + "/" + cr.Name
+	var operations []string
+	for operation := range maintenanceDeferrals.operations[key] {
+		operations = append(operations, operation)
+	}
+	delete(maintenanceDeferrals.operations, key)
+	sort.Strings(operations)
+	return operations
+}
@@ -0,0 +1,59 @@
+package k8sutils
+
+import (
+	"testing"
+	"time"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+func TestIsMaintenanceWindowOpen(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	if !IsMaintenanceWindowOpen(cr, time.Now()) {
+		t.Fatal("expected disruptive operations to be allowed without a maintenance window")
+	}
+	// Saturday 22:00 to Sunday 02:00 in Berlin, which is UTC+1 in January
+	cr.Spec.MaintenanceWindow = &redisv1beta1.MaintenanceWindow{Days: []string{"Saturday"}, Start: "22:00", End: "02:00", TimeZone: "Europe/Berlin"}
+	tests := map[string]bool{
+		"2022-01-08T20:59:00Z": false,
+		"2022-01-08T21:00:00Z": true,
+		"2022-01-09T00:30:00Z": true,
+		"2022-01-09T01:00:00Z": false,
+		"2022-01-15T23:00:00Z": true,
+		"2022-01-12T22:00:00Z": false,
+	}
+	for value, want := range tests {
+		now, _ := time.Parse(time.RFC3339, value)
+		if got := IsMaintenanceWindowOpen(cr, now); got != want {
+			t.Errorf("IsMaintenanceWindowOpen(%s) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestGetNextMaintenanceWindow(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.MaintenanceWindow = &redisv1beta1.MaintenanceWindow{Days: []string{"Tuesday", "Thursday"}, Start: "03:00", End: "05:00"}
+	now, _ := time.Parse(time.RFC3339, "2022-01-11T04:00:00Z")
+	if next := GetNextMaintenanceWindow(cr, now); !next.Equal(time.Date(2022, 1, 13, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the next maintenance window on Thursday, got %s", next)
+	}
+}
+
+func TestDeferDisruptiveOperation(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	if DeferDisruptiveOperation(cr, "rolling restart of redis-master") {
+		t.Fatal("expected the operation to run without a maintenance window")
+	}
+	// a window of a minute on a day other than today is closed
+	cr.Spec.MaintenanceWindow = &redisv1beta1.MaintenanceWindow{Days: []string{time.Now().UTC().AddDate(0, 0, 2).Weekday().String()}, Start: "00:00", End: "00:01"}
+	if !DeferDisruptiveOperation(cr, "rolling restart of redis-master") || !DeferDisruptiveOperation(cr, "rebalance of the redis replicas") {
+		t.Fatal("expected the operations to be deferred outside of the maintenance window")
+	}
+	operations := TakeDeferredOperations(cr)
+	if len(operations) != 2 || operations[0] != "rebalance of the redis replicas" {
+		t.Errorf("expected the two deferred operations, got %v", operations)
+	}
+	if operations := TakeDeferredOperations(cr); len(operations) != 0 {
+		t.Errorf("expected the deferred operations to be reset, got %v", operations)
+	}
+}
@@ -0,0 +1,126 @@
+package k8sutils
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+// getRedisPodsSelector returns the label selector matching all the redis pods of the redis setup
+func getRedisPodsSelector(cr *redisv1beta1.Redis) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "app",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{GetRedisName(cr) + "-master", GetRedisName(cr) + "-slave", GetRedisName(cr) + "-standalone"},
+		}},
+	}
+}
+
+// networkPolicyPorts returns the network policy ports of the TCP port numbers
+func networkPolicyPorts(ports ...int) []networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, port := range ports {
+		portNumber := intstr.FromInt(port)
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &portNumber})
+	}
+	return policyPorts
+}
+
+// generateNetworkPolicyDef generates the network policy of the redis pods. The configured peers reach the redis
+// port and the exporter port, the redis pods reach each other on the redis and cluster bus ports, and the proxy
+// pods and the admin jobs reach the redis port.
+func generateNetworkPolicyDef(cr *redisv1beta1.Redis) *networkingv1.NetworkPolicy {
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	clientPorts := []int{redisPort}
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.Enabled {
+		clientPorts = append(clientPorts, getRedisExporterPort(cr))
+	}
+	var ingress []networkingv1.NetworkPolicyIngressRule
+	// a rule without peers allows everyone, so the peers rule is left out when none are configured
+	if len(cr.Spec.NetworkPolicy.From) > 0 {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  cr.Spec.NetworkPolicy.From,
+			Ports: networkPolicyPorts(clientPorts...),
+		})
+	}
+	ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+		From:  []networkingv1.NetworkPolicyPeer{{PodSelector: getRedisPodsSelector(cr)}},
+		Ports: networkPolicyPorts(redisPort, redisClusterBusPort),
+	})
+	// the import job runs with the labels of the admin jobs as well
+	if getAdminJobImage(cr) != "" || cr.Spec.Import != nil {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: LabelSelectors(getAdminJobLabels(cr))}},
+			Ports: networkPolicyPorts(redisPort),
+		})
+	}
+	if cr.Spec.Mode == "cluster" && cr.Spec.Proxy != nil {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: LabelSelectors(getProxyLabels(cr))}},
+			Ports: networkPolicyPorts(redisPort),
+		})
+	}
+	networkPolicy := &networkingv1.NetworkPolicy{
+		TypeMeta:   GenerateMetaInformation("NetworkPolicy", "networking.k8s.io/v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr), labels, GenerateStatefulSetsAnots(cr)),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *getRedisPodsSelector(cr),
+			Ingress:     ingress,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	AddOwnerRefToObject(networkPolicy, AsOwner(cr))
+	return networkPolicy
+}
+
+// CreateRedisNetworkPolicy will create or update the network policy of the redis pods when it is enabled, and delete
+// it otherwise
+func CreateRedisNetworkPolicy(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if cr.Spec.NetworkPolicy == nil || !cr.Spec.NetworkPolicy.Enabled {
+		deleteRedisNetworkPolicy(cr)
+		return
+	}
+	networkPolicyDefinition := generateNetworkPolicyDef(cr)
+	existingNetworkPolicy, err := GenerateK8sClient().NetworkingV1().NetworkPolicies(cr.Namespace).Get(context.TODO(), networkPolicyDefinition.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating network policy for redis", "NetworkPolicy.Name", networkPolicyDefinition.Name)
+		_, err := GenerateK8sClient().NetworkingV1().NetworkPolicies(cr.Namespace).Create(context.TODO(), networkPolicyDefinition, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating network policy for redis")
+		}
+		return
+	}
+	metaChanged := mergeObjectMeta(&existingNetworkPolicy.ObjectMeta, networkPolicyDefinition.ObjectMeta)
+	if apiequality.Semantic.DeepEqual(existingNetworkPolicy.Spec, networkPolicyDefinition.Spec) && !metaChanged {
+		return
+	}
+	reqLogger.Info("Reconciling network policy for redis", "NetworkPolicy.Name", networkPolicyDefinition.Name)
+	existingNetworkPolicy.Spec = networkPolicyDefinition.Spec
+	_, err = GenerateK8sClient().NetworkingV1().NetworkPolicies(cr.Namespace).Update(context.TODO(), existingNetworkPolicy, metav1.UpdateOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in updating network policy for redis")
+	}
+}
+
+// deleteRedisNetworkPolicy will delete the network policy when it is owned by the redis setup
+func deleteRedisNetworkPolicy(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	existingNetworkPolicy, err := GenerateK8sClient().NetworkingV1().NetworkPolicies(cr.Namespace).Get(context.TODO(), GetRedisName(cr), metav1.GetOptions{})
+	if err != nil || !metav1.IsControlledBy(existingNetworkPolicy, cr) {
+		return
+	}
+	reqLogger.Info("Deleting network policy for redis", "NetworkPolicy.Name", existingNetworkPolicy.Name)
+	err = GenerateK8sClient().NetworkingV1().NetworkPolicies(cr.Namespace).Delete(context.TODO(), existingNetworkPolicy.Name, metav1.DeleteOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in deleting network policy for redis")
+	}
+}
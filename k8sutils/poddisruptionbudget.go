@@ -0,0 +1,286 @@
+package k8sutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+	"sync"
+)
+
+// podDisruptionBudgetCache remembers the hash of the pod disruption budgets reconciled for each redis generation, so
+// that the pod disruption budgets whose desired spec didn't change are not read from the API server again. It is kept
+// in memory, so it starts empty after a restart of the operator.
+type podDisruptionBudgetCache struct {
+	mu      sync.Mutex
+	enabled bool
+	entries map[string]podDisruptionBudgetCacheEntry
+}
+
+// podDisruptionBudgetCacheEntry is the last pod disruption budget reconciled for a redis generation, the UID tells a
+// recreated redis resource of the same name apart
+type podDisruptionBudgetCacheEntry struct {
+	redis      string
+	uid        types.UID
+	generation int64
+	hash       string
+}
+
+var pdbCache = &podDisruptionBudgetCache{entries: map[string]podDisruptionBudgetCacheEntry{}}
+
+// SetPodDisruptionBudgetCache enables skipping the pod disruption budgets which are unchanged since their last
+// reconcile. The controller forgets a pod disruption budget changed or deleted directly, so that its next reconcile
+// reverts the change.
+func SetPodDisruptionBudgetCache(enabled bool) {
+	pdbCache.mu.Lock()
+	defer pdbCache.mu.Unlock()
+	pdbCache.enabled = enabled
+	pdbCache.entries = map[string]podDisruptionBudgetCacheEntry{}
+}
+
+// getPodDisruptionBudgetHash returns the hash of the metadata and spec of the desired pod disruption budget
+func getPodDisruptionBudgetHash(pdb *policyv1beta1.PodDisruptionBudget) string {
+	data, err := json.Marshal(struct {
+		Labels          map[string]string
+		Annotations     map[string]string
+		OwnerReferences []metav1.OwnerReference
+		Spec            policyv1beta1.PodDisruptionBudgetSpec
+	}{pdb.Labels, pdb.Annotations, pdb.OwnerReferences, pdb.Spec})
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// isUnchanged reports whether the desired pod disruption budget was already reconciled for the redis generation
+func (c *podDisruptionBudgetCache) isUnchanged(cr *redisv1beta1.Redis, desired *policyv1beta1.PodDisruptionBudget) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return false
+	}
+	entry, ok := c.entries[cr.Namespace+"/"+desired.Name]
+	return ok && entry.uid == cr.UID && entry.generation == cr.Generation && entry.hash != "" && entry.hash == getPodDisruptionBudgetHash(desired)
+}
+
+// store remembers the pod disruption budget reconciled for the redis generation
+func (c *podDisruptionBudgetCache) store(cr *redisv1beta1.Redis, desired *policyv1beta1.PodDisruptionBudget) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	c.entries[cr.Namespace+"/"+desired.Name] = podDisruptionBudgetCacheEntry{
+		redis:      cr.Name,
+		uid:        cr.UID,
+		generation: cr.Generation,
+		hash:       getPodDisruptionBudgetHash(desired),
+	}
+}
+
+// forget drops the pod disruption budget from the cache, so that its next reconcile reads it again
+func (c *podDisruptionBudgetCache) forget(namespace string, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, namespace+"/"+name)
+}
+
+// ForgetPodDisruptionBudget will drop the pod disruption budget from the reconcile cache, like once it is changed or
+// deleted directly, so that its next reconcile reads it again
+func ForgetPodDisruptionBudget(namespace string, name string) {
+	pdbCache.forget(namespace, name)
+}
+
+// ForgetRedisPodDisruptionBudgets will drop the pod disruption budgets of a redis resource from the reconcile cache,
+// like once it is deleted
+func ForgetRedisPodDisruptionBudgets(namespace string, name string) {
+	pdbCache.mu.Lock()
+	defer pdbCache.mu.Unlock()
+	for key, entry := range pdbCache.entries {
+		if entry.redis == name && strings.HasPrefix(key, namespace+"/") {
+			delete(pdbCache.entries, key)
+		}
+	}
+}
+
+// getPodDisruptionBudgetQuorum returns the pods of a role kept available by its pod disruption budget, a majority
+// by default
+func getPodDisruptionBudgetQuorum(cr *redisv1beta1.Redis, replicas int32) int32 {
+	switch cr.Spec.PodDisruptionBudget.QuorumFormula {
+	case "all-but-one":
+		if replicas < 1 {
+			return 0
+		}
+		return replicas - 1
+	case "custom":
+		return *cr.Spec.PodDisruptionBudget.MinAvailable
+	default:
+		return replicas/2 + 1
+	}
+}
+
+// generatePodDisruptionBudgetDef generates the pod disruption budget definition keeping a quorum of the role available
+func generatePodDisruptionBudgetDef(cr *redisv1beta1.Redis, role string, labels map[string]string, replicas int32) *policyv1beta1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(int(getPodDisruptionBudgetQuorum(cr, replicas)))
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		TypeMeta:   GenerateMetaInformation("PodDisruptionBudget", "policy/v1beta1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-"+role, labels, GenerateStatefulSetsAnots(cr)),
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     LabelSelectorsWithExpressions(labels, cr.Spec.PodDisruptionBudget.MatchExpressions),
+		},
+	}
+	AddOwnerRefToObject(pdb, AsOwner(cr))
+	return pdb
+}
+
+// CreateRedisPodDisruptionBudget will create or update the pod disruption budget of the redis role.
+// The pod disruption budget is only created once the statefulset of the role exists, so that it never
+// selects zero pods on a fresh cluster.
+func CreateRedisPodDisruptionBudget(cr *redisv1beta1.Redis, role string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if cr.Spec.PodDisruptionBudget == nil || !cr.Spec.PodDisruptionBudget.Enabled {
+		return
+	}
+	if isClusterPodDisruptionBudget(cr) {
+		deleteRedisPodDisruptionBudget(cr, GetRedisName(cr)+"-"+role)
+		return
+	}
+	labels := map[string]string{
+		"app":  GetRedisName(cr) + "-" + role,
+		"role": role,
+	}
+	replicas := GetMasterReplicas(cr)
+	if role == "slave" {
+		replicas = GetSlaveReplicas(cr)
+	}
+	pdbDefinition := generatePodDisruptionBudgetDef(cr, role, labels, replicas)
+	if pdbCache.isUnchanged(cr, pdbDefinition) {
+		return
+	}
+	_, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Statefulset for redis is not created yet, skipping pod disruption budget", "Setup.Type", role)
+		return
+	}
+	reconcilePodDisruptionBudget(cr, pdbDefinition)
+}
+
+// isClusterPodDisruptionBudget reports whether a single pod disruption budget covers all pods of the redis cluster
+func isClusterPodDisruptionBudget(cr *redisv1beta1.Redis) bool {
+	return cr.Spec.PodDisruptionBudget != nil && cr.Spec.PodDisruptionBudget.Enabled && cr.Spec.PodDisruptionBudget.Scope == "cluster"
+}
+
+// generateClusterPodDisruptionBudgetDef generates the pod disruption budget capping the disrupted pods of the whole redis cluster
+func generateClusterPodDisruptionBudgetDef(cr *redisv1beta1.Redis) *policyv1beta1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	if cr.Spec.PodDisruptionBudget.MaxUnavailable != nil {
+		maxUnavailable = intstr.FromInt(int(*cr.Spec.PodDisruptionBudget.MaxUnavailable))
+	}
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	expressions := []metav1.LabelSelectorRequirement{{
+		Key:      "app",
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   []string{GetRedisName(cr) + "-master", GetRedisName(cr) + "-slave"},
+	}}
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		TypeMeta:   GenerateMetaInformation("PodDisruptionBudget", "policy/v1beta1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr), labels, GenerateStatefulSetsAnots(cr)),
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector:       LabelSelectorsWithExpressions(nil, append(expressions, cr.Spec.PodDisruptionBudget.MatchExpressions...)),
+		},
+	}
+	AddOwnerRefToObject(pdb, AsOwner(cr))
+	return pdb
+}
+
+// CreateRedisClusterPodDisruptionBudget will create or update the pod disruption budget across all pods of the redis
+// cluster when its scope is cluster, and delete it otherwise. Kubernetes refuses to evict a pod selected by several
+// pod disruption budgets, so it replaces the pod disruption budgets of the roles.
+func CreateRedisClusterPodDisruptionBudget(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if !isClusterPodDisruptionBudget(cr) {
+		deleteRedisPodDisruptionBudget(cr, GetRedisName(cr))
+		return
+	}
+	pdbDefinition := generateClusterPodDisruptionBudgetDef(cr)
+	if pdbCache.isUnchanged(cr, pdbDefinition) {
+		return
+	}
+	for _, role := range []string{"master", "slave"} {
+		_, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+		if err != nil {
+			reqLogger.Info("Statefulset for redis is not created yet, skipping pod disruption budget", "Setup.Type", role)
+			return
+		}
+	}
+	reconcilePodDisruptionBudget(cr, pdbDefinition)
+}
+
+// reconcilePodDisruptionBudget will create the pod disruption budget or update it when it differs from the desired one,
+// and remember it in the cache once it is up to date
+func reconcilePodDisruptionBudget(cr *redisv1beta1.Redis, pdbDefinition *policyv1beta1.PodDisruptionBudget) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	existingPDB, err := GenerateK8sClient().PolicyV1beta1().PodDisruptionBudgets(cr.Namespace).Get(context.TODO(), pdbDefinition.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating pod disruption budget for redis", "PodDisruptionBudget.Name", pdbDefinition.Name)
+		_, err := GenerateK8sClient().PolicyV1beta1().PodDisruptionBudgets(cr.Namespace).Create(context.TODO(), pdbDefinition, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating pod disruption budget for redis")
+			return
+		}
+		pdbCache.store(cr, pdbDefinition)
+		return
+	}
+	if patchPodDisruptionBudget(cr, existingPDB, pdbDefinition) {
+		pdbCache.store(cr, pdbDefinition)
+	}
+}
+
+// deleteRedisPodDisruptionBudget will delete the pod disruption budget when it is owned by the redis setup
+func deleteRedisPodDisruptionBudget(cr *redisv1beta1.Redis, name string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	pdbCache.forget(cr.Namespace, name)
+	existingPDB, err := GenerateK8sClient().PolicyV1beta1().PodDisruptionBudgets(cr.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil || !metav1.IsControlledBy(existingPDB, cr) {
+		return
+	}
+	reqLogger.Info("Deleting pod disruption budget for redis", "PodDisruptionBudget.Name", name)
+	err = GenerateK8sClient().PolicyV1beta1().PodDisruptionBudgets(cr.Namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in deleting pod disruption budget for redis")
+	}
+}
+
+// patchPodDisruptionBudget will update the pod disruption budget when the desired spec has changed, the selector can
+// be updated since kubernetes 1.15. It reports whether the pod disruption budget is up to date.
+func patchPodDisruptionBudget(cr *redisv1beta1.Redis, existing *policyv1beta1.PodDisruptionBudget, desired *policyv1beta1.PodDisruptionBudget) bool {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	metaChanged := mergeObjectMeta(&existing.ObjectMeta, desired.ObjectMeta)
+	if apiequality.Semantic.DeepEqual(existing.Spec.MinAvailable, desired.Spec.MinAvailable) &&
+		apiequality.Semantic.DeepEqual(existing.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) &&
+		apiequality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) && !metaChanged {
+		return true
+	}
+	reqLogger.Info("Reconciling pod disruption budget for redis", "PodDisruptionBudget.Name", desired.Name)
+	existing.Spec.MinAvailable = desired.Spec.MinAvailable
+	existing.Spec.MaxUnavailable = desired.Spec.MaxUnavailable
+	existing.Spec.Selector = desired.Spec.Selector
+	_, err := GenerateK8sClient().PolicyV1beta1().PodDisruptionBudgets(cr.Namespace).Update(context.TODO(), existing, metav1.UpdateOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in updating pod disruption budget for redis")
+		return false
+	}
+	return true
+}
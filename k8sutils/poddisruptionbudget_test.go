@@ -0,0 +1,242 @@
+package k8sutils
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+// useFakeK8sClient replaces the kubernetes client with a fake one for the duration of the test
+func useFakeK8sClient(t *testing.T) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	generateK8sClient := GenerateK8sClient
+	GenerateK8sClient = func() kubernetes.Interface { return client }
+	t.Cleanup(func() { GenerateK8sClient = generateK8sClient })
+	return client
+}
+
+func newTestRedisCluster(size int32) *redisv1beta1.Redis {
+	return &redisv1beta1.Redis{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "default"},
+		Spec: redisv1beta1.RedisSpec{
+			Mode:                "cluster",
+			Size:                &size,
+			GlobalConfig:        redisv1beta1.GlobalConfig{Image: "quay.io/opstree/redis:v6.2"},
+			RedisExporter:       &redisv1beta1.RedisExporter{},
+			PodDisruptionBudget: &redisv1beta1.RedisPodDisruptionBudget{Enabled: true},
+		},
+	}
+}
+
+func TestCreateRedisPodDisruptionBudgetOnFreshCluster(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdbs, err := client.PolicyV1beta1().PodDisruptionBudgets("default").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pdbs.Items) != 0 {
+		t.Fatalf("expected no pod disruption budget before the statefulset exists, got %d", len(pdbs.Items))
+	}
+
+	CreateRedisMaster(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected pod disruption budget after the statefulset is created: %v", err)
+	}
+	if pdb.Spec.MinAvailable.IntValue() != 2 {
+		t.Fatalf("expected minAvailable 2, got %s", pdb.Spec.MinAvailable.String())
+	}
+}
+
+func TestCreateRedisPodDisruptionBudgetOnScale(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+
+	size := int32(5)
+	cr.Spec.Size = &size
+	CreateRedisMaster(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MinAvailable.IntValue() != 3 {
+		t.Fatalf("expected minAvailable 3 after scaling to 5, got %s", pdb.Spec.MinAvailable.String())
+	}
+}
+
+func TestCreateRedisPodDisruptionBudgetExtraLabels(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+
+	pdb, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdb.Labels["owner"] = "someone-else"
+	if _, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Update(context.TODO(), pdb, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cr.Spec.GlobalConfig.Labels = map[string]string{"team": "payments"}
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err = client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Labels["team"] != "payments" || pdb.Labels["owner"] != "someone-else" {
+		t.Fatalf("expected extra labels to be merged with the existing ones, got %v", pdb.Labels)
+	}
+	if pdb.Spec.Selector.MatchLabels["team"] != "" {
+		t.Fatalf("expected extra labels to stay out of the selector, got %v", pdb.Spec.Selector.MatchLabels)
+	}
+
+	cr.Spec.GlobalConfig.Labels = nil
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err = client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pdb.Labels["team"]; ok || pdb.Labels["owner"] != "someone-else" {
+		t.Fatalf("expected the dropped extra label to be pruned and the foreign one kept, got %v", pdb.Labels)
+	}
+}
+
+func TestCreateRedisClusterPodDisruptionBudgetReplacesRolePodDisruptionBudgets(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	CreateRedisSlave(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+	CreateRedisPodDisruptionBudget(cr, "slave")
+
+	maxUnavailable := int32(2)
+	cr.Spec.PodDisruptionBudget.Scope = "cluster"
+	cr.Spec.PodDisruptionBudget.MaxUnavailable = &maxUnavailable
+	CreateRedisPodDisruptionBudget(cr, "master")
+	CreateRedisPodDisruptionBudget(cr, "slave")
+	CreateRedisClusterPodDisruptionBudget(cr)
+	pdbs, err := client.PolicyV1beta1().PodDisruptionBudgets("default").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pdbs.Items) != 1 || pdbs.Items[0].Name != "redis" {
+		t.Fatalf("expected only the cluster pod disruption budget, got %d", len(pdbs.Items))
+	}
+	if pdbs.Items[0].Spec.MaxUnavailable.IntValue() != 2 {
+		t.Fatalf("expected maxUnavailable 2, got %s", pdbs.Items[0].Spec.MaxUnavailable.String())
+	}
+}
+
+func TestCreateRedisPodDisruptionBudgetQuorumFormula(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(5)
+	cr.Spec.PodDisruptionBudget.QuorumFormula = "all-but-one"
+	CreateRedisMaster(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MinAvailable.IntValue() != 4 {
+		t.Fatalf("expected minAvailable 4 with all-but-one, got %s", pdb.Spec.MinAvailable.String())
+	}
+}
+
+func TestPodDisruptionBudgetSelectorMatchExpressions(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	CreateRedisSlave(cr)
+	CreateRedisPodDisruptionBudget(cr, "master")
+
+	expression := metav1.LabelSelectorRequirement{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"cache"}}
+	cr.Spec.PodDisruptionBudget.MatchExpressions = []metav1.LabelSelectorRequirement{expression}
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.Selector.MatchLabels["role"] != "master" || len(pdb.Spec.Selector.MatchExpressions) != 1 || pdb.Spec.Selector.MatchExpressions[0].Key != "tier" {
+		t.Fatalf("expected the role labels and the expressions in the updated selector, got %v", pdb.Spec.Selector)
+	}
+
+	selector := generateClusterPodDisruptionBudgetDef(cr).Spec.Selector
+	if len(selector.MatchLabels) != 0 || len(selector.MatchExpressions) != 2 {
+		t.Fatalf("expected the app and the configured expressions in the cluster selector, got %v", selector)
+	}
+	if app := selector.MatchExpressions[0]; app.Key != "app" || len(app.Values) != 2 || selector.MatchExpressions[1].Key != "tier" {
+		t.Fatalf("expected the app expression before the configured ones, got %v", selector.MatchExpressions)
+	}
+}
+
+func TestPodDisruptionBudgetCacheSkipsUnchangedGeneration(t *testing.T) {
+	client := useFakeK8sClient(t)
+	SetPodDisruptionBudgetCache(true)
+	defer SetPodDisruptionBudgetCache(false)
+	cr := newTestRedisCluster(3)
+	cr.Generation = 1
+	CreateRedisMaster(cr)
+	pdbs := client.PolicyV1beta1().PodDisruptionBudgets("default")
+
+	CreateRedisPodDisruptionBudget(cr, "master")
+	pdb, err := pdbs.Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cached pod disruption budget isn't read again, so a change the controller didn't forget is kept
+	edited := intstr.FromInt(0)
+	pdb.Spec.MinAvailable = &edited
+	if _, err := pdbs.Update(context.TODO(), pdb, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	CreateRedisPodDisruptionBudget(cr, "master")
+	if pdb, _ := pdbs.Get(context.TODO(), "redis-master", metav1.GetOptions{}); pdb.Spec.MinAvailable.IntValue() != 0 {
+		t.Fatal("expected the unchanged pod disruption budget to be skipped")
+	}
+
+	cr.Generation = 2
+	CreateRedisPodDisruptionBudget(cr, "master")
+	if pdb, _ := pdbs.Get(context.TODO(), "redis-master", metav1.GetOptions{}); pdb.Spec.MinAvailable.IntValue() == 0 {
+		t.Fatal("expected the pod disruption budget to be reconciled for a new generation")
+	}
+}
+
+func TestPodDisruptionBudgetCacheForgetsDeletedPodDisruptionBudget(t *testing.T) {
+	client := useFakeK8sClient(t)
+	SetPodDisruptionBudgetCache(true)
+	defer SetPodDisruptionBudgetCache(false)
+	cr := newTestRedisCluster(3)
+	cr.Generation = 1
+	CreateRedisMaster(cr)
+	pdbs := client.PolicyV1beta1().PodDisruptionBudgets("default")
+
+	CreateRedisPodDisruptionBudget(cr, "master")
+	if err := pdbs.Delete(context.TODO(), "redis-master", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	ForgetPodDisruptionBudget("default", "redis-master")
+	CreateRedisPodDisruptionBudget(cr, "master")
+	if _, err := pdbs.Get(context.TODO(), "redis-master", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the forgotten pod disruption budget to be recreated: %v", err)
+	}
+
+	ForgetRedisPodDisruptionBudgets("default", "redis")
+	if len(pdbCache.entries) != 0 {
+		t.Fatalf("expected the cache entries of the deleted redis to be dropped, got %v", pdbCache.entries)
+	}
+}
@@ -0,0 +1,180 @@
+package k8sutils
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultProxyPort = 7777
+	proxyName        = "redis-proxy"
+)
+
+var defaultProxyResources = corev1.ResourceRequirements{
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("256Mi"),
+	},
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("64Mi"),
+	},
+}
+
+// getProxyPort returns the port the proxy listens on
+func getProxyPort(cr *redisv1beta1.Redis) int32 {
+	if cr.Spec.Proxy.Port != nil {
+		return *cr.Spec.Proxy.Port
+	}
+	return defaultProxyPort
+}
+
+// getProxyLabels returns the labels selecting the proxy pods
+func getProxyLabels(cr *redisv1beta1.Redis) map[string]string {
+	return map[string]string{
+		"app":  GetRedisName(cr) + "-proxy",
+		"role": "proxy",
+	}
+}
+
+// getProxyEntryPoints returns the addresses of the current redis cluster masters, sorted so that the proxy
+// deployment only changes along with the topology
+func getProxyEntryPoints(ctx context.Context, cr *redisv1beta1.Redis) []string {
+	var entryPoints []string
+	for _, node := range parseRedisClusterNodes(checkRedisCluster(ctx, cr)) {
+		if strings.Contains(node.Flags, "master") && !strings.Contains(node.Flags, "fail") && len(node.Slots) > 0 {
+			entryPoints = append(entryPoints, node.IP+":"+strconv.Itoa(redisPort))
+		}
+	}
+	sort.Strings(entryPoints)
+	return entryPoints
+}
+
+// generateProxyContainerDef generates the proxy container, the entry points are passed as the last arguments
+// like redis-cluster-proxy expects them
+func generateProxyContainerDef(cr *redisv1beta1.Redis, entryPoints []string) corev1.Container {
+	port := getProxyPort(cr)
+	args := []string{"--port", strconv.Itoa(int(port))}
+	container := corev1.Container{
+		Name:            proxyName,
+		Image:           cr.Spec.Proxy.Image,
+		ImagePullPolicy: cr.Spec.Proxy.ImagePullPolicy,
+		Resources:       generateResourceRequirements(cr.Spec.Proxy.Resources, defaultProxyResources),
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          proxyName,
+				ContainerPort: port,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		ReadinessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(int(port)),
+				},
+			},
+		},
+	}
+	if isRedisPasswordSet(cr) {
+		container.Env = []corev1.EnvVar{
+			{
+				Name: "REDIS_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: getRedisPasswordSecretKeySelector(cr),
+				},
+			},
+		}
+		args = append(args, "--auth", "$(REDIS_PASSWORD)")
+	}
+	args = append(args, cr.Spec.Proxy.Args...)
+	container.Args = append(args, entryPoints...)
+	return container
+}
+
+// generateProxyDeploymentDef generates the deployment of the proxy in front of the redis cluster
+func generateProxyDeploymentDef(cr *redisv1beta1.Redis, entryPoints []string) *appsv1.Deployment {
+	labels := getProxyLabels(cr)
+	replicas := int32(1)
+	if cr.Spec.Proxy.Replicas != nil {
+		replicas = *cr.Spec.Proxy.Replicas
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   GenerateMetaInformation("Deployment", "apps/v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-proxy", labels, GenerateStatefulSetsAnots(cr)),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: LabelSelectors(labels),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: GenerateRedisObjectMetaInformation(cr, "", labels, GenerateStatefulSetsAnots(cr)),
+				Spec: corev1.PodSpec{
+					Containers:        []corev1.Container{generateProxyContainerDef(cr, entryPoints)},
+					NodeSelector:      cr.Spec.NodeSelector,
+					SecurityContext:   cr.Spec.SecurityContext,
+					PriorityClassName: cr.Spec.PriorityClassName,
+					Affinity:          cr.Spec.Affinity,
+				},
+			},
+		},
+	}
+	if cr.Spec.Tolerations != nil {
+		deployment.Spec.Template.Spec.Tolerations = *cr.Spec.Tolerations
+	}
+	AddOwnerRefToObject(deployment, AsOwner(cr))
+	return deployment
+}
+
+// CreateRedisProxy will create or update the proxy deployment, restarting the proxy when the masters of the redis cluster change
+func CreateRedisProxy(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	entryPoints := getProxyEntryPoints(ctx, cr)
+	if len(entryPoints) == 0 {
+		reqLogger.Info("Redis cluster has no masters serving slots yet, skipping proxy")
+		return
+	}
+	deploymentDefinition := generateProxyDeploymentDef(cr, entryPoints)
+	existingDeployment, err := GenerateK8sClient().AppsV1().Deployments(cr.Namespace).Get(context.TODO(), deploymentDefinition.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating proxy deployment for redis", "Deployment.Name", deploymentDefinition.Name)
+		_, err := GenerateK8sClient().AppsV1().Deployments(cr.Namespace).Create(context.TODO(), deploymentDefinition, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating proxy deployment for redis")
+		}
+		return
+	}
+	metaChanged := mergeObjectMeta(&existingDeployment.ObjectMeta, deploymentDefinition.ObjectMeta)
+	// arguments are compared on their own, since a derivative comparison misses removed entry points
+	if metaChanged || !apiequality.Semantic.DeepDerivative(deploymentDefinition.Spec, existingDeployment.Spec) ||
+		!apiequality.Semantic.DeepEqual(deploymentDefinition.Spec.Template.Spec.Containers[0].Args, existingDeployment.Spec.Template.Spec.Containers[0].Args) {
+		reqLogger.Info("Reconciling proxy deployment for redis", "Deployment.Name", deploymentDefinition.Name, "EntryPoints", entryPoints)
+		existingDeployment.Spec.Replicas = deploymentDefinition.Spec.Replicas
+		existingDeployment.Spec.Template = deploymentDefinition.Spec.Template
+		_, err := GenerateK8sClient().AppsV1().Deployments(cr.Namespace).Update(context.TODO(), existingDeployment, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating proxy deployment for redis")
+		}
+	}
+}
+
+// CreateRedisProxyService will create or update the service exposing the proxy
+func CreateRedisProxyService(cr *redisv1beta1.Redis) {
+	port := getProxyPort(cr)
+	serviceDefinition := GenerateServiceDef(cr, getProxyLabels(cr), port, "proxy", GetRedisName(cr)+"-proxy", cr.Spec.Proxy.Service.Type)
+	// the proxy pods do not run the redis exporter
+	serviceDefinition.Spec.Ports = serviceDefinition.Spec.Ports[:1]
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-proxy", metav1.GetOptions{})
+	service := ServiceInterface{
+		ExistingService:      serviceBody,
+		NewServiceDefinition: serviceDefinition,
+		ServiceType:          "proxy",
+	}
+	CompareAndCreateService(cr, service, err)
+}
@@ -0,0 +1,222 @@
+package k8sutils
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+)
+
+const (
+	// RedisQuarantineLabel marks the redis pods removed from the cluster for investigation
+	RedisQuarantineLabel = "redis.opstreelabs.in/quarantined"
+	// RedisServingLabel marks the redis cluster pods selected by the master and slave services while pods are
+	// quarantined, so that the quarantined pods don't receive client connections
+	RedisServingLabel = "redis.opstreelabs.in/serving"
+)
+
+// isPodQuarantined checks if the redis pod is listed in the quarantined pods
+func isPodQuarantined(cr *redisv1beta1.Redis, podName string) bool {
+	for _, name := range cr.Spec.QuarantinePods {
+		if name == podName {
+			return true
+		}
+	}
+	return false
+}
+
+// setQuarantineLabel will add or remove the quarantine label of the redis pod
+func setQuarantineLabel(cr *redisv1beta1.Redis, podName string, quarantined bool) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	pod, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Could not get pod info", "Pod.Name", podName)
+		return
+	}
+	if _, ok := pod.Labels[RedisQuarantineLabel]; ok == quarantined {
+		return
+	}
+	if quarantined {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[RedisQuarantineLabel] = "true"
+	} else {
+		delete(pod.Labels, RedisQuarantineLabel)
+	}
+	_, err = GenerateK8sClient().CoreV1().Pods(cr.Namespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in updating quarantine label of redis pod", "Pod.Name", podName)
+	}
+}
+
+// getClientServiceSelector returns the selector of the master or slave service. While pods are quarantined it also
+// selects the serving label, which the quarantined pods don't have.
+func getClientServiceSelector(cr *redisv1beta1.Redis, labels map[string]string) map[string]string {
+	selector := mergeStringMaps(nil, labels)
+	if cr.Spec.Mode == "cluster" && len(cr.Spec.QuarantinePods) > 0 {
+		selector[RedisServingLabel] = "true"
+	}
+	return selector
+}
+
+// LabelServingRedisPods will set the serving label of the redis pods of the role, true unless the pod is quarantined,
+// and remove it once no pod is quarantined. It runs before the services select the label, so that the serving pods
+// stay selected.
+func LabelServingRedisPods(cr *redisv1beta1.Redis, role string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	pods, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=" + GetRedisName(cr) + "-" + role,
+	})
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return
+	}
+	for _, pod := range pods.Items {
+		// a null label value removes the label with a merge patch
+		var serving *string
+		if len(cr.Spec.QuarantinePods) > 0 {
+			value := "true"
+			if isPodQuarantined(cr, pod.Name) {
+				value = "false"
+			}
+			serving = &value
+		}
+		if current, ok := pod.Labels[RedisServingLabel]; ok == (serving != nil) && (serving == nil || current == *serving) {
+			continue
+		}
+		patch, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": map[string]*string{RedisServingLabel: serving}},
+		})
+		if _, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			reqLogger.Error(err, "Failed in updating serving label of redis pod", "Pod.Name", pod.Name)
+		}
+	}
+}
+
+// runClusterCommand will run a CLUSTER subcommand on the redis pod
+func runClusterCommand(ctx context.Context, cr *redisv1beta1.Redis, podName string, args ...interface{}) error {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	return client.Process(redis.NewStatusCmd(append([]interface{}{"cluster"}, args...)...))
+}
+
+// QuarantineRedisPods will remove the quarantined pods from the redis cluster without deleting them. A quarantined
+// master is first failed over to one of its replicas, then the node is forgotten by the other nodes, which is
+// repeated on every reconcile so that it isn't gossiped back. Pods which are no longer quarantined are reset and
+// added back as replicas. It returns false while a failover of a quarantined master is in progress.
+func QuarantineRedisPods(ctx context.Context, cr *redisv1beta1.Redis) bool {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return true
+	}
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	done := true
+	for _, podName := range cr.Spec.QuarantinePods {
+		setQuarantineLabel(cr, podName, true)
+		podIP := getRedisServerIP(RedisDetails{PodName: podName, Namespace: cr.Namespace})
+		var quarantined *redisClusterNode
+		for i := range nodes {
+			if nodes[i].IP == podIP {
+				quarantined = &nodes[i]
+			}
+		}
+		if quarantined == nil {
+			continue
+		}
+		if strings.Contains(quarantined.Flags, "master") && len(quarantined.Slots) > 0 {
+			done = false
+			replica := ""
+			for _, node := range nodes {
+				if pod, ok := podsByIP[node.IP]; ok && node.MasterID == quarantined.ID && !strings.Contains(node.Flags, "fail") && !isPodQuarantined(cr, pod.Name) {
+					replica = pod.Name
+					break
+				}
+			}
+			if replica == "" {
+				reqLogger.Info("Quarantined redis master has no healthy replica to fail over to, keeping it in the cluster", "Pod.Name", podName)
+				continue
+			}
+			reqLogger.Info("Failing over quarantined redis master", "Pod.Name", podName, "Replica", replica)
+			if err := runClusterCommand(ctx, cr, replica, "failover"); err != nil {
+				reqLogger.Error(err, "Redis failover of quarantined master failed", "Pod.Name", podName)
+			}
+			continue
+		}
+		for _, node := range nodes {
+			pod, ok := podsByIP[node.IP]
+			if !ok || node.ID == quarantined.ID || isPodQuarantined(cr, pod.Name) {
+				continue
+			}
+			if err := runClusterCommand(ctx, cr, pod.Name, "forget", quarantined.ID); err != nil {
+				reqLogger.Error(err, "Redis node failed to forget quarantined node", "Pod.Name", pod.Name, "Quarantined", podName)
+			}
+		}
+		reqLogger.Info("Quarantined redis pod is removed from the cluster", "Pod.Name", podName)
+	}
+	releaseQuarantinedPods(ctx, cr, nodes)
+	return done
+}
+
+// releaseQuarantinedPods will reset the pods which are no longer quarantined and add them back as replicas of the
+// master with the fewest replicas
+func releaseQuarantinedPods(ctx context.Context, cr *redisv1beta1.Redis, nodes []redisClusterNode) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	pods, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: RedisQuarantineLabel,
+	})
+	if err != nil {
+		reqLogger.Error(err, "Could not list quarantined redis pods")
+		return
+	}
+	for _, pod := range pods.Items {
+		if !strings.HasPrefix(pod.Name, GetRedisName(cr)+"-") || isPodQuarantined(cr, pod.Name) {
+			continue
+		}
+		master, ok := getLeastReplicatedMaster(nodes)
+		if !ok {
+			continue
+		}
+		reqLogger.Info("Releasing quarantined redis pod back into the cluster", "Pod.Name", pod.Name)
+		if err := runClusterCommand(ctx, cr, pod.Name, "reset"); err != nil {
+			reqLogger.Error(err, "Redis reset of released pod failed", "Pod.Name", pod.Name)
+			continue
+		}
+		cmd := createRedisReplicationCommand(cr, master.ID, pod.Status.PodIP, master.IP)
+		executeCommand(ctx, cr, cmd, GetRedisName(cr)+"-master-0")
+		setQuarantineLabel(cr, pod.Name, false)
+	}
+}
+
+// countMasterReplicas returns the number of replicas of every redis cluster master, by master node ID
+func countMasterReplicas(nodes []redisClusterNode) map[string]int {
+	replicaCount := map[string]int{}
+	for _, node := range nodes {
+		if strings.Contains(node.Flags, "slave") {
+			replicaCount[node.MasterID]++
+		}
+	}
+	return replicaCount
+}
+
+// getLeastReplicatedMaster returns the redis cluster master serving slots with the fewest replicas
+func getLeastReplicatedMaster(nodes []redisClusterNode) (redisClusterNode, bool) {
+	replicaCount := countMasterReplicas(nodes)
+	var master redisClusterNode
+	found := false
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "master") || len(node.Slots) == 0 || strings.Contains(node.Flags, "fail") {
+			continue
+		}
+		if !found || replicaCount[node.ID] < replicaCount[master.ID] {
+			master = node
+			found = true
+		}
+	}
+	return master, found
+}
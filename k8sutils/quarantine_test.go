@@ -0,0 +1,47 @@
+package k8sutils
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlaveServiceExcludesQuarantinedPods(t *testing.T) {
+	client := useFakeK8sClient(t)
+	for _, name := range []string{"redis-slave-0", "redis-slave-1"} {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "redis-slave", "role": "slave"}}}
+		if _, err := client.CoreV1().Pods("default").Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cr := newTestRedisCluster(2)
+	cr.Spec.QuarantinePods = []string{"redis-slave-1"}
+	CreateSlaveService(cr)
+
+	service, err := client.CoreV1().Services("default").Get(context.TODO(), "redis-slave", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Selector[RedisServingLabel] != "true" {
+		t.Errorf("expected the slave service to select the serving pods, got %v", service.Spec.Selector)
+	}
+	for name, serving := range map[string]string{"redis-slave-0": "true", "redis-slave-1": "false"} {
+		pod, _ := client.CoreV1().Pods("default").Get(context.TODO(), name, metav1.GetOptions{})
+		if pod.Labels[RedisServingLabel] != serving || pod.Labels["app"] != "redis-slave" {
+			t.Errorf("expected %s to be labeled serving=%s, got %v", name, serving, pod.Labels)
+		}
+	}
+
+	cr.Spec.QuarantinePods = nil
+	CreateSlaveService(cr)
+	service, _ = client.CoreV1().Services("default").Get(context.TODO(), "redis-slave", metav1.GetOptions{})
+	if _, ok := service.Spec.Selector[RedisServingLabel]; ok {
+		t.Errorf("expected the serving label to be dropped from the selector, got %v", service.Spec.Selector)
+	}
+	pod, _ := client.CoreV1().Pods("default").Get(context.TODO(), "redis-slave-1", metav1.GetOptions{})
+	if _, ok := pod.Labels[RedisServingLabel]; ok {
+		t.Errorf("expected the serving label to be removed, got %v", pod.Labels)
+	}
+}
@@ -0,0 +1,70 @@
+package k8sutils
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"sync"
+	"time"
+)
+
+// adminRateLimiter throttles the redis admin commands sent to each pod
+type adminRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+var adminLimiter = &adminRateLimiter{next: map[string]time.Time{}}
+
+// SetAdminCommandRate sets the number of redis admin commands per second allowed for each pod, zero disables the limit
+func SetAdminCommandRate(commandsPerSecond float64) {
+	adminLimiter.mu.Lock()
+	defer adminLimiter.mu.Unlock()
+	if commandsPerSecond <= 0 {
+		adminLimiter.interval = 0
+		return
+	}
+	adminLimiter.interval = time.Duration(float64(time.Second) / commandsPerSecond)
+}
+
+// wait blocks until the pod is allowed to receive another admin command or the context is done
+func (l *adminRateLimiter) wait(ctx context.Context, namespace string, podName string) error {
+	l.mu.Lock()
+	if l.interval <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	key := namespace + "/" + podName
+	now := time.Now()
+	next := l.next[key]
+	if next.Before(now) {
+		next = now
+	}
+	l.next[key] = next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := next.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttleRedisClient makes every command of the client wait for the pod admin rate limit
+func throttleRedisClient(ctx context.Context, client *redis.Client, namespace string, podName string) {
+	client.WrapProcess(func(oldProcess func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			if err := adminLimiter.wait(ctx, namespace, podName); err != nil {
+				return err
+			}
+			return oldProcess(cmd)
+		}
+	})
+}
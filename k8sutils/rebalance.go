@@ -0,0 +1,189 @@
+package k8sutils
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strings"
+)
+
+// ReplicaMove is a redis replica moved from one master to another to balance the replicas of the cluster
+type ReplicaMove struct {
+	Replica string
+	From    string
+	To      string
+}
+
+// isRedisClusterStable reports whether every node of the redis cluster is reachable and connected over the cluster
+// bus, and no failover is in progress
+func isRedisClusterStable(nodes []redisClusterNode) bool {
+	for _, node := range nodes {
+		if strings.Contains(node.Flags, "fail") || strings.Contains(node.Flags, "handshake") || strings.Contains(node.Flags, "noaddr") {
+			return false
+		}
+		if node.LinkState == "disconnected" {
+			return false
+		}
+	}
+	return len(nodes) > 0
+}
+
+// isRedisReplicaSynced reports whether the INFO replication of a replica shows its link to the master up and no full
+// sync in progress
+func isRedisReplicaSynced(info map[string]string) bool {
+	return info["master_link_status"] == "up" && info["master_sync_in_progress"] == "0"
+}
+
+// areRedisReplicasSynced reports whether every replica of the redis cluster is in sync with its master, so that no
+// replica is moved, or loses its master, in the middle of a full sync. Replicas without a redis pod aren't synced.
+func areRedisReplicasSynced(ctx context.Context, cr *redisv1beta1.Redis, nodes []redisClusterNode, podsByIP map[string]corev1.Pod) (bool, error) {
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "slave") {
+			continue
+		}
+		pod, ok := podsByIP[node.IP]
+		if !ok {
+			return false, nil
+		}
+		client := configureRedisClient(ctx, cr, pod.Name)
+		output, err := client.Info("replication").Result()
+		client.Close()
+		if err != nil {
+			return false, err
+		}
+		if !isRedisReplicaSynced(parseRedisInfo(output)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// planReplicaMove returns the replica to move and the master it replicates next, so that the replica counts of the
+// masters serving slots differ by at most one. Replicas of masters without slots are moved first. Only the moves
+// accepted by allowed are planned. It returns empty IDs when the replicas are balanced, or no allowed move balances
+// them.
+func planReplicaMove(nodes []redisClusterNode, allowed func(replica redisClusterNode, master redisClusterNode) bool) (string, string) {
+	replicas := map[string][]string{}
+	nodesByID := map[string]redisClusterNode{}
+	var masters []string
+	for _, node := range nodes {
+		nodesByID[node.ID] = node
+		if strings.Contains(node.Flags, "master") && len(node.Slots) > 0 {
+			masters = append(masters, node.ID)
+		}
+	}
+	total := 0
+	for _, node := range nodes {
+		if strings.Contains(node.Flags, "slave") {
+			replicas[node.MasterID] = append(replicas[node.MasterID], node.ID)
+			total++
+		}
+	}
+	if len(masters) == 0 {
+		return "", ""
+	}
+	for _, ids := range replicas {
+		sort.Strings(ids)
+	}
+	// the masters with the most replicas keep the extra ones, which moves the fewest replicas
+	sort.Slice(masters, func(i, j int) bool {
+		if len(replicas[masters[i]]) != len(replicas[masters[j]]) {
+			return len(replicas[masters[i]]) > len(replicas[masters[j]])
+		}
+		return masters[i] < masters[j]
+	})
+	target := map[string]int{}
+	for i, id := range masters {
+		target[id] = total / len(masters)
+		if i < total%len(masters) {
+			target[id]++
+		}
+	}
+	var spare []string
+	for masterID, ids := range replicas {
+		if _, ok := target[masterID]; !ok {
+			spare = append(spare, ids...)
+		}
+	}
+	sort.Strings(spare)
+	isAllowed := func(replica string, receiver string) bool {
+		return allowed == nil || allowed(nodesByID[replica], nodesByID[receiver])
+	}
+	for i := len(masters) - 1; i >= 0; i-- {
+		receiver := masters[i]
+		if len(replicas[receiver]) >= target[receiver] {
+			continue
+		}
+		for _, id := range spare {
+			if isAllowed(id, receiver) {
+				return id, receiver
+			}
+		}
+		if len(spare) > 0 {
+			continue
+		}
+		for _, id := range masters {
+			for j := len(replicas[id]) - 1; j >= 0 && len(replicas[id]) > target[id]; j-- {
+				if isAllowed(replicas[id][j], receiver) {
+					return replicas[id][j], receiver
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// RebalanceRedisReplicas will move one replica with CLUSTER REPLICATE from a master with more replicas than its share
+// to a master with less, once the cluster is stable. A single replica is moved per call so that only one full resync
+// runs at a time. It returns nil when the replicas are balanced, the cluster isn't stable or the move waits for the
+// maintenance window, and the cluster is only stable once every node is connected and every replica is in sync with
+// its master. With a zone topology, only replicas which are in a zone the topology allows for the receiving
+// master are moved.
+func RebalanceRedisReplicas(ctx context.Context, cr *redisv1beta1.Redis) (*ReplicaMove, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	if !isRedisClusterStable(nodes) {
+		return nil, nil
+	}
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		return nil, err
+	}
+	if synced, err := areRedisReplicasSynced(ctx, cr, nodes, podsByIP); err != nil || !synced {
+		return nil, err
+	}
+	var allowed func(redisClusterNode, redisClusterNode) bool
+	if topology := getZoneTopology(cr); topology != "" {
+		zones := getRedisPodZones(cr, podsByIP)
+		allowed = func(replica redisClusterNode, master redisClusterNode) bool {
+			return isZoneTopologyMet(topology, zones[replica.IP], zones[master.IP])
+		}
+	}
+	replicaID, masterID := planReplicaMove(nodes, allowed)
+	if replicaID == "" || DeferDisruptiveOperation(cr, "rebalance of the redis replicas") {
+		return nil, nil
+	}
+	podNames := map[string]string{}
+	move := &ReplicaMove{}
+	for _, node := range nodes {
+		if pod, ok := podsByIP[node.IP]; ok {
+			podNames[node.ID] = pod.Name
+		}
+		if node.ID == replicaID {
+			move.From = node.MasterID
+		}
+	}
+	move.Replica, move.To = podNames[replicaID], podNames[masterID]
+	if move.Replica == "" || move.To == "" {
+		return nil, nil
+	}
+	if from, ok := podNames[move.From]; ok {
+		move.From = from
+	}
+	if err := runClusterCommand(ctx, cr, move.Replica, "replicate", masterID); err != nil {
+		return nil, err
+	}
+	reqLogger.Info("Moved redis replica to balance the replicas of the masters", "Replica", move.Replica, "From", move.From, "To", move.To)
+	return move, nil
+}
@@ -0,0 +1,88 @@
+package k8sutils
+
+import "testing"
+
+func TestPlanReplicaMove(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       []redisClusterNode
+		wantReplica string
+		wantMaster  string
+	}{
+		{
+			name: "balanced",
+			nodes: []redisClusterNode{
+				{ID: "m1", Flags: "master", Slots: []string{"0-8191"}},
+				{ID: "m2", Flags: "master", Slots: []string{"8192-16383"}},
+				{ID: "r1", Flags: "slave", MasterID: "m1"},
+				{ID: "r2", Flags: "slave", MasterID: "m2"},
+			},
+		},
+		{
+			name: "master left without replicas",
+			nodes: []redisClusterNode{
+				{ID: "m1", Flags: "master", Slots: []string{"0-5460"}},
+				{ID: "m2", Flags: "master", Slots: []string{"5461-10922"}},
+				{ID: "m3", Flags: "master", Slots: []string{"10923-16383"}},
+				{ID: "r1", Flags: "slave", MasterID: "m1"},
+				{ID: "r2", Flags: "slave", MasterID: "m1"},
+				{ID: "r3", Flags: "slave", MasterID: "m2"},
+			},
+			wantReplica: "r2",
+			wantMaster:  "m3",
+		},
+		{
+			name: "uneven replica count keeps the extra replica",
+			nodes: []redisClusterNode{
+				{ID: "m1", Flags: "master", Slots: []string{"0-8191"}},
+				{ID: "m2", Flags: "master", Slots: []string{"8192-16383"}},
+				{ID: "r1", Flags: "slave", MasterID: "m1"},
+				{ID: "r2", Flags: "slave", MasterID: "m1"},
+				{ID: "r3", Flags: "slave", MasterID: "m2"},
+			},
+		},
+		{
+			name: "replica of a master without slots moves first",
+			nodes: []redisClusterNode{
+				{ID: "m1", Flags: "master", Slots: []string{"0-8191"}},
+				{ID: "m2", Flags: "master", Slots: []string{"8192-16383"}},
+				{ID: "m3", Flags: "master"},
+				{ID: "r1", Flags: "slave", MasterID: "m1"},
+				{ID: "r2", Flags: "slave", MasterID: "m1"},
+				{ID: "r3", Flags: "slave", MasterID: "m3"},
+			},
+			wantReplica: "r3",
+			wantMaster:  "m2",
+		},
+	}
+	for _, test := range tests {
+		replica, master := planReplicaMove(test.nodes, nil)
+		if replica != test.wantReplica || master != test.wantMaster {
+			t.Errorf("%s: expected to move %q to %q, got %q to %q", test.name, test.wantReplica, test.wantMaster, replica, master)
+		}
+	}
+}
+
+func TestIsRedisClusterStable(t *testing.T) {
+	nodes := parseRedisClusterNodes("a 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-16383\n" +
+		"b 10.0.0.2:6379@16379 slave a 0 0 1 connected\n")
+	if !isRedisClusterStable(nodes) {
+		t.Error("expected a connected cluster to be stable")
+	}
+	nodes[1].LinkState = "disconnected"
+	if isRedisClusterStable(nodes) {
+		t.Error("expected a cluster with a disconnected node not to be stable")
+	}
+}
+
+func TestIsRedisReplicaSynced(t *testing.T) {
+	for info, want := range map[string]bool{
+		"role:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:0\r\n":   true,
+		"role:slave\r\nmaster_link_status:down\r\nmaster_sync_in_progress:0\r\n": false,
+		"role:slave\r\nmaster_link_status:up\r\nmaster_sync_in_progress:1\r\n":   false,
+	} {
+		if got := isRedisReplicaSynced(parseRedisInfo(info)); got != want {
+			t.Errorf("expected %v for %q, got %v", want, info, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
 	"github.com/go-redis/redis"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +15,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -21,12 +23,38 @@ var (
 	execErr bytes.Buffer
 )
 
+const (
+	defaultClusterInitTimeout    = time.Minute * 10
+	defaultClusterInitMaxRetries = 10
+)
+
 // RedisDetails will hold the information for Redis Pod
 type RedisDetails struct {
 	PodName   string
 	Namespace string
 }
 
+// redisPod will hold the name and role of a Redis Pod
+type redisPod struct {
+	Name string
+	Role string
+}
+
+// getRedisPods will return all the redis pods of the setup
+func getRedisPods(cr *redisv1beta1.Redis) []redisPod {
+	var pods []redisPod
+	if cr.Spec.Mode != "cluster" {
+		return append(pods, redisPod{Name: GetRedisName(cr) + "-standalone-0", Role: "standalone"})
+	}
+	for podCount := 0; podCount <= int(GetMasterReplicas(cr))-1; podCount++ {
+		pods = append(pods, redisPod{Name: GetRedisName(cr) + "-master-" + strconv.Itoa(podCount), Role: "master"})
+	}
+	for podCount := 0; podCount <= int(GetSlaveReplicas(cr))-1; podCount++ {
+		pods = append(pods, redisPod{Name: GetRedisName(cr) + "-slave-" + strconv.Itoa(podCount), Role: "slave"})
+	}
+	return pods
+}
+
 // getRedisServerIP will return the IP of redis service
 func getRedisServerIP(redisInfo RedisDetails) string {
 	reqLogger := log.WithValues("Request.Namespace", redisInfo.Namespace, "Request.PodName", redisInfo.PodName)
@@ -38,53 +66,122 @@ func getRedisServerIP(redisInfo RedisDetails) string {
 }
 
 // ExecuteRedisClusterCommand will execute redis cluster creation command
-func ExecuteRedisClusterCommand(cr *redisv1beta1.Redis) {
+func ExecuteRedisClusterCommand(ctx context.Context, cr *redisv1beta1.Redis) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 	replicas := cr.Spec.Size
-	cmd := []string{"redis-cli", "--cluster", "create"}
+	var addresses []string
 	for podCount := 0; podCount <= int(*replicas)-1; podCount++ {
 		pod := RedisDetails{
-			PodName:   cr.ObjectMeta.Name + "-master-" + strconv.Itoa(podCount),
+			PodName:   GetRedisName(cr) + "-master-" + strconv.Itoa(podCount),
 			Namespace: cr.Namespace,
 		}
-		cmd = append(cmd, getRedisServerIP(pod)+":6379")
+		addresses = append(addresses, getRedisServerIP(pod)+":6379")
+	}
+	if cr.Spec.ClusterInit != nil && len(cr.Spec.ClusterInit.Command) > 0 {
+		cmd := append(append([]string{}, cr.Spec.ClusterInit.Command...), addresses...)
+		reqLogger.Info("Redis cluster creation custom command is", "Command", cmd)
+		executeCommand(ctx, cr, cmd, GetRedisName(cr)+"-master-0")
+		return
 	}
+	cmd := append([]string{"redis-cli", "--cluster", "create"}, addresses...)
 	cmd = append(cmd, "--cluster-yes")
-	if cr.Spec.GlobalConfig.Password != nil && cr.Spec.GlobalConfig.ExistingPasswordSecret == nil {
+	if cr.Spec.GlobalConfig.Password != nil && !isRedisPasswordInSecret(cr) {
 		cmd = append(cmd, "-a")
 		cmd = append(cmd, *cr.Spec.GlobalConfig.Password)
 	}
 
-	if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
+	if isRedisPasswordInSecret(cr) {
 		pass := getRedisPassword(cr)
 		cmd = append(cmd, "-a")
 		cmd = append(cmd, pass)
 	}
 	reqLogger.Info("Redis cluster creation command is", "Command", cmd)
-	executeCommand(cr, cmd, cr.ObjectMeta.Name+"-master-0")
+	executeCommand(ctx, cr, cmd, GetRedisName(cr)+"-master-0")
 }
 
-// createRedisReplicationCommand will create redis replication creation command
-func createRedisReplicationCommand(cr *redisv1beta1.Redis, nodeNumber string) []string {
+// GetClusterInitTimeout returns how long the operator tries to form the redis cluster before reporting a failure
+func GetClusterInitTimeout(cr *redisv1beta1.Redis) time.Duration {
+	if cr.Spec.ClusterInit != nil && cr.Spec.ClusterInit.TimeoutSeconds != nil {
+		return time.Duration(*cr.Spec.ClusterInit.TimeoutSeconds) * time.Second
+	}
+	return defaultClusterInitTimeout
+}
+
+// GetClusterInitMaxRetries returns how many times the operator tries to form the redis cluster
+func GetClusterInitMaxRetries(cr *redisv1beta1.Redis) int32 {
+	if cr.Spec.ClusterInit != nil && cr.Spec.ClusterInit.MaxRetries != nil {
+		return *cr.Spec.ClusterInit.MaxRetries
+	}
+	return defaultClusterInitMaxRetries
+}
+
+// CheckRedisClusterStateOK reports whether the redis cluster reports cluster_state:ok, meaning every slot is served
+func CheckRedisClusterStateOK(ctx context.Context, cr *redisv1beta1.Redis) (bool, error) {
+	client := configureRedisClient(ctx, cr, GetRedisName(cr)+"-master-0")
+	defer client.Close()
+	output, err := client.ClusterInfo().Result()
+	if err != nil {
+		return false, err
+	}
+	return parseRedisInfo(output)["cluster_state"] == "ok", nil
+}
+
+// CheckRedisAdminConnection will check that every redis pod can be reached with the admin client
+func CheckRedisAdminConnection(ctx context.Context, cr *redisv1beta1.Redis) error {
+	for _, pod := range getRedisPods(cr) {
+		if getRedisServerIP(RedisDetails{PodName: pod.Name, Namespace: cr.Namespace}) == "" {
+			return fmt.Errorf("redis pod %s has no IP yet", pod.Name)
+		}
+		client := configureRedisClient(ctx, cr, pod.Name)
+		err := client.Ping().Err()
+		client.Close()
+		if err != nil {
+			return fmt.Errorf("redis pod %s is not reachable: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetFollowerCount returns the number of redis slaves, which defaults to the cluster size
+func GetFollowerCount(cr *redisv1beta1.Redis) int32 {
+	if cr.Spec.Slave.Replicas != nil {
+		return *cr.Spec.Slave.Replicas
+	}
+	return *cr.Spec.Size
+}
+
+// getRedisNodeID will return the cluster node id of the redis pod
+func getRedisNodeID(ctx context.Context, cr *redisv1beta1.Redis, podName string) string {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
-	cmd := []string{"redis-cli", "--cluster", "add-node"}
-	masterPod := RedisDetails{
-		PodName:   cr.ObjectMeta.Name + "-master-" + nodeNumber,
-		Namespace: cr.Namespace,
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	cmd := redis.NewStringCmd("cluster", "myid")
+	err := client.Process(cmd)
+	if err != nil {
+		reqLogger.Error(err, "Redis command failed with this error")
 	}
-	slavePod := RedisDetails{
-		PodName:   cr.ObjectMeta.Name + "-slave-" + nodeNumber,
-		Namespace: cr.Namespace,
+	output, err := cmd.Result()
+	if err != nil {
+		reqLogger.Error(err, "Redis command failed with this error")
 	}
-	cmd = append(cmd, getRedisServerIP(slavePod)+":6379")
-	cmd = append(cmd, getRedisServerIP(masterPod)+":6379")
+	return output
+}
+
+// createRedisReplicationCommand will create redis replication creation command
+func createRedisReplicationCommand(cr *redisv1beta1.Redis, masterNodeID string, slaveIP string, masterIP string) []string {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	cmd := []string{"redis-cli", "--cluster", "add-node"}
+	cmd = append(cmd, slaveIP+":6379")
+	cmd = append(cmd, masterIP+":6379")
 	cmd = append(cmd, "--cluster-slave")
+	cmd = append(cmd, "--cluster-master-id")
+	cmd = append(cmd, masterNodeID)
 
-	if cr.Spec.GlobalConfig.Password != nil && cr.Spec.GlobalConfig.ExistingPasswordSecret == nil {
+	if cr.Spec.GlobalConfig.Password != nil && !isRedisPasswordInSecret(cr) {
 		cmd = append(cmd, "-a")
 		cmd = append(cmd, *cr.Spec.GlobalConfig.Password)
 	}
-	if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
+	if isRedisPasswordInSecret(cr) {
 		pass := getRedisPassword(cr)
 		cmd = append(cmd, "-a")
 		cmd = append(cmd, pass)
@@ -93,21 +190,109 @@ func createRedisReplicationCommand(cr *redisv1beta1.Redis, nodeNumber string) []
 	return cmd
 }
 
-// ExecuteRedisReplicationCommand will execute the replication command
-func ExecuteRedisReplicationCommand(cr *redisv1beta1.Redis) {
+// getReplicaMaster returns the master serving slots with the fewest replicas for a joining slave, preferring the
+// master with the IP among the tied ones so that the slaves of a fresh cluster pair with the masters of their ordinal.
+// Only the masters accepted by allowed are considered, unless none of them is.
+func getReplicaMaster(nodes []redisClusterNode, preferredIP string, allowed func(redisClusterNode) bool) (redisClusterNode, bool) {
+	candidates := nodes
+	if allowed != nil {
+		candidates = nil
+		for _, node := range nodes {
+			if !strings.Contains(node.Flags, "master") || allowed(node) {
+				candidates = append(candidates, node)
+			}
+		}
+	}
+	master, ok := getLeastReplicatedMaster(candidates)
+	if !ok {
+		candidates = nodes
+		if master, ok = getLeastReplicatedMaster(nodes); !ok {
+			return master, false
+		}
+	}
+	replicaCount := countMasterReplicas(nodes)
+	for _, node := range candidates {
+		if node.IP == preferredIP && strings.Contains(node.Flags, "master") && len(node.Slots) > 0 &&
+			!strings.Contains(node.Flags, "fail") && replicaCount[node.ID] == replicaCount[master.ID] {
+			return node, true
+		}
+	}
+	return master, true
+}
+
+// ExecuteRedisReplicationCommand will attach every slave which is not yet part of the cluster to a master. By default
+// every slave replicates the master with the fewest replicas, read from CLUSTER NODES, so that the replicas stay
+// balanced when slaves are added after failovers moved the masters. With the roundRobin replica assignment slaves
+// are distributed across the master pods by ordinal, so when the slave count is not a multiple of the cluster size
+// the first masters get one more replica. With a zone topology, the least replicated master is picked among the
+// masters in the zones the topology allows for the slave.
+func ExecuteRedisReplicationCommand(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 	replicas := cr.Spec.Size
-	for podCount := 0; podCount <= int(*replicas)-1; podCount++ {
-		cmd := createRedisReplicationCommand(cr, strconv.Itoa(podCount))
-		executeCommand(cr, cmd, cr.ObjectMeta.Name+"-master-0")
+	followers := GetFollowerCount(cr)
+	if followers%*replicas != 0 {
+		reqLogger.Info("Redis slave count is not a multiple of masters, replicas will be unevenly distributed", "Masters", *replicas, "Slaves", followers)
+	}
+	clusterNodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	topology := getZoneTopology(cr)
+	zones := map[string]string{}
+	if topology != "" && cr.Spec.Slave.ReplicaAssignment != "roundRobin" {
+		if podsByIP, err := getRedisPodsByIP(cr); err != nil {
+			reqLogger.Error(err, "Could not list redis pods, slaves are attached regardless of their zone")
+		} else {
+			zones = getRedisPodZones(cr, podsByIP)
+		}
+	}
+	for podCount := 0; podCount <= int(followers)-1; podCount++ {
+		slavePod := RedisDetails{
+			PodName:   GetRedisName(cr) + "-slave-" + strconv.Itoa(podCount),
+			Namespace: cr.Namespace,
+		}
+		slaveIP := getRedisServerIP(slavePod)
+		if isPodQuarantined(cr, slavePod.PodName) || isRedisNodeInCluster(clusterNodes, slaveIP) {
+			continue
+		}
+		masterPodName := GetRedisName(cr) + "-master-" + strconv.Itoa(podCount%int(*replicas))
+		masterPod := RedisDetails{
+			PodName:   masterPodName,
+			Namespace: cr.Namespace,
+		}
+		masterIP := getRedisServerIP(masterPod)
+		var masterID string
+		if cr.Spec.Slave.ReplicaAssignment != "roundRobin" {
+			allowed := func(master redisClusterNode) bool {
+				return isZoneTopologyMet(topology, zones[slaveIP], zones[master.IP])
+			}
+			if master, ok := getReplicaMaster(clusterNodes, masterIP, allowed); ok {
+				masterID, masterIP = master.ID, master.IP
+				reqLogger.Info("Redis slave replicates the master with the fewest replicas", "Pod.Name", slavePod.PodName, "Master.IP", masterIP, "Zone", zones[slaveIP], "Master.Zone", zones[masterIP])
+			}
+		}
+		if masterID == "" {
+			masterID = getRedisNodeID(ctx, cr, masterPodName)
+		}
+		cmd := createRedisReplicationCommand(cr, masterID, slaveIP, masterIP)
+		executeCommand(ctx, cr, cmd, GetRedisName(cr)+"-master-0")
+		// the next slaves count the replica just added
+		clusterNodes = append(clusterNodes, redisClusterNode{IP: slaveIP, Flags: "slave", MasterID: masterID})
 	}
 }
 
+// isRedisNodeInCluster will check if the ip is already a node of the redis cluster
+func isRedisNodeInCluster(nodes []redisClusterNode, ip string) bool {
+	for _, node := range nodes {
+		if node.IP == ip {
+			return true
+		}
+	}
+	return false
+}
+
 // checkRedisCluster will check the redis cluster have sufficient nodes or not
-func checkRedisCluster(cr *redisv1beta1.Redis) string {
-	var client *redis.Client
+func checkRedisCluster(ctx context.Context, cr *redisv1beta1.Redis) string {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 
-	client = configureRedisClient(cr, cr.ObjectMeta.Name+"-master-0")
+	client := configureRedisClient(ctx, cr, GetRedisName(cr)+"-master-0")
 	cmd := redis.NewStringCmd("cluster", "nodes")
 	err := client.Process(cmd)
 	if err != nil {
@@ -123,19 +308,26 @@ func checkRedisCluster(cr *redisv1beta1.Redis) string {
 }
 
 // ExecuteFaioverOperation will execute redis failover operations
-func ExecuteFaioverOperation(cr *redisv1beta1.Redis) {
-	executeFailoverCommand(cr, "master")
-	executeFailoverCommand(cr, "slave")
+func ExecuteFaioverOperation(ctx context.Context, cr *redisv1beta1.Redis) {
+	executeFailoverCommand(ctx, cr, "master")
+	executeFailoverCommand(ctx, cr, "slave")
 }
 
 // executeFailoverCommand will execute failover command
-func executeFailoverCommand(cr *redisv1beta1.Redis, role string) {
+func executeFailoverCommand(ctx context.Context, cr *redisv1beta1.Redis, role string) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 	replicas := cr.Spec.Size
-	podName := cr.ObjectMeta.Name + "-" + role + "-"
+	if role == "slave" {
+		followers := GetFollowerCount(cr)
+		replicas = &followers
+	}
+	podName := GetRedisName(cr) + "-" + role + "-"
 	for podCount := 0; podCount <= int(*replicas)-1; podCount++ {
+		if isPodQuarantined(cr, podName+strconv.Itoa(podCount)) {
+			continue
+		}
 		reqLogger.Info("Executing redis failover operations", "Redis Node", podName+strconv.Itoa(podCount))
-		client := configureRedisClient(cr, podName+strconv.Itoa(podCount))
+		client := configureRedisClient(ctx, cr, podName+strconv.Itoa(podCount))
 		cmd := redis.NewStringCmd("cluster", "reset")
 		err := client.Process(cmd)
 		if err != nil {
@@ -156,9 +348,9 @@ func executeFailoverCommand(cr *redisv1beta1.Redis, role string) {
 }
 
 // CheckRedisNodeCount will check the count of redis nodes
-func CheckRedisNodeCount(cr *redisv1beta1.Redis) int {
+func CheckRedisNodeCount(ctx context.Context, cr *redisv1beta1.Redis) int {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
-	output := checkRedisCluster(cr)
+	output := checkRedisCluster(ctx, cr)
 	scanner := bufio.NewScanner(strings.NewReader(output))
 
 	count := 0
@@ -170,49 +362,80 @@ func CheckRedisNodeCount(cr *redisv1beta1.Redis) int {
 }
 
 // CheckRedisClusterState will check the redis cluster state
-func CheckRedisClusterState(cr *redisv1beta1.Redis) int {
+func CheckRedisClusterState(ctx context.Context, cr *redisv1beta1.Redis) int {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
-	output := checkRedisCluster(cr)
+	output := checkRedisCluster(ctx, cr)
 	pattern := regexp.MustCompile("fail")
 	match := pattern.FindAllStringIndex(output, -1)
 	reqLogger.Info("Number of failed nodes in cluster", "Failed Node Count", len(match))
 	return len(match)
 }
 
-// configureRedisClient will configure the Redis Client
-func configureRedisClient(cr *redisv1beta1.Redis, podName string) *redis.Client {
+// redisTransientNodeFlags are the CLUSTER NODES flags of a failover in progress, a node suspected to fail which may
+// be failed over next and a node which is still being introduced to the cluster
+var redisTransientNodeFlags = []string{"fail?", "handshake"}
+
+// IsRedisFailoverInProgress checks if the redis cluster has nodes in a transient state, like during a failover run
+// by an admin, so that the operator doesn't change the topology until the cluster settles. Besides the transient
+// flags, a replica of a node which is itself a replica shows a former master which didn't follow its promoted
+// replica yet.
+func IsRedisFailoverInProgress(ctx context.Context, cr *redisv1beta1.Redis) bool {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	nodeFlags := map[string]string{}
+	for _, node := range nodes {
+		nodeFlags[node.ID] = node.Flags
+	}
+	for _, node := range nodes {
+		if strings.Contains(node.Flags, "slave") && strings.Contains(nodeFlags[node.MasterID], "slave") {
+			reqLogger.Info("Redis cluster node replicates a replica", "Node.ID", node.ID, "Node.IP", node.IP, "Master.ID", node.MasterID)
+			return true
+		}
+		for _, flag := range strings.Split(node.Flags, ",") {
+			for _, transient := range redisTransientNodeFlags {
+				if flag == transient {
+					reqLogger.Info("Redis cluster node is in a transient state", "Node.ID", node.ID, "Node.IP", node.IP, "Flags", node.Flags)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// configureRedisClient will return a client of the admin connection pool for the redis pod, its commands fail once
+// the context is done
+func configureRedisClient(ctx context.Context, cr *redisv1beta1.Redis, podName string) *redisAdminClient {
 	redisInfo := RedisDetails{
 		PodName:   podName,
 		Namespace: cr.Namespace,
 	}
-	var client *redis.Client
-
-	if cr.Spec.GlobalConfig.Password != nil && cr.Spec.GlobalConfig.ExistingPasswordSecret == nil {
-		client = redis.NewClient(&redis.Options{
-			Addr:     getRedisServerIP(redisInfo) + ":6379",
-			Password: *cr.Spec.GlobalConfig.Password,
-			DB:       0,
-		})
-	} else if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
-		pass := getRedisPassword(cr)
-		client = redis.NewClient(&redis.Options{
-			Addr:     getRedisServerIP(redisInfo) + ":6379",
-			Password: pass,
-			DB:       0,
-		})
-	} else {
-		client = redis.NewClient(&redis.Options{
-			Addr:     getRedisServerIP(redisInfo) + ":6379",
-			Password: "",
-			DB:       0,
-		})
-	}
-	return client
+	password := ""
+	if isRedisPasswordInSecret(cr) {
+		password = getRedisPassword(cr)
+	} else if cr.Spec.GlobalConfig.Password != nil {
+		password = *cr.Spec.GlobalConfig.Password
+	}
+	pooled := adminClientPool.get(cr.Namespace+"/"+cr.ObjectMeta.Name, podName, getRedisServerIP(redisInfo)+":6379", password)
+	client := pooled.WithContext(ctx)
+	client.SetLimiter(contextLimiter{ctx: ctx})
+	throttleRedisClient(ctx, client, cr.Namespace, podName)
+	return &redisAdminClient{Client: client}
 }
 
 // executeCommand will execute the commands in pod
-func executeCommand(cr *redisv1beta1.Redis, cmd []string, podName string) {
+func executeCommand(ctx context.Context, cr *redisv1beta1.Redis, cmd []string, podName string) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if err := adminLimiter.wait(ctx, cr.Namespace, podName); err != nil {
+		reqLogger.Error(err, "Redis command was not executed")
+		return
+	}
+	if getAdminJobImage(cr) != "" {
+		if err := runAdminJob(ctx, cr, cmd); err != nil {
+			reqLogger.Error(err, "Could not execute command in a redis admin job")
+		}
+		return
+	}
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		reqLogger.Error(err, "Error while reading Incluster config")
@@ -245,6 +468,57 @@ func executeCommand(cr *redisv1beta1.Redis, cmd []string, podName string) {
 	reqLogger.Info("Successfully executed the command", "Command", cmd, "Output", execOut.String())
 }
 
+// runAdminCommand will execute the redis admin command in the pod, or in an admin job when the admin image is set,
+// and return the error it failed with along with its output
+func runAdminCommand(ctx context.Context, cr *redisv1beta1.Redis, cmd []string, podName string) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if err := adminLimiter.wait(ctx, cr.Namespace, podName); err != nil {
+		return err
+	}
+	if getAdminJobImage(cr) != "" {
+		return runAdminJob(ctx, cr, cmd)
+	}
+	targetContainer, pod := getContainerID(cr, podName)
+	if targetContainer < 0 {
+		return fmt.Errorf("could not find the redis container of pod %s", podName)
+	}
+	output, err := executeCommandOutput(cr, cmd, podName, pod.Spec.Containers[targetContainer].Name)
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+	}
+	reqLogger.Info("Successfully executed the command", "Command", withoutPasswordArgs(cmd), "Output", output)
+	return nil
+}
+
+// executeCommandOutput will execute the command in the container of a pod and return its output
+func executeCommandOutput(cr *redisv1beta1.Redis, cmd []string, podName string, containerName string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return "", err
+	}
+	req := GenerateK8sClient().CoreV1().RESTClient().Post().Resource("pods").Name(podName).Namespace(cr.Namespace).SubResource("exec")
+	req.VersionedParams(&corev1.PodExecOptions{
+		Container: containerName,
+		Command:   cmd,
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+	err = exec.Stream(remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Tty:    false,
+	})
+	if err != nil {
+		return stderr.String(), err
+	}
+	return stdout.String(), nil
+}
+
 // getContainerID will return the id of container from pod
 func getContainerID(cr *redisv1beta1.Redis, podName string) (int, *corev1.Pod) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
@@ -256,7 +530,7 @@ func getContainerID(cr *redisv1beta1.Redis, podName string) (int, *corev1.Pod) {
 	targetContainer := -1
 	for containerID, tr := range pod.Spec.Containers {
 		reqLogger.Info("Pod Counted successfully", "Count", containerID, "Container Name", tr.Name)
-		if tr.Name == cr.ObjectMeta.Name+"-master" {
+		if tr.Name == GetRedisName(cr)+"-master" {
 			targetContainer = containerID
 			break
 		}
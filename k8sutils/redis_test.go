@@ -0,0 +1,34 @@
+package k8sutils
+
+import "testing"
+
+func TestGetReplicaMaster(t *testing.T) {
+	nodes := []redisClusterNode{
+		{ID: "a", IP: "10.0.0.1", Flags: "master", Slots: []string{"0-5460"}},
+		{ID: "b", IP: "10.0.0.2", Flags: "master", Slots: []string{"5461-10922"}},
+		{ID: "c", IP: "10.0.0.3", Flags: "master", Slots: []string{"10923-16383"}},
+		{ID: "d", IP: "10.0.0.4", Flags: "slave", MasterID: "a"},
+		{ID: "e", IP: "10.0.0.5", Flags: "slave", MasterID: "b"},
+	}
+	if master, ok := getReplicaMaster(nodes, "10.0.0.1", nil); !ok || master.ID != "c" {
+		t.Fatalf("expected the master without replicas, got %+v", master)
+	}
+	nodes = append(nodes, redisClusterNode{ID: "f", IP: "10.0.0.6", Flags: "slave", MasterID: "c"})
+	if master, ok := getReplicaMaster(nodes, "10.0.0.2", nil); !ok || master.ID != "b" {
+		t.Fatalf("expected the preferred master among the tied ones, got %+v", master)
+	}
+	if _, ok := getReplicaMaster([]redisClusterNode{{ID: "a", IP: "10.0.0.1", Flags: "master"}}, "10.0.0.1", nil); ok {
+		t.Fatal("expected no master when no master serves slots")
+	}
+}
+
+func TestParseFunctionList(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{"library_name", "hello", "engine", "LUA", "functions", []interface{}{}, "library_code", "#!lua name=hello\n"},
+		[]interface{}{"library_name", "broken", "engine", "LUA"},
+	}
+	code := parseFunctionList(reply)
+	if len(code) != 1 || !code["#!lua name=hello\n"] {
+		t.Errorf("expected the code of the hello library, got %v", code)
+	}
+}
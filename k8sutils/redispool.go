@@ -0,0 +1,131 @@
+package k8sutils
+
+import (
+	"context"
+	"github.com/go-redis/redis"
+	"sync"
+	"time"
+)
+
+// redisAdminClient is a client of the redis admin connection pool, closing it releases it back to the pool
+type redisAdminClient struct {
+	*redis.Client
+}
+
+// Close releases the client, its connections are kept open until they are idle for the idle TTL
+func (c *redisAdminClient) Close() error {
+	return nil
+}
+
+// pooledRedisClient is a redis client shared by the admin commands sent to a pod
+type pooledRedisClient struct {
+	client   *redis.Client
+	addr     string
+	password string
+	lastUsed time.Time
+}
+
+// redisClientPool keeps the redis clients of the admin commands across reconciles, by redis resource and pod
+type redisClientPool struct {
+	mu           sync.Mutex
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	idleTTL      time.Duration
+	clients      map[string]map[string]*pooledRedisClient
+}
+
+var adminClientPool = &redisClientPool{
+	dialTimeout:  5 * time.Second,
+	readTimeout:  3 * time.Second,
+	writeTimeout: 3 * time.Second,
+	idleTTL:      5 * time.Minute,
+	clients:      map[string]map[string]*pooledRedisClient{},
+}
+
+// SetRedisClientTimeouts sets the dial, read and write timeouts of the redis admin connections, and how long a pod's
+// connections are kept open once they are no longer used
+func SetRedisClientTimeouts(dial, read, write, idleTTL time.Duration) {
+	adminClientPool.mu.Lock()
+	defer adminClientPool.mu.Unlock()
+	adminClientPool.dialTimeout = dial
+	adminClientPool.readTimeout = read
+	adminClientPool.writeTimeout = write
+	adminClientPool.idleTTL = idleTTL
+}
+
+// CloseRedisClients will close the redis admin connections of a redis resource, like once it is deleted
+func CloseRedisClients(namespace string, name string) {
+	adminClientPool.mu.Lock()
+	defer adminClientPool.mu.Unlock()
+	adminClientPool.closeClients(namespace + "/" + name)
+}
+
+// closeClients closes the clients of a redis resource, the pool lock must be held
+func (p *redisClientPool) closeClients(key string) {
+	for _, pooled := range p.clients[key] {
+		pooled.client.Close()
+	}
+	delete(p.clients, key)
+}
+
+// get returns the client of the pod, a new one is created when the pod has none or its address or password changed.
+// The clients idle for longer than the idle TTL are closed along the way.
+func (p *redisClientPool) get(key string, podName string, addr string, password string) *redis.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for resource, pods := range p.clients {
+		for name, pooled := range pods {
+			if now.Sub(pooled.lastUsed) > p.idleTTL {
+				pooled.client.Close()
+				delete(pods, name)
+			}
+		}
+		if len(pods) == 0 {
+			delete(p.clients, resource)
+		}
+	}
+
+	if p.clients[key] == nil {
+		p.clients[key] = map[string]*pooledRedisClient{}
+	}
+	pooled, ok := p.clients[key][podName]
+	if ok && (pooled.addr != addr || pooled.password != password) {
+		pooled.client.Close()
+		ok = false
+	}
+	if !ok {
+		pooled = &pooledRedisClient{
+			client: redis.NewClient(&redis.Options{
+				Addr:         addr,
+				Password:     password,
+				DB:           0,
+				DialTimeout:  p.dialTimeout,
+				ReadTimeout:  p.readTimeout,
+				WriteTimeout: p.writeTimeout,
+				PoolSize:     2,
+				IdleTimeout:  p.idleTTL,
+			}),
+			addr:     addr,
+			password: password,
+		}
+		p.clients[key][podName] = pooled
+	}
+	pooled.lastUsed = now
+	return pooled.client
+}
+
+// contextLimiter fails the commands of a client once the reconcile context is done, instead of sending them to a pod
+// the reconcile no longer waits for
+type contextLimiter struct {
+	ctx context.Context
+}
+
+// Allow returns the error of the context once it is done
+func (l contextLimiter) Allow() error {
+	return l.ctx.Err()
+}
+
+// ReportResult is a no-op, the results of the commands don't change the limit
+func (l contextLimiter) ReportResult(result error) {}
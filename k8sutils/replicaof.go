@@ -0,0 +1,170 @@
+package k8sutils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+)
+
+const (
+	replicaOfAuthMountPath = "/etc/redis/replicaof"
+	replicaOfAuthFile      = "masterauth.conf"
+)
+
+// getReplicaOfAddress returns the host and port of the external redis primary
+func getReplicaOfAddress(cr *redisv1beta1.Redis) string {
+	port := int32(6379)
+	if cr.Spec.ReplicaOf.Port != nil {
+		port = *cr.Spec.ReplicaOf.Port
+	}
+	return cr.Spec.ReplicaOf.Host + " " + strconv.Itoa(int(port))
+}
+
+// getReplicaOfPassword returns the password of the external redis primary
+func getReplicaOfPassword(cr *redisv1beta1.Redis) (string, error) {
+	return readPasswordSecret(cr, cr.Spec.ReplicaOf.PasswordSecret)
+}
+
+// readPasswordSecret returns the password of an external redis held by the key of the secret
+func readPasswordSecret(cr *redisv1beta1.Redis, passwordSecret *redisv1beta1.ExistingPasswordSecret) (string, error) {
+	secret, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), *passwordSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	password, ok := secret.Data[*passwordSecret.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", *passwordSecret.Key, *passwordSecret.Name)
+	}
+	return string(password), nil
+}
+
+// getReplicaOfAuthVolume returns the volume mounting the masterauth configuration of the external redis primary
+func getReplicaOfAuthVolume(cr *redisv1beta1.Redis) corev1.Volume {
+	return corev1.Volume{
+		Name: "replicaof-auth",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: GetRedisName(cr) + "-replicaof-auth",
+			},
+		},
+	}
+}
+
+// GenerateReplicaOfAuthSecret generates the secret holding the masterauth configuration included by redis
+func GenerateReplicaOfAuthSecret(cr *redisv1beta1.Redis, password string) *corev1.Secret {
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   GenerateMetaInformation("Secret", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-replicaof-auth", labels, GenerateSecretAnots()),
+		Data: map[string][]byte{
+			replicaOfAuthFile: []byte("masterauth " + password + "\n"),
+		},
+	}
+	AddOwnerRefToObject(secret, AsOwner(cr))
+	return secret
+}
+
+// CreateRedisReplicaOfAuthSecret will create or update the masterauth secret, a changed password is also applied
+// to the running redis with CONFIG SET
+func CreateRedisReplicaOfAuthSecret(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	password, err := getReplicaOfPassword(cr)
+	if err != nil {
+		reqLogger.Error(err, "Failed in reading the password of the external redis primary")
+		return
+	}
+	secretBody := GenerateReplicaOfAuthSecret(cr, password)
+	existingSecret, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), secretBody.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating masterauth secret for redis", "Secret.Name", secretBody.Name)
+		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Create(context.TODO(), secretBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating masterauth secret for redis")
+		}
+		return
+	}
+	passwordChanged := !apiequality.Semantic.DeepEqual(existingSecret.Data, secretBody.Data)
+	if mergeObjectMeta(&existingSecret.ObjectMeta, secretBody.ObjectMeta) || passwordChanged {
+		reqLogger.Info("Reconciling masterauth secret for redis", "Secret.Name", secretBody.Name)
+		existingSecret.Data = secretBody.Data
+		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Update(context.TODO(), existingSecret, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating masterauth secret for redis")
+			return
+		}
+	}
+	if passwordChanged {
+		setDynamicRedisConfig(ctx, cr, "standalone", map[string]string{"masterauth": password})
+	}
+}
+
+// parseRedisInfo parses the fields of the INFO command output
+func parseRedisInfo(output string) map[string]string {
+	info := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) == 2 {
+			info[fields[0]] = fields[1]
+		}
+	}
+	return info
+}
+
+// GetReplicaOfStatus returns the replication state of the standalone redis replicating the external primary
+func GetReplicaOfStatus(ctx context.Context, cr *redisv1beta1.Redis) (*redisv1beta1.ReplicaOfStatus, error) {
+	client := configureRedisClient(ctx, cr, GetRedisName(cr)+"-standalone-0")
+	defer client.Close()
+	output, err := client.Info("replication").Result()
+	if err != nil {
+		return nil, err
+	}
+	info := parseRedisInfo(output)
+	status := &redisv1beta1.ReplicaOfStatus{
+		LinkStatus: info["master_link_status"],
+	}
+	status.LastIOSecondsAgo, _ = strconv.ParseInt(info["master_last_io_seconds_ago"], 10, 64)
+	status.LinkDownSeconds, _ = strconv.ParseInt(info["master_link_down_since_seconds"], 10, 64)
+	status.ReplOffset, _ = strconv.ParseInt(info["slave_repl_offset"], 10, 64)
+	if masterOffset, err := getReplicaOfMasterOffset(ctx, cr); err == nil {
+		status.MasterReplOffset = masterOffset
+		status.LagBytes = getReplicationLagBytes(masterOffset, status.ReplOffset)
+	} else {
+		log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name).Info("Could not read the replication offset of the external primary", "Reason", err.Error())
+	}
+	return status, nil
+}
+
+// getReplicaOfMasterOffset returns the master_repl_offset of the external redis primary
+func getReplicaOfMasterOffset(ctx context.Context, cr *redisv1beta1.Redis) (int64, error) {
+	password := ""
+	if cr.Spec.ReplicaOf.PasswordSecret != nil {
+		var err error
+		if password, err = getReplicaOfPassword(cr); err != nil {
+			return 0, err
+		}
+	}
+	master := redis.NewClient(&redis.Options{
+		Addr:     strings.Replace(getReplicaOfAddress(cr), " ", ":", 1),
+		Password: password,
+	}).WithContext(ctx)
+	defer master.Close()
+	output, err := master.Info("replication").Result()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(parseRedisInfo(output)["master_repl_offset"], 10, 64)
+}
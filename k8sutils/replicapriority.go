@@ -0,0 +1,71 @@
+package k8sutils
+
+import (
+	"context"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+)
+
+// getReplicaPriority returns the replica priority of the slave pod with the ordinal, the first rule listing the
+// ordinal applies and the default otherwise
+func getReplicaPriority(cr *redisv1beta1.Redis, ordinal int32) *int32 {
+	priority := cr.Spec.Slave.ReplicaPriority
+	if priority == nil {
+		return nil
+	}
+	for _, rule := range priority.Rules {
+		for _, ruleOrdinal := range rule.Ordinals {
+			if ruleOrdinal == ordinal {
+				return &rule.Priority
+			}
+		}
+	}
+	return priority.Default
+}
+
+// generateReplicaPriorityConfig returns the redis configuration directives of the replica priority. Redis cluster
+// elects the replica with the most recent data instead of using the priority, so a priority of 0 also turns on
+// cluster-replica-no-failover to keep the replica from being promoted.
+func generateReplicaPriorityConfig(cr *redisv1beta1.Redis, priority int32) map[string]string {
+	prefix := "replica"
+	if !redisVersionAtLeast(cr, 5, 0) {
+		prefix = "slave"
+	}
+	config := map[string]string{
+		prefix + "-priority": strconv.Itoa(int(priority)),
+	}
+	if cr.Spec.Mode == "cluster" {
+		config["cluster-"+prefix+"-no-failover"] = yesNo(priority == 0)
+	}
+	return config
+}
+
+// ApplyReplicaPriorities will set the replica priority of the slave pods matched by a rule with CONFIG SET, since
+// the configmap of the slaves only holds the default priority. The rules are applied on every reconcile, which also
+// restores them after a pod restart.
+func ApplyReplicaPriorities(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if cr.Spec.Slave.ReplicaPriority == nil || len(cr.Spec.Slave.ReplicaPriority.Rules) == 0 {
+		return
+	}
+	for ordinal := int32(0); ordinal < GetFollowerCount(cr); ordinal++ {
+		priority := getReplicaPriority(cr, ordinal)
+		if priority == nil {
+			continue
+		}
+		podName := GetRedisName(cr) + "-slave-" + strconv.Itoa(int(ordinal))
+		client := configureRedisClient(ctx, cr, podName)
+		for key, value := range generateReplicaPriorityConfig(cr, *priority) {
+			current, err := client.ConfigGet(key).Result()
+			if err == nil && len(current) == 2 && current[1] == value {
+				continue
+			}
+			if err := client.ConfigSet(key, value).Err(); err != nil {
+				reqLogger.Error(err, "Failed in setting the replica priority", "Pod.Name", podName, "Directive", key)
+				continue
+			}
+			reqLogger.Info("Replica priority is set", "Pod.Name", podName, "Directive", key, "Value", value)
+		}
+		client.Close()
+	}
+}
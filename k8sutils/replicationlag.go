@@ -0,0 +1,106 @@
+package k8sutils
+
+import (
+	"context"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultReplicationLagThreshold is the replication lag in bytes above which replicas are reported as lagging
+const defaultReplicationLagThreshold = 10 * 1024 * 1024
+
+// replicaOffset is a replica connected to a redis master, parsed from a slaveN line of INFO replication
+type replicaOffset struct {
+	IP     string
+	Offset int64
+}
+
+// GetReplicationLagThreshold returns the replication lag in bytes above which replicas are reported as lagging
+func GetReplicationLagThreshold(cr *redisv1beta1.Redis) int64 {
+	if cr.Spec.Replication != nil && cr.Spec.Replication.LagThresholdBytes != nil {
+		return *cr.Spec.Replication.LagThresholdBytes
+	}
+	return defaultReplicationLagThreshold
+}
+
+// parseReplicaOffsets parses the master offset and the offsets of the connected replicas from INFO replication
+func parseReplicaOffsets(output string) (int64, []replicaOffset, bool) {
+	info := parseRedisInfo(output)
+	if info["role"] != "master" {
+		return 0, nil, false
+	}
+	masterOffset, err := strconv.ParseInt(info["master_repl_offset"], 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	var replicas []replicaOffset
+	for key, value := range info {
+		if !strings.HasPrefix(key, "slave") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(key, "slave")); err != nil {
+			continue
+		}
+		fields := map[string]string{}
+		for _, field := range strings.Split(value, ",") {
+			pair := strings.SplitN(field, "=", 2)
+			if len(pair) == 2 {
+				fields[pair[0]] = pair[1]
+			}
+		}
+		offset, err := strconv.ParseInt(fields["offset"], 10, 64)
+		if err != nil {
+			continue
+		}
+		replicas = append(replicas, replicaOffset{IP: fields["ip"], Offset: offset})
+	}
+	return masterOffset, replicas, true
+}
+
+// getReplicationLagBytes returns how far the replica offset is behind the master offset, a replica which read the
+// master offset before the replica offset can be ahead of it
+func getReplicationLagBytes(masterOffset int64, replicaOffset int64) int64 {
+	if masterOffset > replicaOffset {
+		return masterOffset - replicaOffset
+	}
+	return 0
+}
+
+// GetReplicationLag returns the replication lag in bytes of every replica of the redis cluster, computed from the
+// master_repl_offset of its master and the offset the master reports for the replica
+func GetReplicationLag(ctx context.Context, cr *redisv1beta1.Redis) []redisv1beta1.ReplicationLag {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return nil
+	}
+	var lags []redisv1beta1.ReplicationLag
+	for _, pod := range getRedisPods(cr) {
+		client := configureRedisClient(ctx, cr, pod.Name)
+		output, err := client.Info("replication").Result()
+		client.Close()
+		if err != nil {
+			reqLogger.Info("Could not read the replication info of redis", "Pod.Name", pod.Name, "Reason", err.Error())
+			continue
+		}
+		masterOffset, replicas, ok := parseReplicaOffsets(output)
+		if !ok {
+			continue
+		}
+		for _, replica := range replicas {
+			replicaPod, ok := podsByIP[replica.IP]
+			if !ok {
+				continue
+			}
+			lag := getReplicationLagBytes(masterOffset, replica.Offset)
+			lags = append(lags, redisv1beta1.ReplicationLag{PodName: replicaPod.Name, MasterPodName: pod.Name, LagBytes: lag})
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		return lags[i].PodName < lags[j].PodName
+	})
+	return lags
+}
@@ -0,0 +1,39 @@
+package k8sutils
+
+import (
+	"testing"
+)
+
+func TestParseReplicaOffsets(t *testing.T) {
+	output := "# Replication\r\nrole:master\r\nconnected_slaves:2\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=900,lag=0\r\n" +
+		"slave1:ip=10.0.0.3,port=6379,state=online,offset=1000,lag=1\r\n" +
+		"master_replid:8d8c8e8f\r\nmaster_repl_offset:1000\r\n"
+	masterOffset, replicas, ok := parseReplicaOffsets(output)
+	if !ok {
+		t.Fatalf("expected the master replication info to be parsed")
+	}
+	if masterOffset != 1000 {
+		t.Fatalf("expected master offset 1000, got %d", masterOffset)
+	}
+	offsets := map[string]int64{}
+	for _, replica := range replicas {
+		offsets[replica.IP] = replica.Offset
+	}
+	if len(offsets) != 2 || offsets["10.0.0.2"] != 900 || offsets["10.0.0.3"] != 1000 {
+		t.Fatalf("unexpected replica offsets %v", offsets)
+	}
+
+	if _, _, ok := parseReplicaOffsets("# Replication\r\nrole:slave\r\nmaster_repl_offset:1000\r\n"); ok {
+		t.Fatalf("expected the replication info of a replica to be skipped")
+	}
+}
+
+func TestGetReplicationLagBytes(t *testing.T) {
+	if lag := getReplicationLagBytes(1000, 900); lag != 100 {
+		t.Errorf("expected a lag of 100 bytes, got %d", lag)
+	}
+	if lag := getReplicationLagBytes(900, 1000); lag != 0 {
+		t.Errorf("expected a replica ahead of the read master offset to have no lag, got %d", lag)
+	}
+}
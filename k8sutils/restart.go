@@ -0,0 +1,178 @@
+package k8sutils
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+	"time"
+)
+
+// RedisRestartedAtAnnotation triggers a rolling restart of the redis pods whenever its value changes
+const RedisRestartedAtAnnotation = "rediscluster.redis.opstreelabs.in/restartedAt"
+
+// getRestartRoles returns the roles of the redis statefulsets in the order they are restarted, replicas first
+func getRestartRoles(cr *redisv1beta1.Redis) []string {
+	if cr.Spec.Mode == "cluster" {
+		return []string{"slave", "master"}
+	}
+	return []string{"standalone"}
+}
+
+// getStatefulSetPodRestart returns the restartedAt and secret checksum annotations of the pod template of the
+// statefulset of the role, whether the statefulset exists and whether its rollout is finished
+func getStatefulSetPodRestart(cr *redisv1beta1.Redis, role string) (redisv1beta1.PodRestartStatus, bool, bool) {
+	restart := redisv1beta1.PodRestartStatus{Role: role}
+	statefulSet, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+	if err != nil {
+		return restart, false, false
+	}
+	rolledOut := statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.UpdateRevision == statefulSet.Status.CurrentRevision &&
+		statefulSet.Spec.Replicas != nil && statefulSet.Status.ReadyReplicas == *statefulSet.Spec.Replicas
+	restart.RestartedAt = statefulSet.Spec.Template.Annotations[RedisRestartedAtAnnotation]
+	restart.SecretChecksum = statefulSet.Spec.Template.Annotations[secretChecksumAnnotation]
+	return restart, true, rolledOut
+}
+
+// getWantedPodRestart returns the annotations the pod template of the role is rolled to, the restartedAt annotation
+// of the redis resource and the checksum of the secrets. Removing the restartedAt annotation keeps the pods running.
+func getWantedPodRestart(cr *redisv1beta1.Redis, current redisv1beta1.PodRestartStatus, checksum string) redisv1beta1.PodRestartStatus {
+	wanted := redisv1beta1.PodRestartStatus{Role: current.Role, RestartedAt: cr.Annotations[RedisRestartedAtAnnotation], SecretChecksum: checksum}
+	if wanted.RestartedAt == "" {
+		wanted.RestartedAt = current.RestartedAt
+	}
+	return wanted
+}
+
+// isPodRestartChanged returns whether rolling the pod template to the wanted annotations restarts the pods.
+// Statefulsets created before the secret checksum existed get it without a restart, so that upgrading the operator
+// doesn't restart redis.
+func isPodRestartChanged(current redisv1beta1.PodRestartStatus, wanted redisv1beta1.PodRestartStatus) bool {
+	return current.RestartedAt != wanted.RestartedAt || (current.SecretChecksum != "" && current.SecretChecksum != wanted.SecretChecksum)
+}
+
+// getPendingRestartRole returns the role whose pods are restarted next, once the roles before it are rolled out
+func getPendingRestartRole(cr *redisv1beta1.Redis) (string, bool) {
+	checksum := getSecretsChecksum(cr)
+	for _, role := range getRestartRoles(cr) {
+		current, exists, rolledOut := getStatefulSetPodRestart(cr, role)
+		if !exists {
+			return "", false
+		}
+		if isPodRestartChanged(current, getWantedPodRestart(cr, current, checksum)) {
+			return role, true
+		}
+		if !rolledOut {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// getRestartFailovers returns the pods of the role which are redis masters serving slots, mapped to a healthy replica
+// to fail over to, or to an empty string when the master has no replica
+func getRestartFailovers(ctx context.Context, cr *redisv1beta1.Redis, role string) map[string]string {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return nil
+	}
+	if len(podsByIP) == 0 {
+		return nil
+	}
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	failovers := map[string]string{}
+	for _, master := range nodes {
+		pod, ok := podsByIP[master.IP]
+		if !ok || pod.Labels["role"] != role || !strings.Contains(master.Flags, "master") || len(master.Slots) == 0 {
+			continue
+		}
+		failovers[pod.Name] = ""
+		for _, node := range nodes {
+			if replica, ok := podsByIP[node.IP]; ok && node.MasterID == master.ID && !strings.Contains(node.Flags, "fail") && replica.Labels["role"] != role {
+				failovers[pod.Name] = replica.Name
+				break
+			}
+		}
+	}
+	return failovers
+}
+
+// getPodRestart returns the restartedAt and secret checksum annotations of the pod template of the role, as planned
+// by UpdateRedisPodRestarts
+func getPodRestart(cr *redisv1beta1.Redis, role string) redisv1beta1.PodRestartStatus {
+	for _, restart := range cr.Status.PodRestarts {
+		if restart.Role == role {
+			return restart
+		}
+	}
+	return redisv1beta1.PodRestartStatus{Role: role}
+}
+
+// hasRestartFailovers returns whether masters among the pods of the role still have a replica to fail over to
+func hasRestartFailovers(ctx context.Context, cr *redisv1beta1.Redis, role string) bool {
+	for _, replica := range getRestartFailovers(ctx, cr, role) {
+		if replica != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateRedisPodRestarts will plan the restartedAt and secret checksum annotations of the pod template of every role
+// in the status, which the statefulsets are generated with. A changed restartedAt annotation or rotated credentials
+// restart the pods. In cluster mode the change only reaches a role once the roles before it are rolled out and the
+// masters among its pods are failed over to their replicas, so that every slot keeps being served while the pods
+// restart.
+func UpdateRedisPodRestarts(ctx context.Context, cr *redisv1beta1.Redis) {
+	checksum := getSecretsChecksum(cr)
+	var restarts []redisv1beta1.PodRestartStatus
+	blocked := false
+	for _, role := range getRestartRoles(cr) {
+		current, exists, rolledOut := getStatefulSetPodRestart(cr, role)
+		wanted := getWantedPodRestart(cr, current, checksum)
+		planned := wanted
+		switch {
+		case !exists:
+			blocked = true
+		case !isPodRestartChanged(current, wanted):
+			blocked = blocked || !rolledOut
+		case cr.Spec.Mode != "cluster":
+		default:
+			if blocked || hasRestartFailovers(ctx, cr, role) {
+				planned = current
+			}
+			blocked = true
+		}
+		if planned.RestartedAt != "" || planned.SecretChecksum != "" {
+			restarts = append(restarts, planned)
+		}
+	}
+	cr.Status.PodRestarts = restarts
+}
+
+// FailoverRedisMastersForRestart will fail over the masters among the pods restarted next to their replicas, so that
+// the rolling restart only restarts redis replicas. Masters without a replica are restarted as they are.
+func FailoverRedisMastersForRestart(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	// the restart itself waits for the maintenance window
+	if !IsMaintenanceWindowOpen(cr, time.Now()) {
+		return
+	}
+	role, ok := getPendingRestartRole(cr)
+	if !ok {
+		return
+	}
+	for master, replica := range getRestartFailovers(ctx, cr, role) {
+		if replica == "" {
+			reqLogger.Info("Redis master has no healthy replica to fail over to, restarting it as it is", "Pod.Name", master)
+			continue
+		}
+		reqLogger.Info("Failing over redis master before restarting it", "Pod.Name", master, "Replica", replica)
+		if err := runClusterCommand(ctx, cr, replica, "failover"); err != nil {
+			reqLogger.Error(err, "Redis failover before restart failed", "Pod.Name", master)
+		}
+	}
+}
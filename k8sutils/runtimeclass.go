@@ -0,0 +1,45 @@
+package k8sutils
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sync/atomic"
+)
+
+// runtimeClassLookupForbidden is set once the operator is denied reading runtime classes, so that the check is
+// skipped instead of failing on every reconcile
+var runtimeClassLookupForbidden int32
+
+// getRuntimeClassName returns the runtime class of the redis pods of the role, like gVisor or Kata sandboxes
+func getRuntimeClassName(cr *redisv1beta1.Redis, role string) *string {
+	if role == "master" && cr.Spec.Master.RuntimeClassName != nil {
+		return cr.Spec.Master.RuntimeClassName
+	}
+	if role == "slave" && cr.Spec.Slave.RuntimeClassName != nil {
+		return cr.Spec.Slave.RuntimeClassName
+	}
+	return cr.Spec.GlobalConfig.RuntimeClassName
+}
+
+// checkRuntimeClass will warn when the runtime class of the statefulset doesn't exist, since its pods are rejected
+// until it is created. Runtime classes are cluster-scoped, so the check is skipped when the operator can't read them.
+func checkRuntimeClass(cr *redisv1beta1.Redis, statefulset *appsv1.StatefulSet) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	runtimeClassName := statefulset.Spec.Template.Spec.RuntimeClassName
+	if runtimeClassName == nil || atomic.LoadInt32(&runtimeClassLookupForbidden) == 1 {
+		return
+	}
+	_, err := GenerateK8sClient().NodeV1beta1().RuntimeClasses().Get(context.TODO(), *runtimeClassName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		reqLogger.Info("Runtime class of the redis pods doesn't exist, pods will be rejected", "StatefulSet.Name", statefulset.Name, "RuntimeClass", *runtimeClassName)
+	} else if errors.IsForbidden(err) {
+		if atomic.CompareAndSwapInt32(&runtimeClassLookupForbidden, 0, 1) {
+			reqLogger.Info("Operator is not allowed to read runtime classes, skipping the runtime class check", "Reason", err.Error())
+		}
+	} else if err != nil {
+		reqLogger.Info("Could not get runtime class, skipping the runtime class check", "Reason", err.Error())
+	}
+}
@@ -2,7 +2,13 @@ package k8sutils
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	redisv1beta1 "redis-operator/api/v1beta1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -10,15 +16,22 @@ import (
 
 var log = logf.Log.WithName("controller_redis")
 
+const (
+	generatedPasswordKey    = "password"
+	generatedPasswordLength = 32
+	// secretChecksumAnnotation rolls the redis pods when a credential they read from a secret changes
+	secretChecksumAnnotation = "redis.opstreelabs.in/secret-checksum"
+)
+
 // GenerateSecret is a method that will generate a secret interface
 func GenerateSecret(cr *redisv1beta1.Redis) *corev1.Secret {
 	password := []byte(*cr.Spec.GlobalConfig.Password)
 	labels := map[string]string{
-		"app": cr.ObjectMeta.Name,
+		"app": GetRedisName(cr),
 	}
 	secret := &corev1.Secret{
 		TypeMeta:   GenerateMetaInformation("Secret", "v1"),
-		ObjectMeta: GenerateObjectMetaInformation(cr.ObjectMeta.Name, cr.Namespace, labels, GenerateSecretAnots()),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr), labels, GenerateSecretAnots()),
 		Data: map[string][]byte{
 			"password": password,
 		},
@@ -31,35 +44,234 @@ func GenerateSecret(cr *redisv1beta1.Redis) *corev1.Secret {
 func CreateRedisSecret(cr *redisv1beta1.Redis) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 	secretBody := GenerateSecret(cr)
-	secretName, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name, metav1.GetOptions{})
+	secretName, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), GetRedisName(cr), metav1.GetOptions{})
 	if err != nil {
-		reqLogger.Info("Creating secret for redis", "Secret.Name", cr.ObjectMeta.Name)
+		reqLogger.Info("Creating secret for redis", "Secret.Name", GetRedisName(cr))
 		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Create(context.TODO(), secretBody, metav1.CreateOptions{})
 		if err != nil {
 			reqLogger.Error(err, "Failed in creating secret for redis")
 		}
 	} else if secretBody != secretName {
-		reqLogger.Info("Reconciling secret for redis", "Secret.Name", cr.ObjectMeta.Name)
+		reqLogger.Info("Reconciling secret for redis", "Secret.Name", GetRedisName(cr))
 		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Update(context.TODO(), secretBody, metav1.UpdateOptions{})
 		if err != nil {
 			reqLogger.Error(err, "Failed in updating secret for redis")
 		}
 	} else {
-		reqLogger.Info("Secret for redis are in sync", "Secret.Name", cr.ObjectMeta.Name)
+		reqLogger.Info("Secret for redis are in sync", "Secret.Name", GetRedisName(cr))
 	}
 }
 
+// isRedisPasswordSet returns whether redis requires a password
+func isRedisPasswordSet(cr *redisv1beta1.Redis) bool {
+	return cr.Spec.GlobalConfig.Password != nil || isRedisPasswordInSecret(cr)
+}
+
+// isRedisPasswordInSecret returns whether the redis password is read from an existing or a generated secret, rather
+// than from the spec
+func isRedisPasswordInSecret(cr *redisv1beta1.Redis) bool {
+	return cr.Spec.GlobalConfig.ExistingPasswordSecret != nil || cr.Spec.GlobalConfig.GeneratePassword
+}
+
 // getRedisPassword method will return the redis password
 func getRedisPassword(cr *redisv1beta1.Redis) string {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
-	secretName, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), *cr.Spec.GlobalConfig.ExistingPasswordSecret.Name, metav1.GetOptions{})
+	selector := getRedisPasswordSecretKeySelector(cr)
+	secretName, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), selector.Name, metav1.GetOptions{})
 	if err != nil {
 		reqLogger.Error(err, "Failed in getting existing secret for redis")
+		return ""
+	}
+	return string(secretName.Data[selector.Key])
+}
+
+// getRedisPasswordSecretKeySelector returns the reference to the secret key holding the redis password. The generated
+// password secret is created when it doesn't exist yet.
+func getRedisPasswordSecretKeySelector(cr *redisv1beta1.Redis) *corev1.SecretKeySelector {
+	if cr.Spec.GlobalConfig.GeneratePassword {
+		reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+		if err := ensureGeneratedPasswordSecret(cr); err != nil {
+			reqLogger.Error(err, "Failed in generating the password secret for redis")
+		}
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: getGeneratedPasswordSecretName(cr),
+			},
+			Key: generatedPasswordKey,
+		}
+	}
+	if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
+		return &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: *cr.Spec.GlobalConfig.ExistingPasswordSecret.Name,
+			},
+			Key: *cr.Spec.GlobalConfig.ExistingPasswordSecret.Key,
+		}
+	}
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: GetRedisName(cr),
+		},
+		Key: "password",
 	}
-	for key, value := range secretName.Data {
-		if key == *cr.Spec.GlobalConfig.ExistingPasswordSecret.Key {
-			return string(value)
+}
+
+// getExporterPasswordVolume returns the volume mounting the password file of the redis exporter
+func getExporterPasswordVolume(cr *redisv1beta1.Redis) corev1.Volume {
+	return corev1.Volume{
+		Name: "exporter-password",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: GetRedisName(cr) + "-exporter-password",
+			},
+		},
+	}
+}
+
+// GenerateExporterPasswordSecret generates the secret holding the password file of the redis exporter, which maps
+// the redis address to its password
+func GenerateExporterPasswordSecret(cr *redisv1beta1.Redis) (*corev1.Secret, error) {
+	password := ""
+	if isRedisPasswordInSecret(cr) {
+		password = getRedisPassword(cr)
+	} else if cr.Spec.GlobalConfig.Password != nil {
+		password = *cr.Spec.GlobalConfig.Password
+	}
+	passwordFile, err := json.Marshal(map[string]string{exporterRedisAddr: password})
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   GenerateMetaInformation("Secret", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-exporter-password", labels, GenerateSecretAnots()),
+		Data: map[string][]byte{
+			exporterPasswordFileKey: passwordFile,
+		},
+	}
+	AddOwnerRefToObject(secret, AsOwner(cr))
+	return secret, nil
+}
+
+// CreateRedisExporterPasswordSecret will create or update the secret holding the password file of the redis exporter
+func CreateRedisExporterPasswordSecret(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	secretBody, err := GenerateExporterPasswordSecret(cr)
+	if err != nil {
+		reqLogger.Error(err, "Failed in generating exporter password secret for redis")
+		return
+	}
+	existingSecret, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), secretBody.Name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating exporter password secret for redis", "Secret.Name", secretBody.Name)
+		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Create(context.TODO(), secretBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating exporter password secret for redis")
+		}
+	} else if mergeObjectMeta(&existingSecret.ObjectMeta, secretBody.ObjectMeta) || !apiequality.Semantic.DeepEqual(existingSecret.Data, secretBody.Data) {
+		reqLogger.Info("Reconciling exporter password secret for redis", "Secret.Name", secretBody.Name)
+		existingSecret.Data = secretBody.Data
+		_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Update(context.TODO(), existingSecret, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating exporter password secret for redis")
+		}
+	}
+}
+
+// getGeneratedPasswordSecretName returns the name of the secret holding the password generated by the operator
+func getGeneratedPasswordSecretName(cr *redisv1beta1.Redis) string {
+	return GetRedisName(cr) + "-generated-password"
+}
+
+// generateRandomPassword returns a hex encoded password read from the cryptographic random source
+func generateRandomPassword() (string, error) {
+	password := make([]byte, generatedPasswordLength)
+	if _, err := rand.Read(password); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(password), nil
+}
+
+// createGeneratedPasswordSecret will create the secret holding a newly generated redis password
+func createGeneratedPasswordSecret(cr *redisv1beta1.Redis) error {
+	password, err := generateRandomPassword()
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   GenerateMetaInformation("Secret", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, getGeneratedPasswordSecretName(cr), labels, GenerateSecretAnots()),
+		Data: map[string][]byte{
+			generatedPasswordKey: []byte(password),
+		},
+	}
+	AddOwnerRefToObject(secret, AsOwner(cr))
+	_, err = GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// ensureGeneratedPasswordSecret will create the secret holding a generated redis password unless it exists. The
+// password is never regenerated, so it survives operator restarts and only goes away with the redis resource owning
+// the secret.
+func ensureGeneratedPasswordSecret(cr *redisv1beta1.Redis) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	secretName := getGeneratedPasswordSecretName(cr)
+	_, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		reqLogger.Info("Creating secret with a generated password for redis", "Secret.Name", secretName)
+		return createGeneratedPasswordSecret(cr)
+	}
+	return err
+}
+
+// getReferencedSecretKeys returns the secret keys the redis pods read credentials from
+func getReferencedSecretKeys(cr *redisv1beta1.Redis) []*corev1.SecretKeySelector {
+	var keys []*corev1.SecretKeySelector
+	if isRedisPasswordSet(cr) {
+		keys = append(keys, getRedisPasswordSecretKeySelector(cr))
+	}
+	if cr.Spec.ReplicaOf != nil && cr.Spec.ReplicaOf.PasswordSecret != nil {
+		keys = append(keys, &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: *cr.Spec.ReplicaOf.PasswordSecret.Name},
+			Key:                  *cr.Spec.ReplicaOf.PasswordSecret.Key,
+		})
+	}
+	return keys
+}
+
+// GetReferencedSecretNames returns the names of the secrets the redis pods read credentials from
+func GetReferencedSecretNames(cr *redisv1beta1.Redis) []string {
+	var names []string
+	for _, key := range getReferencedSecretKeys(cr) {
+		names = append(names, key.Name)
+	}
+	return names
+}
+
+// getSecretsChecksum returns the checksum of the credentials the redis pods read from secrets, or an empty string
+// when they don't read any. Missing secrets are left out, the pods can't start without them anyway.
+func getSecretsChecksum(cr *redisv1beta1.Redis) string {
+	keys := getReferencedSecretKeys(cr)
+	if len(keys) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, key := range keys {
+		secret, err := GenerateK8sClient().CoreV1().Secrets(cr.Namespace).Get(context.TODO(), key.Name, metav1.GetOptions{})
+		if err != nil {
+			continue
 		}
+		hash.Write([]byte(key.Name + "/" + key.Key + "="))
+		hash.Write(secret.Data[key.Key])
+		hash.Write([]byte{0})
 	}
-	return ""
+	return hex.EncodeToString(hash.Sum(nil))
 }
@@ -0,0 +1,189 @@
+package k8sutils
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+// getServiceAccountName returns the service account name for the redis role
+func getServiceAccountName(cr *redisv1beta1.Redis, role string) string {
+	if role == "master" && cr.Spec.Master.ServiceAccountName != nil {
+		return *cr.Spec.Master.ServiceAccountName
+	}
+	if role == "slave" && cr.Spec.Slave.ServiceAccountName != nil {
+		return *cr.Spec.Slave.ServiceAccountName
+	}
+	return getGlobalServiceAccountName(cr)
+}
+
+// getGlobalServiceAccountName returns the service account name shared by all redis roles
+func getGlobalServiceAccountName(cr *redisv1beta1.Redis) string {
+	if cr.Spec.GlobalConfig.ServiceAccountName != nil {
+		return *cr.Spec.GlobalConfig.ServiceAccountName
+	}
+	if cr.Spec.GlobalConfig.CreateServiceAccount {
+		return GetRedisName(cr)
+	}
+	return ""
+}
+
+// getRedisServiceAccountNames returns the distinct service accounts of the redis roles
+func getRedisServiceAccountNames(cr *redisv1beta1.Redis) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, role := range getRedisRoles(cr) {
+		name := getServiceAccountName(cr, role)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isGlobalServiceAccountUsed reports whether a redis role runs with the global service account, which is not created
+// when every role overrides it
+func isGlobalServiceAccountUsed(cr *redisv1beta1.Redis) bool {
+	for _, name := range getRedisServiceAccountNames(cr) {
+		if name == getGlobalServiceAccountName(cr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkServiceAccount will check that the service account used by redis pods exists
+func checkServiceAccount(cr *redisv1beta1.Redis, serviceAccountName string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if serviceAccountName == "" {
+		return
+	}
+	_, err := GenerateK8sClient().CoreV1().ServiceAccounts(cr.Namespace).Get(context.TODO(), serviceAccountName, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Service account for redis is not present, pods will not be created until it exists", "ServiceAccount.Name", serviceAccountName)
+	}
+}
+
+// GenerateServiceAccount generates the service account definition for redis pods
+func GenerateServiceAccount(cr *redisv1beta1.Redis) *corev1.ServiceAccount {
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta:   GenerateMetaInformation("ServiceAccount", "v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, getGlobalServiceAccountName(cr), labels, GenerateSecretAnots()),
+	}
+	AddOwnerRefToObject(serviceAccount, AsOwner(cr))
+	return serviceAccount
+}
+
+// GenerateRole generates the role with the in-cluster permissions needed by redis pods
+func GenerateRole(cr *redisv1beta1.Redis) *rbacv1.Role {
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	role := &rbacv1.Role{
+		TypeMeta:   GenerateMetaInformation("Role", "rbac.authorization.k8s.io/v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, getGlobalServiceAccountName(cr), labels, GenerateSecretAnots()),
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "endpoints"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+	}
+	AddOwnerRefToObject(role, AsOwner(cr))
+	return role
+}
+
+// GenerateRoleBinding generates the role binding for the service accounts of the redis roles
+func GenerateRoleBinding(cr *redisv1beta1.Redis) *rbacv1.RoleBinding {
+	labels := map[string]string{
+		"app": GetRedisName(cr),
+	}
+	name := getGlobalServiceAccountName(cr)
+	var subjects []rbacv1.Subject
+	for _, serviceAccountName := range getRedisServiceAccountNames(cr) {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:      "ServiceAccount",
+			Name:      serviceAccountName,
+			Namespace: cr.Namespace,
+		})
+	}
+	roleBinding := &rbacv1.RoleBinding{
+		TypeMeta:   GenerateMetaInformation("RoleBinding", "rbac.authorization.k8s.io/v1"),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, name, labels, GenerateSecretAnots()),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: subjects,
+	}
+	AddOwnerRefToObject(roleBinding, AsOwner(cr))
+	return roleBinding
+}
+
+// CreateRedisServiceAccount will create the service account, role and role binding for redis pods. The service account
+// is only created when a role uses it, the role binding binds the service accounts of all roles.
+func CreateRedisServiceAccount(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	name := getGlobalServiceAccountName(cr)
+
+	serviceAccountBody := GenerateServiceAccount(cr)
+	existingServiceAccount, err := GenerateK8sClient().CoreV1().ServiceAccounts(cr.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if !isGlobalServiceAccountUsed(cr) {
+		reqLogger.Info("Service account for redis is overridden by every role, not creating it", "ServiceAccount.Name", name)
+	} else if err != nil {
+		reqLogger.Info("Creating service account for redis", "ServiceAccount.Name", name)
+		_, err := GenerateK8sClient().CoreV1().ServiceAccounts(cr.Namespace).Create(context.TODO(), serviceAccountBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating service account for redis")
+		}
+	} else if mergeObjectMeta(&existingServiceAccount.ObjectMeta, serviceAccountBody.ObjectMeta) {
+		reqLogger.Info("Reconciling service account for redis", "ServiceAccount.Name", name)
+		_, err := GenerateK8sClient().CoreV1().ServiceAccounts(cr.Namespace).Update(context.TODO(), existingServiceAccount, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating service account for redis")
+		}
+	}
+
+	roleBody := GenerateRole(cr)
+	existingRole, err := GenerateK8sClient().RbacV1().Roles(cr.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating role for redis", "Role.Name", name)
+		_, err := GenerateK8sClient().RbacV1().Roles(cr.Namespace).Create(context.TODO(), roleBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating role for redis")
+		}
+	} else if mergeObjectMeta(&existingRole.ObjectMeta, roleBody.ObjectMeta) || !apiequality.Semantic.DeepEqual(existingRole.Rules, roleBody.Rules) {
+		reqLogger.Info("Reconciling role for redis", "Role.Name", name)
+		existingRole.Rules = roleBody.Rules
+		_, err := GenerateK8sClient().RbacV1().Roles(cr.Namespace).Update(context.TODO(), existingRole, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating role for redis")
+		}
+	}
+
+	roleBindingBody := GenerateRoleBinding(cr)
+	existingRoleBinding, err := GenerateK8sClient().RbacV1().RoleBindings(cr.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		reqLogger.Info("Creating role binding for redis", "RoleBinding.Name", name)
+		_, err := GenerateK8sClient().RbacV1().RoleBindings(cr.Namespace).Create(context.TODO(), roleBindingBody, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating role binding for redis")
+		}
+	} else if mergeObjectMeta(&existingRoleBinding.ObjectMeta, roleBindingBody.ObjectMeta) || !apiequality.Semantic.DeepEqual(existingRoleBinding.Subjects, roleBindingBody.Subjects) {
+		reqLogger.Info("Reconciling role binding for redis", "RoleBinding.Name", name)
+		existingRoleBinding.Subjects = roleBindingBody.Subjects
+		_, err := GenerateK8sClient().RbacV1().RoleBindings(cr.Namespace).Update(context.TODO(), existingRoleBinding, metav1.UpdateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in updating role binding for redis")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package k8sutils
+
+import (
+	"testing"
+)
+
+func TestGenerateRoleBindingBindsRoleServiceAccounts(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.GlobalConfig.CreateServiceAccount = true
+	master := "redis-master"
+	cr.Spec.Master.ServiceAccountName = &master
+
+	subjects := GenerateRoleBinding(cr).Subjects
+	if len(subjects) != 2 || subjects[0].Name != "redis-master" || subjects[1].Name != "redis" {
+		t.Errorf("expected the master and the global service account, got %v", subjects)
+	}
+	if !isGlobalServiceAccountUsed(cr) {
+		t.Errorf("expected the slaves to use the global service account")
+	}
+
+	slave := "redis-slave"
+	cr.Spec.Slave.ServiceAccountName = &slave
+	subjects = GenerateRoleBinding(cr).Subjects
+	if len(subjects) != 2 || subjects[0].Name != "redis-master" || subjects[1].Name != "redis-slave" {
+		t.Errorf("expected the master and slave service accounts, got %v", subjects)
+	}
+	if isGlobalServiceAccountUsed(cr) {
+		t.Errorf("expected the global service account to be unused")
+	}
+}
@@ -3,13 +3,22 @@ package k8sutils
 import (
 	"context"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	redisv1beta1 "redis-operator/api/v1beta1"
 )
 
 const (
-	redisPort = 6379
+	redisPort         = 6379
+	redisExporterPort = redisv1beta1.DefaultRedisExporterPort
+	// redisClusterBusPort is the port redis cluster nodes use to talk to each other, the redis port plus 10000
+	redisClusterBusPort = redisPort + 10000
+	// redisClientPortName, redisBusPortName and redisMetricsPortName are the names of the redis client port, the
+	// redis cluster bus port and the exporter port on the services and the containers
+	redisClientPortName  = "redis-client"
+	redisBusPortName     = "redis-bus"
+	redisMetricsPortName = "metrics"
 )
 
 // ServiceInterface is the interface to pass service information accross methods
@@ -19,18 +28,71 @@ type ServiceInterface struct {
 	ServiceType          string
 }
 
+// getRedisExporterPort returns the port the redis exporter listens on, the default one when the defaulting webhook
+// didn't set it
+func getRedisExporterPort(cr *redisv1beta1.Redis) int {
+	if cr.Spec.RedisExporter == nil || cr.Spec.RedisExporter.Port == nil {
+		return redisExporterPort
+	}
+	return int(*cr.Spec.RedisExporter.Port)
+}
+
+// getHeadlessServiceName returns the name of the headless service for the redis role
+func getHeadlessServiceName(cr *redisv1beta1.Redis, role string) string {
+	if role == "standalone" {
+		return GetRedisName(cr) + "-headless"
+	}
+	return GetRedisName(cr) + "-" + role + "-headless"
+}
+
+// GetLegacyServiceRoles returns the roles whose statefulset was created by older operator versions, which governed
+// the pods with the regular service instead of the headless one. These statefulsets are recreated with the headless
+// service while their pods keep running, the pods pick up their headless DNS names once they restart.
+func GetLegacyServiceRoles(cr *redisv1beta1.Redis) []string {
+	var roles []string
+	for _, role := range getRedisRoles(cr) {
+		statefulSet, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+		if err == nil && statefulSet.Spec.ServiceName != getHeadlessServiceName(cr, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// getServicePortName returns the name of the client port on the services of the role, which service meshes like
+// Istio use to detect the protocol
+func getServicePortName(cr *redisv1beta1.Redis, role string) string {
+	var service redisv1beta1.Service
+	switch role {
+	case "master":
+		service = cr.Spec.Master.Service
+	case "slave":
+		service = cr.Spec.Slave.Service
+	case "proxy":
+		service = cr.Spec.Proxy.Service
+	case "write":
+		service = cr.Spec.WriteService.Service
+	default:
+		service = cr.Spec.Service
+	}
+	if service.PortName != "" {
+		return service.PortName
+	}
+	return redisClientPortName
+}
+
 // GenerateHeadlessServiceDef generate service definition
 func GenerateHeadlessServiceDef(cr *redisv1beta1.Redis, labels map[string]string, portNumber int32, role string, serviceName string, clusterIP string) *corev1.Service {
-	var redisExporterPort int32 = 9121
 	service := &corev1.Service{
 		TypeMeta:   GenerateMetaInformation("Service", "core/v1"),
-		ObjectMeta: GenerateObjectMetaInformation(serviceName, cr.Namespace, labels, GenerateServiceAnots()),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, serviceName, labels, GenerateServiceAnots(cr)),
 		Spec: corev1.ServiceSpec{
-			ClusterIP: clusterIP,
-			Selector:  labels,
+			ClusterIP:                clusterIP,
+			Selector:                 labels,
+			PublishNotReadyAddresses: cr.Spec.DNSWait != nil && cr.Spec.DNSWait.Enabled,
 			Ports: []corev1.ServicePort{
 				{
-					Name:       cr.ObjectMeta.Name + "-" + role,
+					Name:       getServicePortName(cr, role),
 					Port:       portNumber,
 					TargetPort: intstr.FromInt(int(portNumber)),
 					Protocol:   corev1.ProtocolTCP,
@@ -38,11 +100,19 @@ func GenerateHeadlessServiceDef(cr *redisv1beta1.Redis, labels map[string]string
 			},
 		},
 	}
-	if !cr.Spec.RedisExporter.Enabled {
+	if cr.Spec.Mode == "cluster" {
 		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
-			Name:       "redis-exporter",
-			Port:       redisExporterPort,
-			TargetPort: intstr.FromInt(int(redisExporterPort)),
+			Name:       redisBusPortName,
+			Port:       int32(redisClusterBusPort),
+			TargetPort: intstr.FromInt(redisClusterBusPort),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.Enabled {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       redisMetricsPortName,
+			Port:       int32(getRedisExporterPort(cr)),
+			TargetPort: intstr.FromInt(getRedisExporterPort(cr)),
 			Protocol:   corev1.ProtocolTCP,
 		})
 	}
@@ -52,7 +122,6 @@ func GenerateHeadlessServiceDef(cr *redisv1beta1.Redis, labels map[string]string
 
 // GenerateServiceDef generate service definition
 func GenerateServiceDef(cr *redisv1beta1.Redis, labels map[string]string, portNumber int32, role string, serviceName string, typeService string) *corev1.Service {
-	var redisExporterPort int32 = 9121
 	var serviceType corev1.ServiceType
 
 	if typeService == "LoadBalancer" {
@@ -65,13 +134,13 @@ func GenerateServiceDef(cr *redisv1beta1.Redis, labels map[string]string, portNu
 
 	service := &corev1.Service{
 		TypeMeta:   GenerateMetaInformation("Service", "core/v1"),
-		ObjectMeta: GenerateObjectMetaInformation(serviceName, cr.Namespace, labels, GenerateServiceAnots()),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, serviceName, labels, GenerateServiceAnots(cr)),
 		Spec: corev1.ServiceSpec{
 			Type:     serviceType,
 			Selector: labels,
 			Ports: []corev1.ServicePort{
 				{
-					Name:       cr.ObjectMeta.Name + "-" + role,
+					Name:       getServicePortName(cr, role),
 					Port:       portNumber,
 					TargetPort: intstr.FromInt(int(portNumber)),
 					Protocol:   corev1.ProtocolTCP,
@@ -79,11 +148,11 @@ func GenerateServiceDef(cr *redisv1beta1.Redis, labels map[string]string, portNu
 			},
 		},
 	}
-	if cr.Spec.RedisExporter.Enabled {
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.Enabled {
 		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
-			Name:       "redis-exporter",
-			Port:       redisExporterPort,
-			TargetPort: intstr.FromInt(int(redisExporterPort)),
+			Name:       redisMetricsPortName,
+			Port:       int32(getRedisExporterPort(cr)),
+			TargetPort: intstr.FromInt(getRedisExporterPort(cr)),
 			Protocol:   corev1.ProtocolTCP,
 		})
 	}
@@ -94,11 +163,11 @@ func GenerateServiceDef(cr *redisv1beta1.Redis, labels map[string]string, portNu
 // CreateMasterHeadlessService creates master headless service
 func CreateMasterHeadlessService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-master",
+		"app":  GetRedisName(cr) + "-master",
 		"role": "master",
 	}
-	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "master", cr.ObjectMeta.Name+"-master-headless", "None")
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-master-headless", metav1.GetOptions{})
+	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "master", GetRedisName(cr)+"-master-headless", "None")
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-master-headless", metav1.GetOptions{})
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
 		NewServiceDefinition: serviceDefinition,
@@ -110,11 +179,13 @@ func CreateMasterHeadlessService(cr *redisv1beta1.Redis) {
 // CreateMasterService creates different services for master
 func CreateMasterService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-master",
+		"app":  GetRedisName(cr) + "-master",
 		"role": "master",
 	}
-	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "master", cr.ObjectMeta.Name+"-master", cr.Spec.Master.Service.Type)
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-master", metav1.GetOptions{})
+	LabelServingRedisPods(cr, "master")
+	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "master", GetRedisName(cr)+"-master", cr.Spec.Master.Service.Type)
+	serviceDefinition.Spec.Selector = getClientServiceSelector(cr, labels)
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-master", metav1.GetOptions{})
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
 		NewServiceDefinition: serviceDefinition,
@@ -126,11 +197,11 @@ func CreateMasterService(cr *redisv1beta1.Redis) {
 // CreateSlaveHeadlessService creates slave headless service
 func CreateSlaveHeadlessService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-slave",
+		"app":  GetRedisName(cr) + "-slave",
 		"role": "slave",
 	}
-	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "slave", cr.ObjectMeta.Name+"-slave-headless", "None")
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-slave-headless", metav1.GetOptions{})
+	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "slave", GetRedisName(cr)+"-slave-headless", "None")
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-slave-headless", metav1.GetOptions{})
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
 		NewServiceDefinition: serviceDefinition,
@@ -142,11 +213,13 @@ func CreateSlaveHeadlessService(cr *redisv1beta1.Redis) {
 // CreateSlaveService creates different services for slave
 func CreateSlaveService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-slave",
+		"app":  GetRedisName(cr) + "-slave",
 		"role": "slave",
 	}
-	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "slave", cr.ObjectMeta.Name+"-slave", cr.Spec.Slave.Service.Type)
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-slave", metav1.GetOptions{})
+	LabelServingRedisPods(cr, "slave")
+	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "slave", GetRedisName(cr)+"-slave", cr.Spec.Slave.Service.Type)
+	serviceDefinition.Spec.Selector = getClientServiceSelector(cr, labels)
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-slave", metav1.GetOptions{})
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
 		NewServiceDefinition: serviceDefinition,
@@ -158,11 +231,11 @@ func CreateSlaveService(cr *redisv1beta1.Redis) {
 // CreateStandaloneService creates redis standalone service
 func CreateStandaloneService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-" + "standalone",
+		"app":  GetRedisName(cr) + "-" + "standalone",
 		"role": "standalone",
 	}
-	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "standalone", cr.ObjectMeta.Name, cr.Spec.Service.Type)
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name, metav1.GetOptions{})
+	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "standalone", GetRedisName(cr), cr.Spec.Service.Type)
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr), metav1.GetOptions{})
 
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
@@ -175,11 +248,11 @@ func CreateStandaloneService(cr *redisv1beta1.Redis) {
 // CreateStandaloneHeadlessService creates redis standalone service
 func CreateStandaloneHeadlessService(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-" + "standalone",
+		"app":  GetRedisName(cr) + "-" + "standalone",
 		"role": "standalone",
 	}
-	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "standalone", cr.ObjectMeta.Name+"-headless", "None")
-	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-headless", metav1.GetOptions{})
+	serviceDefinition := GenerateHeadlessServiceDef(cr, labels, int32(redisPort), "standalone", GetRedisName(cr)+"-headless", "None")
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-headless", metav1.GetOptions{})
 
 	service := ServiceInterface{
 		ExistingService:      serviceBody,
@@ -194,7 +267,7 @@ func CompareAndCreateService(cr *redisv1beta1.Redis, service ServiceInterface, e
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 
 	if err != nil {
-		reqLogger.Info("Creating redis service", "Redis.Name", cr.ObjectMeta.Name+"-"+service.ServiceType, "Service.Type", service.ServiceType)
+		reqLogger.Info("Creating redis service", "Redis.Name", GetRedisName(cr)+"-"+service.ServiceType, "Service.Type", service.ServiceType)
 		_, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Create(context.TODO(), service.NewServiceDefinition, metav1.CreateOptions{})
 		if err != nil {
 			reqLogger.Error(err, "Failed in creating service for redis")
@@ -202,11 +275,15 @@ func CompareAndCreateService(cr *redisv1beta1.Redis, service ServiceInterface, e
 	}
 
 	if service.ExistingService != nil {
-		if service.ExistingService.Spec.Type != service.NewServiceDefinition.Spec.Type {
+		metaChanged := mergeObjectMeta(&service.ExistingService.ObjectMeta, service.NewServiceDefinition.ObjectMeta)
+		portsChanged := mergeServicePorts(service.ExistingService, service.NewServiceDefinition)
+		selectorChanged := !apiequality.Semantic.DeepEqual(service.ExistingService.Spec.Selector, service.NewServiceDefinition.Spec.Selector)
+		if service.ExistingService.Spec.Type != service.NewServiceDefinition.Spec.Type || metaChanged || portsChanged || selectorChanged {
 			existingService := service.ExistingService
 			existingService.Spec.Type = service.NewServiceDefinition.Spec.Type
+			existingService.Spec.Selector = service.NewServiceDefinition.Spec.Selector
 			if existingService.ObjectMeta.Name != "" && existingService != nil {
-				reqLogger.Info("Service type has been updated for the service", "Redis.Name", cr.ObjectMeta.Name+"-"+service.ServiceType, "Service.Type", service.ServiceType)
+				reqLogger.Info("Service has been updated", "Redis.Name", GetRedisName(cr)+"-"+service.ServiceType, "Service.Type", service.ServiceType)
 				_, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Update(context.TODO(), existingService, metav1.UpdateOptions{})
 				if err != nil {
 					reqLogger.Error(err, "Failed in updating service for redis")
@@ -221,10 +298,47 @@ func CompareAndCreateHeadlessService(cr *redisv1beta1.Redis, service ServiceInte
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
 
 	if err != nil {
-		reqLogger.Info("Creating redis service", "Redis.Name", cr.ObjectMeta.Name+"-"+service.ServiceType, "Service.Type", service.ServiceType)
+		reqLogger.Info("Creating redis service", "Redis.Name", GetRedisName(cr)+"-"+service.ServiceType, "Service.Type", service.ServiceType)
 		_, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Create(context.TODO(), service.NewServiceDefinition, metav1.CreateOptions{})
 		if err != nil {
 			reqLogger.Error(err, "Failed in creating service for redis")
 		}
 	}
+
+	if service.ExistingService != nil && service.ExistingService.ObjectMeta.Name != "" {
+		metaChanged := mergeObjectMeta(&service.ExistingService.ObjectMeta, service.NewServiceDefinition.ObjectMeta)
+		portsChanged := mergeServicePorts(service.ExistingService, service.NewServiceDefinition)
+		if service.ExistingService.Spec.PublishNotReadyAddresses != service.NewServiceDefinition.Spec.PublishNotReadyAddresses || metaChanged || portsChanged {
+			existingService := service.ExistingService
+			existingService.Spec.PublishNotReadyAddresses = service.NewServiceDefinition.Spec.PublishNotReadyAddresses
+			reqLogger.Info("Headless service has been updated", "Redis.Name", GetRedisName(cr)+"-"+service.ServiceType, "Service.Type", service.ServiceType)
+			_, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Update(context.TODO(), existingService, metav1.UpdateOptions{})
+			if err != nil {
+				reqLogger.Error(err, "Failed in updating service for redis")
+			}
+		}
+	}
+}
+
+// mergeServicePorts sets the ports of the desired service on the existing service when their names or numbers
+// differ, keeping the node ports already allocated to the existing ports. It reports whether the ports changed.
+func mergeServicePorts(existing *corev1.Service, desired *corev1.Service) bool {
+	changed := len(existing.Spec.Ports) != len(desired.Spec.Ports)
+	for i := 0; !changed && i < len(desired.Spec.Ports); i++ {
+		changed = existing.Spec.Ports[i].Name != desired.Spec.Ports[i].Name || existing.Spec.Ports[i].Port != desired.Spec.Ports[i].Port
+	}
+	if !changed {
+		return false
+	}
+	nodePorts := map[int32]int32{}
+	for _, port := range existing.Spec.Ports {
+		nodePorts[port.Port] = port.NodePort
+	}
+	ports := make([]corev1.ServicePort, len(desired.Spec.Ports))
+	for i, port := range desired.Spec.Ports {
+		port.NodePort = nodePorts[port.Port]
+		ports[i] = port
+	}
+	existing.Spec.Ports = ports
+	return true
 }
@@ -0,0 +1,326 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The phases of a shard removal
+const (
+	ShardRemovalMigratingSlots      = "MigratingSlots"
+	ShardRemovalReassigningReplicas = "ReassigningReplicas"
+	ShardRemovalForgettingNodes     = "ForgettingNodes"
+)
+
+// GetMasterReplicas returns the number of redis master pods, which stays at the count before the size was decreased
+// while the removed shards are drained
+func GetMasterReplicas(cr *redisv1beta1.Redis) int32 {
+	if removal := cr.Status.ShardRemoval; removal != nil && removal.Masters > *cr.Spec.Size {
+		return removal.Masters
+	}
+	return *cr.Spec.Size
+}
+
+// GetSlaveReplicas returns the number of redis slave pods, which stays at the count before the size was decreased
+// while the removed shards are drained
+func GetSlaveReplicas(cr *redisv1beta1.Redis) int32 {
+	if removal := cr.Status.ShardRemoval; removal != nil && removal.Slaves > GetFollowerCount(cr) {
+		return removal.Slaves
+	}
+	return GetFollowerCount(cr)
+}
+
+// NewShardRemoval returns the shard removal to start when the master statefulset has more replicas than the size of
+// the redis cluster, nil when no shard is removed
+func NewShardRemoval(cr *redisv1beta1.Redis) *redisv1beta1.ShardRemovalStatus {
+	statefulSets := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace)
+	masters, err := statefulSets.Get(context.TODO(), GetRedisName(cr)+"-master", metav1.GetOptions{})
+	if err != nil || masters.Spec.Replicas == nil || *masters.Spec.Replicas <= *cr.Spec.Size {
+		return nil
+	}
+	removal := &redisv1beta1.ShardRemovalStatus{
+		StartTime: metav1.Now(),
+		Masters:   *masters.Spec.Replicas,
+		Slaves:    GetFollowerCount(cr),
+		Phase:     ShardRemovalMigratingSlots,
+	}
+	slaves, err := statefulSets.Get(context.TODO(), GetRedisName(cr)+"-slave", metav1.GetOptions{})
+	if err == nil && slaves.Spec.Replicas != nil && *slaves.Spec.Replicas > removal.Slaves {
+		removal.Slaves = *slaves.Spec.Replicas
+	}
+	return removal
+}
+
+// isRemovedPod reports whether the redis pod is deleted once the shards are removed, the master and slave pods beyond
+// the new counts
+func isRemovedPod(cr *redisv1beta1.Redis, podName string) bool {
+	counts := map[string]int32{
+		"master": *cr.Spec.Size,
+		"slave":  GetFollowerCount(cr),
+	}
+	for role, count := range counts {
+		prefix := GetRedisName(cr) + "-" + role + "-"
+		if !strings.HasPrefix(podName, prefix) {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(podName, prefix))
+		return err == nil && ordinal >= int(count)
+	}
+	return false
+}
+
+// countSlots returns the number of slots served by the redis master, the slots in migrating or importing state aside
+func countSlots(node redisClusterNode) int {
+	count := 0
+	for _, slot := range node.Slots {
+		if strings.HasPrefix(slot, "[") {
+			continue
+		}
+		if start, end, err := parseSlotRange(slot); err == nil {
+			count += end - start + 1
+		}
+	}
+	return count
+}
+
+// RemoveRedisShards will drain the shards beyond the size of the redis cluster, one step per call. Every step is
+// derived from the state of the cluster, so an interrupted removal resumes where it stopped.
+//   - The slots of the removed masters are resharded to the remaining masters with redis-cli, once it is checked
+//     that the remaining masters have the memory to hold their data.
+//   - The removed pods serving as master of a remaining shard are failed over to a remaining replica, and the
+//     remaining pods left without a master serving slots replicate the master with the fewest replicas.
+//   - The removed nodes are forgotten by the other nodes and reset, so that they aren't gossiped back.
+//
+// It returns true once the removed pods are out of the cluster and can be deleted.
+func RemoveRedisShards(ctx context.Context, cr *redisv1beta1.Redis) (bool, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	removal := cr.Status.ShardRemoval
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	if !isRedisClusterStable(nodes) {
+		reqLogger.Info("Redis cluster isn't stable, waiting for it to settle before removing shards")
+		return false, nil
+	}
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		return false, err
+	}
+	if synced, err := areRedisReplicasSynced(ctx, cr, nodes, podsByIP); err != nil || !synced {
+		if err == nil {
+			reqLogger.Info("Redis replicas aren't in sync with their masters, waiting for them before removing shards")
+		}
+		return false, err
+	}
+	shards, _, err := getShardPods(ctx, cr)
+	if err != nil {
+		return false, err
+	}
+	removedShard := map[string]bool{}
+	for index, pods := range shards {
+		for _, podName := range pods {
+			removedShard[podName] = index >= int(*cr.Spec.Size)
+		}
+	}
+	podNames := map[string]string{}
+	for _, node := range nodes {
+		if pod, ok := podsByIP[node.IP]; ok {
+			podNames[node.ID] = pod.Name
+		}
+	}
+
+	var sources, targets []redisClusterNode
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "master") || countSlots(node) == 0 {
+			continue
+		}
+		if removedShard[podNames[node.ID]] {
+			sources = append(sources, node)
+		} else {
+			targets = append(targets, node)
+		}
+	}
+	if len(sources) > 0 {
+		removal.Phase = ShardRemovalMigratingSlots
+		if len(targets) == 0 {
+			return false, fmt.Errorf("no remaining redis master can take the slots of the removed shards")
+		}
+		if err := checkShardRemovalCapacity(ctx, cr, sources, targets, podNames); err != nil {
+			return false, err
+		}
+		return false, migrateShardSlots(ctx, cr, sources[0], targets, podNames)
+	}
+
+	removal.Phase = ShardRemovalReassigningReplicas
+	if failedOver, err := failoverRemovedMasters(ctx, cr, nodes, podNames); err != nil || failedOver {
+		return false, err
+	}
+	if reassignRedisReplicas(ctx, cr, nodes, podNames) {
+		return false, nil
+	}
+
+	var removed []redisClusterNode
+	for _, node := range nodes {
+		if isRemovedPod(cr, podNames[node.ID]) {
+			removed = append(removed, node)
+		}
+	}
+	if len(removed) == 0 {
+		return true, nil
+	}
+	removal.Phase = ShardRemovalForgettingNodes
+	for _, node := range removed {
+		for _, peer := range nodes {
+			if peer.ID == node.ID || isRemovedPod(cr, podNames[peer.ID]) {
+				continue
+			}
+			if err := runClusterCommand(ctx, cr, podNames[peer.ID], "forget", node.ID); err != nil {
+				return false, err
+			}
+		}
+		if err := runClusterCommand(ctx, cr, podNames[node.ID], "reset"); err != nil {
+			return false, err
+		}
+		reqLogger.Info("Removed redis node is forgotten by the cluster", "Pod.Name", podNames[node.ID])
+	}
+	return false, nil
+}
+
+// checkShardRemovalCapacity returns an error when the remaining masters can't hold the data of the removed masters,
+// masters without a maxmemory are checked against the memory limit of the redis container
+// within their maxmemory, the data would then be evicted or fail the migration. The data is expected to spread
+// evenly across the remaining masters, and masters without a maxmemory are not checked.
+func checkShardRemovalCapacity(ctx context.Context, cr *redisv1beta1.Redis, sources []redisClusterNode, targets []redisClusterNode, podNames map[string]string) error {
+	var moved int64
+	for _, source := range sources {
+		used, _, err := getRedisMemory(ctx, cr, podNames[source.ID])
+		if err != nil {
+			return err
+		}
+		moved += used
+	}
+	share := moved / int64(len(targets))
+	resources := getRedisResources(cr)
+	memoryLimit := resources.Limits.Memory().Value()
+	for _, target := range targets {
+		used, maxMemory, err := getRedisMemory(ctx, cr, podNames[target.ID])
+		if err != nil {
+			return err
+		}
+		if maxMemory > 0 && used+share > maxMemory {
+			return fmt.Errorf("removing the shards would put %d bytes on redis master %s, above its maxmemory of %d bytes", used+share, podNames[target.ID], maxMemory)
+		}
+		if maxMemory == 0 && memoryLimit > 0 && used+share > memoryLimit {
+			return fmt.Errorf("removing the shards would put %d bytes on redis master %s, above its memory limit of %d bytes", used+share, podNames[target.ID], memoryLimit)
+		}
+	}
+	return nil
+}
+
+// getRedisMemory returns the used memory and the maxmemory of the redis pod
+func getRedisMemory(ctx context.Context, cr *redisv1beta1.Redis, podName string) (int64, int64, error) {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	output, err := client.Info("memory").Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	info := parseRedisInfo(output)
+	used, err := strconv.ParseInt(info["used_memory"], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis pod %s reported no used_memory", podName)
+	}
+	maxMemory, _ := strconv.ParseInt(info["maxmemory"], 10, 64)
+	return used, maxMemory, nil
+}
+
+// migrateShardSlots reshards the slots of the removed master evenly to the remaining masters with redis-cli, which
+// moves the keys along with the slots. The masters with the fewest slots get the remainder. It returns the error of
+// the first failed reshard.
+func migrateShardSlots(ctx context.Context, cr *redisv1beta1.Redis, source redisClusterNode, targets []redisClusterNode, podNames map[string]string) error {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	sort.Slice(targets, func(i, j int) bool {
+		return countSlots(targets[i]) < countSlots(targets[j])
+	})
+	slots := countSlots(source)
+	for i, target := range targets {
+		share := slots / len(targets)
+		if i < slots%len(targets) {
+			share++
+		}
+		if share == 0 {
+			continue
+		}
+		cmd := []string{"redis-cli", "--cluster", "reshard", source.IP + ":6379", "--cluster-from", source.ID, "--cluster-to", target.ID, "--cluster-slots", strconv.Itoa(share), "--cluster-yes"}
+		cmd = append(cmd, getRedisCLIAuthArgs(cr)...)
+		reqLogger.Info("Resharding the slots of a removed redis master", "From", podNames[source.ID], "To", podNames[target.ID], "Slots", share)
+		if err := runAdminCommand(ctx, cr, cmd, GetRedisName(cr)+"-master-0"); err != nil {
+			return fmt.Errorf("could not reshard the slots of redis master %s to %s: %v", podNames[source.ID], podNames[target.ID], err)
+		}
+	}
+	return nil
+}
+
+// failoverRemovedMasters fails over the removed pods serving as master of a remaining shard to one of their remaining
+// replicas. It returns true when a failover was started.
+func failoverRemovedMasters(ctx context.Context, cr *redisv1beta1.Redis, nodes []redisClusterNode, podNames map[string]string) (bool, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	failedOver := false
+	for _, master := range nodes {
+		if !strings.Contains(master.Flags, "master") || countSlots(master) == 0 || !isRemovedPod(cr, podNames[master.ID]) {
+			continue
+		}
+		replica := ""
+		for _, node := range nodes {
+			if node.MasterID == master.ID && podNames[node.ID] != "" && !isRemovedPod(cr, podNames[node.ID]) {
+				replica = podNames[node.ID]
+				break
+			}
+		}
+		if replica == "" {
+			return failedOver, fmt.Errorf("redis master %s is removed but has no remaining replica to fail over to", podNames[master.ID])
+		}
+		reqLogger.Info("Failing over removed redis master", "Pod.Name", podNames[master.ID], "Replica", replica)
+		if err := runClusterCommand(ctx, cr, replica, "failover"); err != nil {
+			return failedOver, err
+		}
+		failedOver = true
+	}
+	return failedOver, nil
+}
+
+// reassignRedisReplicas makes the remaining pods replicating a master without slots, or left as masters without
+// slots, replicate the master with the fewest replicas. It returns true when a replica was reassigned.
+func reassignRedisReplicas(ctx context.Context, cr *redisv1beta1.Redis, nodes []redisClusterNode, podNames map[string]string) bool {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	slots := map[string]int{}
+	for _, node := range nodes {
+		slots[node.ID] = countSlots(node)
+	}
+	reassigned := false
+	for i, node := range nodes {
+		podName := podNames[node.ID]
+		if podName == "" || isRemovedPod(cr, podName) {
+			continue
+		}
+		if (strings.Contains(node.Flags, "master") && slots[node.ID] > 0) || (strings.Contains(node.Flags, "slave") && slots[node.MasterID] > 0) {
+			continue
+		}
+		master, ok := getLeastReplicatedMaster(nodes)
+		if !ok {
+			return reassigned
+		}
+		if err := runClusterCommand(ctx, cr, podName, "replicate", master.ID); err != nil {
+			reqLogger.Error(err, "Redis replica could not be reassigned to a remaining master", "Pod.Name", podName)
+			continue
+		}
+		reqLogger.Info("Reassigned redis replica to a remaining master", "Pod.Name", podName, "Master", podNames[master.ID])
+		nodes[i].Flags = strings.Replace(node.Flags, "master", "slave", 1)
+		nodes[i].MasterID = master.ID
+		reassigned = true
+	}
+	return reassigned
+}
@@ -0,0 +1,70 @@
+package k8sutils
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewShardRemovalKeepsCurrentReplicas(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	for name, replicas := range map[string]int32{"redis-master": 5, "redis-slave": 5} {
+		replicas := replicas
+		statefulSet := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		}
+		if _, err := client.AppsV1().StatefulSets("default").Create(context.TODO(), statefulSet, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removal := NewShardRemoval(cr)
+	if removal == nil {
+		t.Fatal("expected a shard removal when the size is below the master replicas")
+	}
+	if removal.Masters != 5 || removal.Slaves != 5 {
+		t.Errorf("expected the removal to keep 5 masters and 5 slaves, got %d and %d", removal.Masters, removal.Slaves)
+	}
+	cr.Status.ShardRemoval = removal
+	if got := GetMasterReplicas(cr); got != 5 {
+		t.Errorf("expected 5 master replicas during the removal, got %d", got)
+	}
+	if got := GetSlaveReplicas(cr); got != 5 {
+		t.Errorf("expected 5 slave replicas during the removal, got %d", got)
+	}
+
+	size := int32(5)
+	cr.Spec.Size = &size
+	cr.Status.ShardRemoval = nil
+	if removal := NewShardRemoval(cr); removal != nil {
+		t.Errorf("expected no shard removal when the size matches the master replicas, got %+v", removal)
+	}
+}
+
+func TestIsRemovedPod(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	tests := map[string]bool{
+		"redis-master-2":     false,
+		"redis-master-3":     true,
+		"redis-slave-1":      false,
+		"redis-slave-4":      true,
+		"redis-standalone-0": false,
+		"":                   false,
+	}
+	for podName, want := range tests {
+		if got := isRemovedPod(cr, podName); got != want {
+			t.Errorf("isRemovedPod(%q) = %v, want %v", podName, got, want)
+		}
+	}
+}
+
+func TestCountSlots(t *testing.T) {
+	node := redisClusterNode{Slots: []string{"0-99", "200", "[300->-abc]"}}
+	if got := countSlots(node); got != 101 {
+		t.Errorf("expected 101 slots, got %d", got)
+	}
+}
@@ -0,0 +1,93 @@
+package k8sutils
+
+import (
+	"context"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+)
+
+// getShardPods returns the redis pods of every shard and the pod currently serving as master of every shard, indexed
+// by the ordinal of the initial master pod of the shard, so that a shard keeps its index after a failover
+func getShardPods(ctx context.Context, cr *redisv1beta1.Redis) (map[int][]string, map[int]string, error) {
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		return nil, nil, err
+	}
+	shardMasterIDs := map[string]string{}
+	podNames := map[string]string{}
+	masterIDs := map[string]bool{}
+	for _, node := range parseRedisClusterNodes(checkRedisCluster(ctx, cr)) {
+		pod, ok := podsByIP[node.IP]
+		if !ok {
+			continue
+		}
+		podNames[node.ID] = pod.Name
+		shardMasterIDs[node.ID] = node.ID
+		if strings.Contains(node.Flags, "slave") {
+			shardMasterIDs[node.ID] = node.MasterID
+		} else if strings.Contains(node.Flags, "master") {
+			masterIDs[node.ID] = true
+		}
+	}
+	shardIndexes := map[string]int{}
+	for nodeID, podName := range podNames {
+		if ordinal, err := strconv.Atoi(strings.TrimPrefix(podName, GetRedisName(cr)+"-master-")); err == nil {
+			shardIndexes[shardMasterIDs[nodeID]] = ordinal
+		}
+	}
+	shards := map[int][]string{}
+	masters := map[int]string{}
+	for nodeID, podName := range podNames {
+		if index, ok := shardIndexes[shardMasterIDs[nodeID]]; ok {
+			shards[index] = append(shards[index], podName)
+			if masterIDs[nodeID] {
+				masters[index] = podName
+			}
+		}
+	}
+	return shards, masters, nil
+}
+
+// GetShardMasters returns the pod currently serving as master of every redis cluster shard, keyed by the shard index
+func GetShardMasters(ctx context.Context, cr *redisv1beta1.Redis) map[string]string {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	_, masters, err := getShardPods(ctx, cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list the redis pods of the shards")
+		return nil
+	}
+	shardMasters := map[string]string{}
+	for index, podName := range masters {
+		shardMasters[strconv.Itoa(index)] = podName
+	}
+	return shardMasters
+}
+
+// ApplyShardOverrides will set the redis configuration overrides of each shard on its nodes with CONFIG SET,
+// only directives which differ from the running configuration are set
+func ApplyShardOverrides(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	shards, _, err := getShardPods(ctx, cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list the redis pods of the shards")
+		return
+	}
+	for _, override := range cr.Spec.ShardOverrides {
+		for _, podName := range shards[int(override.Shard)] {
+			client := configureRedisClient(ctx, cr, podName)
+			for key, value := range override.RedisConfig {
+				current, err := client.ConfigGet(key).Result()
+				if err == nil && len(current) == 2 && current[1] == value {
+					continue
+				}
+				if err := client.ConfigSet(key, value).Err(); err != nil {
+					reqLogger.Error(err, "Failed in setting the shard redis configuration, only dynamic directives can be overridden", "Shard", override.Shard, "Pod.Name", podName, "Directive", key)
+					continue
+				}
+				reqLogger.Info("Shard redis configuration is set", "Shard", override.Shard, "Pod.Name", podName, "Directive", key, "Value", value)
+			}
+			client.Close()
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RedisClusterSlots is the number of hash slots of a redis cluster
+const RedisClusterSlots = 16384
+
+// getRedisCLIAuthArgs returns the redis-cli arguments authenticating against the redis cluster
+func getRedisCLIAuthArgs(cr *redisv1beta1.Redis) []string {
+	if isRedisPasswordInSecret(cr) {
+		return []string{"-a", getRedisPassword(cr)}
+	}
+	if cr.Spec.GlobalConfig.Password != nil {
+		return []string{"-a", *cr.Spec.GlobalConfig.Password}
+	}
+	return nil
+}
+
+// GetOpenSlots returns the slots left in migrating or importing state, by name of the redis master pod holding them
+func GetOpenSlots(ctx context.Context, cr *redisv1beta1.Redis) (map[string][]string, error) {
+	openSlots := map[string][]string{}
+	for podCount := 0; podCount <= int(GetMasterReplicas(cr))-1; podCount++ {
+		podName := GetRedisName(cr) + "-master-" + strconv.Itoa(podCount)
+		client := configureRedisClient(ctx, cr, podName)
+		output, err := client.ClusterNodes().Result()
+		client.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range parseRedisClusterNodes(output) {
+			if !strings.Contains(node.Flags, "myself") {
+				continue
+			}
+			for _, slot := range node.Slots {
+				if strings.HasPrefix(slot, "[") {
+					openSlots[podName] = append(openSlots[podName], slot)
+				}
+			}
+		}
+	}
+	return openSlots, nil
+}
+
+// FixRedisClusterSlots will resume or roll back the slot migrations left open, using redis-cli cluster fix
+func FixRedisClusterSlots(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	masterPod := RedisDetails{
+		PodName:   GetRedisName(cr) + "-master-0",
+		Namespace: cr.Namespace,
+	}
+	cmd := []string{"redis-cli", "--cluster", "fix", getRedisServerIP(masterPod) + ":6379", "--cluster-yes"}
+	cmd = append(cmd, getRedisCLIAuthArgs(cr)...)
+	reqLogger.Info("Fixing open slots of the redis cluster")
+	executeCommand(ctx, cr, cmd, masterPod.PodName)
+}
+
+// GetRedisClusterSlotCoverage returns the number of slots served by redis masters which aren't failing, slots in
+// migrating or importing state are counted once through the range of the master owning them
+func GetRedisClusterSlotCoverage(ctx context.Context, cr *redisv1beta1.Redis) (int, error) {
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	if len(nodes) == 0 {
+		return 0, fmt.Errorf("no redis cluster nodes are listed")
+	}
+	covered := 0
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "master") || strings.Contains(node.Flags, "fail") {
+			continue
+		}
+		for _, slot := range node.Slots {
+			if strings.HasPrefix(slot, "[") {
+				continue
+			}
+			start, end, err := parseSlotRange(slot)
+			if err != nil {
+				return 0, fmt.Errorf("invalid slot range %q of node %s", slot, node.ID)
+			}
+			covered += end - start + 1
+		}
+	}
+	return covered, nil
+}
+
+// IsRedisFullCoverageRequired reports whether the redis cluster stops serving queries once a slot isn't covered. The
+// directive of the master configuration is used when it is set, otherwise the running redis is asked with CONFIG GET.
+func IsRedisFullCoverageRequired(ctx context.Context, cr *redisv1beta1.Redis) bool {
+	if value, ok := generateRedisConfig(cr, "master")["cluster-require-full-coverage"]; ok {
+		return strings.Trim(value, `"`) != "no"
+	}
+	client := configureRedisClient(ctx, cr, GetRedisName(cr)+"-master-0")
+	defer client.Close()
+	result, err := client.ConfigGet("cluster-require-full-coverage").Result()
+	if err != nil || len(result) != 2 {
+		return true
+	}
+	return result[1] != "no"
+}
+
+// parseSlotRange returns the first and last slot of a slot range of the CLUSTER NODES output, like 0-5460 or 42
+func parseSlotRange(slot string) (int, int, error) {
+	bounds := strings.SplitN(slot, "-", 2)
+	start, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end := start
+	if len(bounds) == 2 {
+		if end, err = strconv.Atoi(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, end, nil
+}
+
+// slotOwner is a redis master claiming a slot in its own view of the cluster
+type slotOwner struct {
+	podName     string
+	nodeID      string
+	configEpoch int64
+}
+
+// addSlotClaims adds the slots the redis pod claims for itself in its CLUSTER NODES output to the owners
+func addSlotClaims(owners map[int][]slotOwner, podName string, output string) error {
+	for _, node := range parseRedisClusterNodes(output) {
+		if !strings.Contains(node.Flags, "myself") || !strings.Contains(node.Flags, "master") {
+			continue
+		}
+		for _, slot := range node.Slots {
+			if strings.HasPrefix(slot, "[") {
+				continue
+			}
+			start, end, err := parseSlotRange(slot)
+			if err != nil {
+				return fmt.Errorf("invalid slot range %q of node %s", slot, node.ID)
+			}
+			for s := start; s <= end; s++ {
+				owners[s] = append(owners[s], slotOwner{podName: podName, nodeID: node.ID, configEpoch: node.ConfigEpoch})
+			}
+		}
+	}
+	return nil
+}
+
+// getDuplicateSlots returns the slots claimed by more than one redis master, by comparing the slots every redis pod
+// of the cluster reports for itself in CLUSTER NODES. Quarantined pods are left out since they are forgotten. Pods
+// which can't be reached are skipped, as long as a quorum of the pods is reached, so that a single unreachable pod
+// doesn't keep the duplicates of the others from being fixed.
+func getDuplicateSlots(ctx context.Context, cr *redisv1beta1.Redis) (map[int][]slotOwner, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	owners := map[int][]slotOwner{}
+	pods, reached := 0, 0
+	for _, pod := range getRedisPods(cr) {
+		if isPodQuarantined(cr, pod.Name) {
+			continue
+		}
+		pods++
+		client := configureRedisClient(ctx, cr, pod.Name)
+		output, err := client.ClusterNodes().Result()
+		client.Close()
+		if err != nil {
+			reqLogger.Info("Redis pod is unreachable, leaving it out of the duplicate slot check", "Pod.Name", pod.Name, "Reason", err.Error())
+			continue
+		}
+		reached++
+		if err := addSlotClaims(owners, pod.Name, output); err != nil {
+			return nil, err
+		}
+	}
+	if reached <= pods/2 {
+		return nil, fmt.Errorf("only %d of %d redis pods are reachable, a quorum is needed to check the slots", reached, pods)
+	}
+	for slot, claims := range owners {
+		if len(claims) < 2 {
+			delete(owners, slot)
+		}
+	}
+	return owners, nil
+}
+
+// getSlotWinner returns the claim of the master with the highest config epoch, and false when several masters share
+// the highest config epoch
+func getSlotWinner(claims []slotOwner) (slotOwner, bool) {
+	winner, tied := claims[0], false
+	for _, claim := range claims[1:] {
+		if claim.configEpoch > winner.configEpoch {
+			winner, tied = claim, false
+		} else if claim.configEpoch == winner.configEpoch {
+			tied = true
+		}
+	}
+	return winner, !tied
+}
+
+// FixDuplicateSlots will resolve the slots claimed by more than one redis master. Like redis does when masters
+// collide, the master with the higher config epoch wins, and the other masters claiming the slot are told with
+// CLUSTER SETSLOT NODE. Slots whose masters share the highest config epoch are left alone since no winner can be
+// picked. It returns the number of slots which have been fixed.
+func FixDuplicateSlots(ctx context.Context, cr *redisv1beta1.Redis) (int, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	duplicates, err := getDuplicateSlots(ctx, cr)
+	if err != nil {
+		return 0, err
+	}
+	slots := make([]int, 0, len(duplicates))
+	for slot := range duplicates {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+	fixed := 0
+	for _, slot := range slots {
+		claims := duplicates[slot]
+		winner, ok := getSlotWinner(claims)
+		if !ok {
+			reqLogger.Info("Slot is claimed by redis masters with the same config epoch, leaving it", "Slot", slot, "ConfigEpoch", winner.configEpoch)
+			continue
+		}
+		corrected := true
+		for _, claim := range claims {
+			if claim.nodeID == winner.nodeID {
+				continue
+			}
+			if err := runClusterCommand(ctx, cr, claim.podName, "setslot", slot, "node", winner.nodeID); err != nil {
+				reqLogger.Error(err, "Could not reassign duplicate slot", "Slot", slot, "Pod.Name", claim.podName)
+				corrected = false
+				continue
+			}
+			reqLogger.Info("Reassigned duplicate slot to the master with the higher config epoch", "Slot", slot,
+				"Pod.Name", claim.podName, "ConfigEpoch", claim.configEpoch, "Winner", winner.podName, "WinnerConfigEpoch", winner.configEpoch)
+		}
+		if corrected {
+			fixed++
+		}
+	}
+	return fixed, nil
+}
@@ -0,0 +1,45 @@
+package k8sutils
+
+import "testing"
+
+func TestParseSlotRange(t *testing.T) {
+	for slot, want := range map[string][2]int{"0-5460": {0, 5460}, "42": {42, 42}, "16383-16383": {16383, 16383}} {
+		start, end, err := parseSlotRange(slot)
+		if err != nil || start != want[0] || end != want[1] {
+			t.Errorf("expected %s to parse as %v, got %d-%d, %v", slot, want, start, end, err)
+		}
+	}
+	for _, slot := range []string{"", "a-5", "5-b"} {
+		if _, _, err := parseSlotRange(slot); err == nil {
+			t.Errorf("expected %q to be rejected", slot)
+		}
+	}
+}
+
+func TestAddSlotClaimsOnlyCountsOwnSlots(t *testing.T) {
+	owners := map[int][]slotOwner{}
+	output := "a 10.0.0.1:6379@16379 myself,master - 0 0 3 connected 0-1 5 [6->-b]\n" +
+		"b 10.0.0.2:6379@16379 master - 0 0 2 connected 1-2\n"
+	if err := addSlotClaims(owners, "redis-master-0", output); err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 3 || len(owners[0]) != 1 || len(owners[1]) != 1 || len(owners[5]) != 1 {
+		t.Fatalf("expected the slots 0, 1 and 5 of the pod itself, got %v", owners)
+	}
+	if claim := owners[1][0]; claim.podName != "redis-master-0" || claim.nodeID != "a" || claim.configEpoch != 3 {
+		t.Errorf("expected the claim of node a with config epoch 3, got %+v", claim)
+	}
+}
+
+func TestGetSlotWinner(t *testing.T) {
+	winner, ok := getSlotWinner([]slotOwner{{podName: "redis-master-0", nodeID: "a", configEpoch: 2}, {podName: "redis-master-1", nodeID: "b", configEpoch: 5}, {podName: "redis-slave-1", nodeID: "c", configEpoch: 1}})
+	if !ok || winner.nodeID != "b" {
+		t.Errorf("expected the master with the highest config epoch to win, got %+v, %v", winner, ok)
+	}
+	if _, ok := getSlotWinner([]slotOwner{{nodeID: "a", configEpoch: 5}, {nodeID: "b", configEpoch: 5}, {nodeID: "c", configEpoch: 1}}); ok {
+		t.Error("expected masters sharing the highest config epoch to tie")
+	}
+	if winner, ok := getSlotWinner([]slotOwner{{nodeID: "a", configEpoch: 5}, {nodeID: "b", configEpoch: 5}, {nodeID: "c", configEpoch: 6}}); !ok || winner.nodeID != "c" {
+		t.Errorf("expected a higher config epoch to break the tie, got %+v, %v", winner, ok)
+	}
+}
@@ -2,21 +2,77 @@ package k8sutils
 
 import (
 	"context"
+	"fmt"
 	// "github.com/google/go-cmp/cmp"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"strconv"
+	"strings"
+	"time"
 
 	redisv1beta1 "redis-operator/api/v1beta1"
 )
 
 const (
-	constRedisExpoterName = "redis-exporter"
-	graceTime             = 15
+	constRedisExpoterName     = "redis-exporter"
+	constDNSWaitName          = "dns-wait"
+	defaultDNSWaitImage       = "busybox:1.33"
+	graceTime                 = 15
+	shutdownGracePeriodBuffer = 10
+	exporterRedisAddr         = "redis://localhost:6379"
+	exporterPasswordMountPath = "/etc/redis-exporter"
+	exporterPasswordFileKey   = "password-file.json"
+	// startupProbeCommand succeeds once redis finished loading the AOF or RDB file, redis-cli reads the password
+	// from REDISCLI_AUTH
+	startupProbeCommand = `REDISCLI_AUTH="$REDIS_PASSWORD" redis-cli -h 127.0.0.1 -p 6379 info persistence | grep -q "^loading:0"`
+	// replicationSyncProbeCommand succeeds once a replica finished its sync with its master and the link is up, a
+	// redis which isn't a replica passes
+	replicationSyncProbeCommand = `info=$(REDISCLI_AUTH="$REDIS_PASSWORD" redis-cli -h 127.0.0.1 -p 6379 info replication) && ` +
+		`{ echo "$info" | grep -q "^role:master" || { echo "$info" | grep -q "^master_sync_in_progress:0" && echo "$info" | grep -q "^master_link_status:up"; }; }`
 )
 
+// startupProbeStorageThreshold is the storage size from which the startup probe is enabled by default, since
+// loading that much data can take longer than the liveness probe allows
+var startupProbeStorageThreshold = resource.MustParse("10Gi")
+
+var (
+	defaultInitContainerResources = corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+		},
+	}
+	defaultExporterResources = corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+	}
+)
+
+// defaultRedisResources are the resources of the redis containers whose spec doesn't set them, configured with the
+// operator flags
+var defaultRedisResources = corev1.ResourceRequirements{}
+
+// SetDefaultRedisResources sets the resources of the redis containers whose spec doesn't set them
+func SetDefaultRedisResources(resources corev1.ResourceRequirements) {
+	defaultRedisResources = resources
+}
+
 // StatefulInterface is the interface to pass statefulset information accross methods
 type StatefulInterface struct {
 	Existing *appsv1.StatefulSet
@@ -28,21 +84,28 @@ type StatefulInterface struct {
 func GenerateStateFulSetsDef(cr *redisv1beta1.Redis, labels map[string]string, role string, replicas *int32) *appsv1.StatefulSet {
 	statefulset := &appsv1.StatefulSet{
 		TypeMeta:   GenerateMetaInformation("StatefulSet", "apps/v1"),
-		ObjectMeta: GenerateObjectMetaInformation(cr.ObjectMeta.Name+"-"+role, cr.Namespace, labels, GenerateStatefulSetsAnots()),
+		ObjectMeta: GenerateRedisObjectMetaInformation(cr, GetRedisName(cr)+"-"+role, labels, mergeStringMaps(cr.Spec.GlobalConfig.StatefulSetAnnotations, GenerateStatefulSetsAnots(cr))),
 		Spec: appsv1.StatefulSetSpec{
-			Selector:    LabelSelectors(labels),
-			ServiceName: cr.ObjectMeta.Name + "-" + role,
-			Replicas:    replicas,
+			Selector:            LabelSelectors(labels),
+			ServiceName:         getHeadlessServiceName(cr, role),
+			PodManagementPolicy: getPodManagementPolicy(cr),
+			Replicas:            replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: mergeStringMaps(cr.Spec.GlobalConfig.Labels, labels),
+					Annotations: mergeStringMaps(cr.Spec.GlobalConfig.Annotations, map[string]string{
+						configChecksumAnnotation: getRedisConfigChecksum(cr, role),
+					}),
 				},
 				Spec: corev1.PodSpec{
-					Containers:        FinalContainerDef(cr, role),
-					NodeSelector:      cr.Spec.NodeSelector,
-					SecurityContext:   cr.Spec.SecurityContext,
-					PriorityClassName: cr.Spec.PriorityClassName,
-					Affinity:          cr.Spec.Affinity,
+					Containers:                    FinalContainerDef(cr, role),
+					NodeSelector:                  cr.Spec.NodeSelector,
+					SecurityContext:               cr.Spec.SecurityContext,
+					PriorityClassName:             cr.Spec.PriorityClassName,
+					Affinity:                      getRedisAffinity(cr, role),
+					ServiceAccountName:            getServiceAccountName(cr, role),
+					RuntimeClassName:              getRuntimeClassName(cr, role),
+					TerminationGracePeriodSeconds: getTerminationGracePeriod(cr),
 				},
 			},
 		},
@@ -50,14 +113,58 @@ func GenerateStateFulSetsDef(cr *redisv1beta1.Redis, labels map[string]string, r
 	if cr.Spec.Tolerations != nil {
 		statefulset.Spec.Template.Spec.Tolerations = *cr.Spec.Tolerations
 	}
+	restart := getPodRestart(cr, role)
+	if restart.SecretChecksum != "" {
+		statefulset.Spec.Template.Annotations[secretChecksumAnnotation] = restart.SecretChecksum
+	}
+	if restart.RestartedAt != "" {
+		statefulset.Spec.Template.Annotations[RedisRestartedAtAnnotation] = restart.RestartedAt
+	}
+	statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, getExternalConfigVolume(cr, role))
+	if cr.Spec.DNSWait != nil && cr.Spec.DNSWait.Enabled {
+		statefulset.Spec.Template.Spec.InitContainers = append(statefulset.Spec.Template.Spec.InitContainers, GenerateDNSWaitContainerDef(cr, role))
+	}
+	if cr.Spec.ACL != nil {
+		statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, getACLVolume(cr))
+	}
+	if role == "standalone" && cr.Spec.ReplicaOf != nil && cr.Spec.ReplicaOf.PasswordSecret != nil {
+		statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, getReplicaOfAuthVolume(cr))
+	}
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.Enabled && cr.Spec.RedisExporter.PasswordFile {
+		statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, getExporterPasswordVolume(cr))
+	}
+	if entrypoint := getEntrypointConfigMap(cr, role); entrypoint != nil {
+		statefulset.Spec.Template.Spec.Volumes = append(statefulset.Spec.Template.Spec.Volumes, getEntrypointVolume(entrypoint))
+	}
 	AddOwnerRefToObject(statefulset, AsOwner(cr))
 	return statefulset
 }
 
+// getPodManagementPolicy returns the pod management policy of the redis statefulsets, set explicitly so that it
+// compares equal to the kubernetes default of existing statefulsets
+func getPodManagementPolicy(cr *redisv1beta1.Redis) appsv1.PodManagementPolicyType {
+	if cr.Spec.PodManagementPolicy != "" {
+		return cr.Spec.PodManagementPolicy
+	}
+	return appsv1.OrderedReadyPodManagement
+}
+
+// getTerminationGracePeriod returns the termination grace period of the redis pods, leaving time to redis for its shutdown timeout
+func getTerminationGracePeriod(cr *redisv1beta1.Redis) *int64 {
+	if cr.Spec.TerminationGracePeriodSeconds != nil {
+		return cr.Spec.TerminationGracePeriodSeconds
+	}
+	if cr.Spec.ShutdownTimeout != nil {
+		gracePeriod := int64(*cr.Spec.ShutdownTimeout) + shutdownGracePeriodBuffer
+		return &gracePeriod
+	}
+	return nil
+}
+
 // GenerateContainerDef generates container definition
 func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container {
 	containerDefinition := corev1.Container{
-		Name:            cr.ObjectMeta.Name + "-" + role,
+		Name:            GetRedisName(cr) + "-" + role,
 		Image:           cr.Spec.GlobalConfig.Image,
 		ImagePullPolicy: cr.Spec.GlobalConfig.ImagePullPolicy,
 		Env: []corev1.EnvVar{
@@ -65,11 +172,25 @@ func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container
 				Name:  "SERVER_MODE",
 				Value: role,
 			},
+			{
+				Name:  "EXTERNAL_CONFIG_FILE",
+				Value: externalConfigMountPath + "/" + externalConfigFile,
+			},
 		},
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{}, Requests: corev1.ResourceList{},
+		Resources: getRedisResources(cr),
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          redisClientPortName,
+				ContainerPort: redisPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "external-config",
+				MountPath: externalConfigMountPath,
+			},
 		},
-		VolumeMounts: []corev1.VolumeMount{},
 		ReadinessProbe: &corev1.Probe{
 			InitialDelaySeconds: graceTime,
 			PeriodSeconds:       15,
@@ -77,10 +198,7 @@ func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container
 			TimeoutSeconds:      5,
 			Handler: corev1.Handler{
 				Exec: &corev1.ExecAction{
-					Command: []string{
-						"bash",
-						"/usr/bin/healthcheck.sh",
-					},
+					Command: getReadinessProbeCommand(cr, role),
 				},
 			},
 		},
@@ -89,54 +207,28 @@ func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container
 			TimeoutSeconds:      5,
 			Handler: corev1.Handler{
 				Exec: &corev1.ExecAction{
-					Command: []string{
-						"bash",
-						"/usr/bin/healthcheck.sh",
-					},
+					Command: getProbeCommand(cr, role),
 				},
 			},
 		},
+		StartupProbe: generateStartupProbe(cr, role),
 	}
-	if cr.Spec.GlobalConfig.Resources != nil {
-		containerDefinition.Resources.Limits[corev1.ResourceCPU] = resource.MustParse(cr.Spec.GlobalConfig.Resources.ResourceLimits.CPU)
-		containerDefinition.Resources.Requests[corev1.ResourceCPU] = resource.MustParse(cr.Spec.GlobalConfig.Resources.ResourceRequests.CPU)
-		containerDefinition.Resources.Limits[corev1.ResourceMemory] = resource.MustParse(cr.Spec.GlobalConfig.Resources.ResourceLimits.Memory)
-		containerDefinition.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(cr.Spec.GlobalConfig.Resources.ResourceRequests.Memory)
+	for _, probes := range getProbeOverrides(cr, role) {
+		if probes.Liveness != nil {
+			applyProbeTiming(containerDefinition.LivenessProbe, probes.Liveness)
+		}
+		if probes.Readiness != nil {
+			applyProbeTiming(containerDefinition.ReadinessProbe, probes.Readiness)
+		}
 	}
 	if cr.Spec.Storage != nil {
 		VolumeMounts := corev1.VolumeMount{
-			Name:      cr.ObjectMeta.Name + "-" + role,
+			Name:      GetRedisName(cr) + "-" + role,
 			MountPath: "/data",
 		}
 		containerDefinition.VolumeMounts = append(containerDefinition.VolumeMounts, VolumeMounts)
 	}
-	if cr.Spec.GlobalConfig.Password != nil && cr.Spec.GlobalConfig.ExistingPasswordSecret == nil {
-		containerDefinition.Env = append(containerDefinition.Env, corev1.EnvVar{
-			Name: "REDIS_PASSWORD",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: cr.ObjectMeta.Name,
-					},
-					Key: "password",
-				},
-			},
-		})
-	}
-
-	if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
-		containerDefinition.Env = append(containerDefinition.Env, corev1.EnvVar{
-			Name: "REDIS_PASSWORD",
-			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &corev1.SecretKeySelector{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: *cr.Spec.GlobalConfig.ExistingPasswordSecret.Name,
-					},
-					Key: *cr.Spec.GlobalConfig.ExistingPasswordSecret.Key,
-				},
-			},
-		})
-	}
+	containerDefinition.Env = append(containerDefinition.Env, getRedisPasswordEnv(cr, "REDIS_PASSWORD")...)
 
 	if cr.Spec.Mode != "cluster" {
 		containerDefinition.Env = append(containerDefinition.Env, corev1.EnvVar{
@@ -148,6 +240,11 @@ func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container
 			Name:  "SETUP_MODE",
 			Value: "cluster",
 		})
+		containerDefinition.Ports = append(containerDefinition.Ports, corev1.ContainerPort{
+			Name:          redisBusPortName,
+			ContainerPort: redisClusterBusPort,
+			Protocol:      corev1.ProtocolTCP,
+		})
 	}
 
 	if cr.Spec.Storage != nil {
@@ -156,9 +253,293 @@ func GenerateContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container
 			Value: "true",
 		})
 	}
+
+	if cr.Spec.ACL != nil {
+		containerDefinition.VolumeMounts = append(containerDefinition.VolumeMounts, corev1.VolumeMount{
+			Name:      GetRedisName(cr) + "-acl",
+			MountPath: aclMountPath,
+		})
+		containerDefinition.Env = append(containerDefinition.Env, corev1.EnvVar{
+			Name:  "ACL_MODE",
+			Value: "true",
+		}, corev1.EnvVar{
+			Name:  "ACL_FILE",
+			Value: aclMountPath + "/" + getACLKey(cr),
+		})
+	}
+	if role == "standalone" && cr.Spec.ReplicaOf != nil && cr.Spec.ReplicaOf.PasswordSecret != nil {
+		containerDefinition.VolumeMounts = append(containerDefinition.VolumeMounts, corev1.VolumeMount{
+			Name:      "replicaof-auth",
+			MountPath: replicaOfAuthMountPath,
+			ReadOnly:  true,
+		})
+	}
+	if entrypoint := getEntrypointConfigMap(cr, role); entrypoint != nil {
+		containerDefinition.Command = []string{entrypointMountPath + "/" + getEntrypointKey(entrypoint)}
+		containerDefinition.Args = []string{redisImageEntrypoint}
+		containerDefinition.VolumeMounts = append(containerDefinition.VolumeMounts, corev1.VolumeMount{
+			Name:      "entrypoint",
+			MountPath: entrypointMountPath,
+			ReadOnly:  true,
+		})
+	}
 	return containerDefinition
 }
 
+// getRedisResources returns the resources of the redis container, every value set in the spec takes precedence over
+// the default of the operator
+func getRedisResources(cr *redisv1beta1.Redis) corev1.ResourceRequirements {
+	resources := corev1.ResourceRequirements{Limits: corev1.ResourceList{}, Requests: corev1.ResourceList{}}
+	for name, quantity := range defaultRedisResources.Limits {
+		resources.Limits[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range defaultRedisResources.Requests {
+		resources.Requests[name] = quantity.DeepCopy()
+	}
+	if spec := cr.Spec.GlobalConfig.Resources; spec != nil {
+		setResourceQuantity(resources.Limits, corev1.ResourceCPU, spec.ResourceLimits.CPU)
+		setResourceQuantity(resources.Limits, corev1.ResourceMemory, spec.ResourceLimits.Memory)
+		setResourceQuantity(resources.Requests, corev1.ResourceCPU, spec.ResourceRequests.CPU)
+		setResourceQuantity(resources.Requests, corev1.ResourceMemory, spec.ResourceRequests.Memory)
+	}
+	return resources
+}
+
+// setResourceQuantity sets the quantity of the resource when the value isn't empty
+func setResourceQuantity(resources corev1.ResourceList, name corev1.ResourceName, value string) {
+	if value != "" {
+		resources[name] = resource.MustParse(value)
+	}
+}
+
+// generateResourceRequirements converts the resources of the redis spec into container resources, falling back to the defaults
+func generateResourceRequirements(resources *redisv1beta1.Resources, defaults corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if resources == nil {
+		return *defaults.DeepCopy()
+	}
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(resources.ResourceLimits.CPU),
+			corev1.ResourceMemory: resource.MustParse(resources.ResourceLimits.Memory),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(resources.ResourceRequests.CPU),
+			corev1.ResourceMemory: resource.MustParse(resources.ResourceRequests.Memory),
+		},
+	}
+}
+
+// GenerateDNSWaitContainerDef generates the init container which waits until the record of the pod,
+// <pod>.<headless service>.<namespace>.svc, resolves to the pod IP
+func GenerateDNSWaitContainerDef(cr *redisv1beta1.Redis, role string) corev1.Container {
+	image := defaultDNSWaitImage
+	if cr.Spec.DNSWait.Image != "" {
+		image = cr.Spec.DNSWait.Image
+	}
+	podFQDN := "$(POD_NAME)." + getHeadlessServiceName(cr, role) + "." + cr.Namespace + ".svc"
+	var resources *redisv1beta1.Resources
+	if cr.Spec.InitContainer != nil {
+		resources = cr.Spec.InitContainer.Resources
+	}
+	return corev1.Container{
+		Name:            constDNSWaitName,
+		Image:           image,
+		ImagePullPolicy: cr.Spec.DNSWait.ImagePullPolicy,
+		Resources:       generateResourceRequirements(resources, defaultInitContainerResources),
+		Env: []corev1.EnvVar{
+			{
+				Name: "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "metadata.name",
+					},
+				},
+			},
+			{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "status.podIP",
+					},
+				},
+			},
+			{
+				Name:  "POD_FQDN",
+				Value: podFQDN,
+			},
+		},
+		Command: []string{
+			"sh",
+			"-c",
+			"until nslookup \"$POD_FQDN\" | grep -qwF \"$POD_IP\"; do echo \"waiting for $POD_FQDN to resolve to $POD_IP\"; sleep 2; done",
+		},
+	}
+}
+
+// generateExporterProbe generates the HTTP probe of the redis exporter on its web port, so that a hung exporter is restarted
+func generateExporterProbe(override *redisv1beta1.ExporterProbe, port int) *corev1.Probe {
+	probe := &corev1.Probe{
+		InitialDelaySeconds: 10,
+		PeriodSeconds:       15,
+		TimeoutSeconds:      5,
+		FailureThreshold:    3,
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/health",
+				Port: intstr.FromInt(port),
+			},
+		},
+	}
+	if override == nil {
+		return probe
+	}
+	if override.Path != "" {
+		probe.HTTPGet.Path = override.Path
+	}
+	if override.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *override.InitialDelaySeconds
+	}
+	if override.PeriodSeconds != nil {
+		probe.PeriodSeconds = *override.PeriodSeconds
+	}
+	if override.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *override.TimeoutSeconds
+	}
+	if override.FailureThreshold != nil {
+		probe.FailureThreshold = *override.FailureThreshold
+	}
+	return probe
+}
+
+// generateStartupProbe generates the startup probe of redis, which is enabled by default for large storage sizes.
+// Redis answers PING with a LOADING error while it loads its data, so without it the liveness probe can kill redis
+// before it finished loading. The startup probe of the role overrides the one of the whole setup field by field.
+func generateStartupProbe(cr *redisv1beta1.Redis, role string) *corev1.Probe {
+	var overrides []*redisv1beta1.StartupProbe
+	if cr.Spec.StartupProbe != nil {
+		overrides = append(overrides, cr.Spec.StartupProbe)
+	}
+	if role == "master" && cr.Spec.Master.StartupProbe != nil {
+		overrides = append(overrides, cr.Spec.Master.StartupProbe)
+	}
+	if role == "slave" && cr.Spec.Slave.StartupProbe != nil {
+		overrides = append(overrides, cr.Spec.Slave.StartupProbe)
+	}
+	enabled := false
+	if cr.Spec.Storage != nil {
+		size := cr.Spec.Storage.VolumeClaimTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+		enabled = size.Cmp(startupProbeStorageThreshold) >= 0
+	}
+	for _, override := range overrides {
+		if override.Enabled != nil {
+			enabled = *override.Enabled
+		}
+	}
+	if !enabled {
+		return nil
+	}
+	probe := &corev1.Probe{
+		PeriodSeconds:    10,
+		TimeoutSeconds:   5,
+		SuccessThreshold: 1,
+		FailureThreshold: 360,
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"sh", "-c", startupProbeCommand},
+			},
+		},
+	}
+	for _, override := range overrides {
+		if override.PeriodSeconds != nil {
+			probe.PeriodSeconds = *override.PeriodSeconds
+		}
+		if override.TimeoutSeconds != nil {
+			probe.TimeoutSeconds = *override.TimeoutSeconds
+		}
+		if override.FailureThreshold != nil {
+			probe.FailureThreshold = *override.FailureThreshold
+		}
+	}
+	return probe
+}
+
+// getProbeOverrides returns the probe overrides applying to the redis role, the overrides of the whole setup first
+// so that the ones of the role take precedence
+func getProbeOverrides(cr *redisv1beta1.Redis, role string) []*redisv1beta1.Probes {
+	var overrides []*redisv1beta1.Probes
+	if cr.Spec.Probes != nil {
+		overrides = append(overrides, cr.Spec.Probes)
+	}
+	if role == "master" && cr.Spec.Master.Probes != nil {
+		overrides = append(overrides, cr.Spec.Master.Probes)
+	}
+	if role == "slave" && cr.Spec.Slave.Probes != nil {
+		overrides = append(overrides, cr.Spec.Slave.Probes)
+	}
+	return overrides
+}
+
+// applyProbeTiming overrides the timing of the probe with the fields set in the timing
+func applyProbeTiming(probe *corev1.Probe, timing *redisv1beta1.ProbeTiming) {
+	if timing.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *timing.InitialDelaySeconds
+	}
+	if timing.PeriodSeconds != nil {
+		probe.PeriodSeconds = *timing.PeriodSeconds
+	}
+	if timing.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *timing.TimeoutSeconds
+	}
+	if timing.FailureThreshold != nil {
+		probe.FailureThreshold = *timing.FailureThreshold
+	}
+}
+
+// getProbeCommand returns the exec command of the redis liveness and readiness probes of the role
+func getProbeCommand(cr *redisv1beta1.Redis, role string) []string {
+	var command []string
+	for _, probes := range getProbeOverrides(cr, role) {
+		if len(probes.Command) > 0 {
+			command = probes.Command
+		}
+	}
+	if command != nil {
+		return command
+	}
+	return []string{
+		"bash",
+		"/usr/bin/healthcheck.sh",
+	}
+}
+
+// isReplicationSyncProbeEnabled reports whether the readiness probe of the role waits for the replication sync, which
+// is the default for a standalone redis replicating an external primary. The setting of the role takes precedence.
+func isReplicationSyncProbeEnabled(cr *redisv1beta1.Redis, role string) bool {
+	enabled := role == "standalone" && cr.Spec.ReplicaOf != nil
+	for _, probes := range getProbeOverrides(cr, role) {
+		if probes.ReplicationSync != nil {
+			enabled = *probes.ReplicationSync
+		}
+	}
+	return enabled
+}
+
+// getReadinessProbeCommand returns the exec command of the redis readiness probe of the role, which keeps a syncing
+// replica out of the service endpoints when the replication sync check is enabled. A custom probe command is used as
+// it is.
+func getReadinessProbeCommand(cr *redisv1beta1.Redis, role string) []string {
+	command := getProbeCommand(cr, role)
+	for _, probes := range getProbeOverrides(cr, role) {
+		if len(probes.Command) > 0 {
+			return command
+		}
+	}
+	if !isReplicationSyncProbeEnabled(cr, role) {
+		return command
+	}
+	return []string{"sh", "-c", strings.Join(command, " ") + " && " + replicationSyncProbeCommand}
+}
+
 // FinalContainerDef will generate the final statefulset definition
 func FinalContainerDef(cr *redisv1beta1.Redis, role string) []corev1.Container {
 	var containerDefinition []corev1.Container
@@ -168,67 +549,94 @@ func FinalContainerDef(cr *redisv1beta1.Redis, role string) []corev1.Container {
 	containerDefinition = append(containerDefinition, GenerateContainerDef(cr, role))
 
 	if !cr.Spec.RedisExporter.Enabled {
-		return containerDefinition
+		return append(containerDefinition, cr.Spec.Sidecars...)
 	}
 
-	if cr.Spec.GlobalConfig.Password != nil {
+	if cr.Spec.RedisExporter.PasswordFile {
+		exporterEnvDetails = []corev1.EnvVar{
+			{
+				Name:  "REDIS_PASSWORD_FILE",
+				Value: exporterPasswordMountPath + "/" + exporterPasswordFileKey,
+			}, {
+				Name:  "REDIS_ADDR",
+				Value: exporterRedisAddr,
+			},
+		}
+	} else if isRedisPasswordSet(cr) {
 		exporterEnvDetails = []corev1.EnvVar{
 			{
 				Name: "REDIS_PASSWORD",
 				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: cr.ObjectMeta.Name,
-						},
-						Key: "password",
-					},
+					SecretKeyRef: getRedisPasswordSecretKeySelector(cr),
 				},
 			}, {
 				Name:  "REDIS_ADDR",
-				Value: "redis://localhost:6379",
+				Value: exporterRedisAddr,
 			},
 		}
 	} else {
 		exporterEnvDetails = []corev1.EnvVar{
 			{
 				Name:  "REDIS_ADDR",
-				Value: "redis://localhost:6379",
+				Value: exporterRedisAddr,
 			},
 		}
 	}
+	// the exporter listens on the default port by itself, so that the pods of existing redis aren't restarted
+	if port := getRedisExporterPort(cr); port != redisExporterPort {
+		exporterEnvDetails = append(exporterEnvDetails, corev1.EnvVar{
+			Name:  "REDIS_EXPORTER_WEB_LISTEN_ADDRESS",
+			Value: ":" + strconv.Itoa(port),
+		})
+	}
 	exporterDefinition = corev1.Container{
 		Name:            constRedisExpoterName,
 		Image:           cr.Spec.RedisExporter.Image,
 		ImagePullPolicy: cr.Spec.RedisExporter.ImagePullPolicy,
-		Env:             exporterEnvDetails,
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{}, Requests: corev1.ResourceList{},
+		Args:            cr.Spec.RedisExporter.ExtraArgs,
+		Env:             append(exporterEnvDetails, cr.Spec.RedisExporter.Env...),
+		Resources:       generateResourceRequirements(cr.Spec.RedisExporter.Resources, defaultExporterResources),
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          redisMetricsPortName,
+				ContainerPort: int32(getRedisExporterPort(cr)),
+				Protocol:      corev1.ProtocolTCP,
+			},
 		},
 	}
+	if probe := cr.Spec.RedisExporter.Probe; probe == nil || !probe.Disabled {
+		exporterDefinition.LivenessProbe = generateExporterProbe(probe, getRedisExporterPort(cr))
+		exporterDefinition.ReadinessProbe = generateExporterProbe(probe, getRedisExporterPort(cr))
+	}
 
-	if cr.Spec.RedisExporter.Resources != nil {
-		exporterDefinition.Resources.Limits[corev1.ResourceCPU] = resource.MustParse(cr.Spec.RedisExporter.Resources.ResourceLimits.CPU)
-		exporterDefinition.Resources.Requests[corev1.ResourceCPU] = resource.MustParse(cr.Spec.RedisExporter.Resources.ResourceRequests.CPU)
-		exporterDefinition.Resources.Limits[corev1.ResourceMemory] = resource.MustParse(cr.Spec.RedisExporter.Resources.ResourceLimits.Memory)
-		exporterDefinition.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(cr.Spec.RedisExporter.Resources.ResourceRequests.Memory)
+	if cr.Spec.RedisExporter.PasswordFile {
+		exporterDefinition.VolumeMounts = append(exporterDefinition.VolumeMounts, corev1.VolumeMount{
+			Name:      "exporter-password",
+			MountPath: exporterPasswordMountPath,
+			ReadOnly:  true,
+		})
 	}
 
 	containerDefinition = append(containerDefinition, exporterDefinition)
-	return containerDefinition
+	return append(containerDefinition, cr.Spec.Sidecars...)
 }
 
 // CreateRedisMaster will create a Redis Master
 func CreateRedisMaster(cr *redisv1beta1.Redis) {
 
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-master",
+		"app":  GetRedisName(cr) + "-master",
 		"role": "master",
 	}
-	statefulDefinition := GenerateStateFulSetsDef(cr, labels, "master", cr.Spec.Size)
-	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-master", metav1.GetOptions{})
+	masters := GetMasterReplicas(cr)
+	statefulDefinition := GenerateStateFulSetsDef(cr, labels, "master", &masters)
+	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-master", metav1.GetOptions{})
 
 	if cr.Spec.Storage != nil {
 		statefulDefinition.Spec.VolumeClaimTemplates = append(statefulDefinition.Spec.VolumeClaimTemplates, CreatePVCTemplate(cr, "master"))
+		if err != nil && cr.Spec.RestoreFrom != nil {
+			createRestoredPVCs(cr, "master")
+		}
 	}
 
 	stateful := StatefulInterface{
@@ -242,11 +650,12 @@ func CreateRedisMaster(cr *redisv1beta1.Redis) {
 // CreateRedisSlave will create a Redis Slave
 func CreateRedisSlave(cr *redisv1beta1.Redis) {
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-slave",
+		"app":  GetRedisName(cr) + "-slave",
 		"role": "slave",
 	}
-	statefulDefinition := GenerateStateFulSetsDef(cr, labels, "slave", cr.Spec.Size)
-	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-slave", metav1.GetOptions{})
+	followers := GetSlaveReplicas(cr)
+	statefulDefinition := GenerateStateFulSetsDef(cr, labels, "slave", &followers)
+	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-slave", metav1.GetOptions{})
 
 	if cr.Spec.Storage != nil {
 		statefulDefinition.Spec.VolumeClaimTemplates = append(statefulDefinition.Spec.VolumeClaimTemplates, CreatePVCTemplate(cr, "slave"))
@@ -265,13 +674,16 @@ func CreateRedisStandalone(cr *redisv1beta1.Redis) {
 	var standaloneReplica int32 = 1
 
 	labels := map[string]string{
-		"app":  cr.ObjectMeta.Name + "-" + "standalone",
+		"app":  GetRedisName(cr) + "-" + "standalone",
 		"role": "standalone",
 	}
 	statefulDefinition := GenerateStateFulSetsDef(cr, labels, "standalone", &standaloneReplica)
-	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), cr.ObjectMeta.Name+"-standalone", metav1.GetOptions{})
+	statefulObject, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-standalone", metav1.GetOptions{})
 	if cr.Spec.Storage != nil {
 		statefulDefinition.Spec.VolumeClaimTemplates = append(statefulDefinition.Spec.VolumeClaimTemplates, CreatePVCTemplate(cr, "standalone"))
+		if err != nil && cr.Spec.RestoreFrom != nil {
+			createRestoredPVCs(cr, "standalone")
+		}
 	}
 
 	stateful := StatefulInterface{
@@ -285,20 +697,61 @@ func CreateRedisStandalone(cr *redisv1beta1.Redis) {
 // CompareAndCreateStateful will compare and create a statefulset pod
 func CompareAndCreateStateful(cr *redisv1beta1.Redis, clusterInfo StatefulInterface, err error, role string) {
 	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	checkServiceAccount(cr, clusterInfo.Desired.Spec.Template.Spec.ServiceAccountName)
+	checkLimitRanges(cr, clusterInfo.Desired)
+	checkRuntimeClass(cr, clusterInfo.Desired)
 
 	if err != nil {
-		reqLogger.Info("Creating redis setup", "Redis.Name", cr.ObjectMeta.Name+"-"+clusterInfo.Type, "Setup.Type", clusterInfo.Type)
+		reqLogger.Info("Creating redis setup", "Redis.Name", GetRedisName(cr)+"-"+clusterInfo.Type, "Setup.Type", clusterInfo.Type)
 		_, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Create(context.TODO(), clusterInfo.Desired, metav1.CreateOptions{})
 		if err != nil {
 			reqLogger.Error(err, "Failed in creating statefulset for redis")
 		}
+		// the typed client returns an empty statefulset along with the error, which has nothing to compare
+		return
 	}
 
-	state := compareState(clusterInfo)
-
 	if clusterInfo.Existing != nil {
-		if !state {
-			reqLogger.Info("Reconciling redis setup because spec is changed", "Redis.Name", cr.ObjectMeta.Name+"-"+clusterInfo.Type, "Setup.Type", clusterInfo.Type)
+		if err == nil && clusterInfo.Existing.DeletionTimestamp != nil {
+			reqLogger.Info("Waiting for the statefulset of redis to be deleted before creating it again", "Redis.Name", GetRedisName(cr)+"-"+clusterInfo.Type)
+			return
+		}
+		if changes := getStatefulSetImmutableChanges(clusterInfo.Existing, clusterInfo.Desired); err == nil && len(changes) > 0 {
+			recreateStatefulSet(cr, clusterInfo, changes)
+			return
+		}
+		if isScaleDownDeferred(clusterInfo.Existing, clusterInfo.Desired) {
+			reqLogger.Info("Deferring scale down of redis until the rollout of the statefulset completes", "Redis.Name", GetRedisName(cr)+"-"+clusterInfo.Type, "Replicas", *clusterInfo.Existing.Spec.Replicas, "Desired.Replicas", *clusterInfo.Desired.Spec.Replicas)
+			clusterInfo.Desired.Spec.Replicas = clusterInfo.Existing.Spec.Replicas
+		}
+		metaChanged := mergeObjectMeta(&clusterInfo.Existing.ObjectMeta, clusterInfo.Desired.ObjectMeta)
+		// a derivative comparison misses the restartedAt annotation added to the pod template
+		restartChanged := clusterInfo.Existing.Spec.Template.Annotations[RedisRestartedAtAnnotation] != clusterInfo.Desired.Spec.Template.Annotations[RedisRestartedAtAnnotation]
+		startupChanged, entrypointChanged := false, false
+		if len(clusterInfo.Existing.Spec.Template.Spec.Containers) > 0 && len(clusterInfo.Desired.Spec.Template.Spec.Containers) > 0 {
+			existing, desired := clusterInfo.Existing.Spec.Template.Spec.Containers[0], clusterInfo.Desired.Spec.Template.Spec.Containers[0]
+			// and it misses the startup probe being removed
+			startupChanged = (existing.StartupProbe == nil) != (desired.StartupProbe == nil)
+			// and the custom entrypoint being removed or replaced by one with as many arguments
+			entrypointChanged = !apiequality.Semantic.DeepEqual(existing.Command, desired.Command)
+		}
+		// statefulsets created before the secret checksum existed only get it along with the next change, so that
+		// upgrading the operator doesn't restart redis
+		existingSecretChecksum, ok := clusterInfo.Existing.Spec.Template.Annotations[secretChecksumAnnotation]
+		secretChanged := ok && existingSecretChecksum != clusterInfo.Desired.Spec.Template.Annotations[secretChecksumAnnotation]
+		templateChanged := restartChanged || startupChanged || entrypointChanged || secretChanged ||
+			isPodTemplateChanged(clusterInfo.Existing.Spec.Template, clusterInfo.Desired.Spec.Template)
+		// a changed pod template rolls the pods, which waits for the maintenance window
+		if templateChanged && DeferDisruptiveOperation(cr, getRolloutOperation(clusterInfo)) {
+			reqLogger.Info("Deferring rollout of redis until the maintenance window", "Redis.Name", GetRedisName(cr)+"-"+clusterInfo.Type, "Setup.Type", clusterInfo.Type)
+			clusterInfo.Desired.Spec.Template = clusterInfo.Existing.Spec.Template
+			restartChanged, startupChanged, entrypointChanged, secretChanged = false, false, false, false
+		}
+		if !compareState(clusterInfo) || metaChanged || restartChanged || startupChanged || entrypointChanged || secretChanged {
+			// keep the labels and annotations set on the statefulset by other tools
+			clusterInfo.Desired.Labels = clusterInfo.Existing.Labels
+			clusterInfo.Desired.Annotations = clusterInfo.Existing.Annotations
+			reqLogger.Info("Reconciling redis setup because spec is changed", "Redis.Name", GetRedisName(cr)+"-"+clusterInfo.Type, "Setup.Type", clusterInfo.Type)
 			_, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Update(context.TODO(), clusterInfo.Desired, metav1.UpdateOptions{})
 			if err != nil {
 				reqLogger.Error(err, "Failed in updating statefulset for redis")
@@ -307,6 +760,123 @@ func CompareAndCreateStateful(cr *redisv1beta1.Redis, clusterInfo StatefulInterf
 	}
 }
 
+// getRolloutOperation describes the rollout of the pods of the statefulset, an upgrade when the redis image changes.
+// A statefulset without containers is a rolling restart.
+func getRolloutOperation(clusterInfo StatefulInterface) string {
+	name := clusterInfo.Desired.Name
+	existing, desired := clusterInfo.Existing.Spec.Template.Spec.Containers, clusterInfo.Desired.Spec.Template.Spec.Containers
+	if len(existing) > 0 && len(desired) > 0 && existing[0].Image != desired[0].Image {
+		return fmt.Sprintf("upgrade of %s to %s", name, desired[0].Image)
+	}
+	return fmt.Sprintf("rolling restart of %s", name)
+}
+
+// isScaleDownDeferred checks if the desired statefulset removes replicas while the existing one is still rolling out
+// its pods. Removing pods while others are being replaced compounds the disruptions, so the scale down waits for
+// the rollout to complete.
+func isScaleDownDeferred(existing *appsv1.StatefulSet, desired *appsv1.StatefulSet) bool {
+	if existing.Spec.Replicas == nil || desired.Spec.Replicas == nil || *desired.Spec.Replicas >= *existing.Spec.Replicas {
+		return false
+	}
+	return existing.Status.ObservedGeneration < existing.Generation ||
+		existing.Status.CurrentRevision != existing.Status.UpdateRevision ||
+		existing.Status.UpdatedReplicas < *existing.Spec.Replicas
+}
+
+// getStatefulSetImmutableChanges returns the immutable statefulset fields which differ between the existing and desired statefulset
+func getStatefulSetImmutableChanges(existing *appsv1.StatefulSet, desired *appsv1.StatefulSet) []string {
+	var changes []string
+	if existing.Spec.ServiceName != desired.Spec.ServiceName {
+		changes = append(changes, "serviceName")
+	}
+	if existing.Spec.PodManagementPolicy != desired.Spec.PodManagementPolicy {
+		changes = append(changes, "podManagementPolicy")
+	}
+	if !apiequality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) {
+		changes = append(changes, "selector")
+	}
+	if len(existing.Spec.VolumeClaimTemplates) != len(desired.Spec.VolumeClaimTemplates) {
+		changes = append(changes, "volumeClaimTemplates")
+		return changes
+	}
+	for i, desiredPVC := range desired.Spec.VolumeClaimTemplates {
+		existingPVC := existing.Spec.VolumeClaimTemplates[i]
+		if existingPVC.Name != desiredPVC.Name ||
+			!apiequality.Semantic.DeepEqual(existingPVC.Spec.AccessModes, desiredPVC.Spec.AccessModes) ||
+			!apiequality.Semantic.DeepEqual(existingPVC.Spec.Resources, desiredPVC.Spec.Resources) ||
+			(desiredPVC.Spec.StorageClassName != nil && !apiequality.Semantic.DeepEqual(existingPVC.Spec.StorageClassName, desiredPVC.Spec.StorageClassName)) {
+			changes = append(changes, "volumeClaimTemplates")
+			break
+		}
+	}
+	return changes
+}
+
+// isStatefulSetRecreateSafe checks that the recreated statefulset adopts the running pods and their volumes.
+// A new selector would leave the orphaned pods running beside the new ones, and a new or renamed volume claim
+// template would start redis on empty volumes.
+func isStatefulSetRecreateSafe(existing *appsv1.StatefulSet, desired *appsv1.StatefulSet) bool {
+	if !apiequality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) {
+		return false
+	}
+	if len(existing.Spec.VolumeClaimTemplates) != len(desired.Spec.VolumeClaimTemplates) {
+		return false
+	}
+	for i, desiredPVC := range desired.Spec.VolumeClaimTemplates {
+		if existing.Spec.VolumeClaimTemplates[i].Name != desiredPVC.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// statefulSetDeletionTimeout is how long a recreated statefulset is waited for to be deleted within a reconcile
+const statefulSetDeletionTimeout = 5 * time.Second
+
+// recreateStatefulSet applies immutable field changes by deleting the statefulset while orphaning its pods and
+// volumes, and creating it again. The new statefulset adopts the running pods and the existing volume claims,
+// volume claim template changes only apply to the volumes created afterwards. The orphaning deletion completes once
+// the pods are released, the statefulset is created when it is gone, on a later reconcile if it takes a while.
+func recreateStatefulSet(cr *redisv1beta1.Redis, clusterInfo StatefulInterface, changes []string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	if !isStatefulSetRecreateSafe(clusterInfo.Existing, clusterInfo.Desired) {
+		reqLogger.Error(fmt.Errorf("immutable fields %v of statefulset %s changed", changes, clusterInfo.Existing.Name),
+			"Refusing to recreate statefulset for redis, the running pods or their data would not be kept")
+		return
+	}
+	reqLogger.Info("Recreating statefulset for redis because immutable fields changed, pods and volumes are kept", "StatefulSet.Name", clusterInfo.Existing.Name, "Fields", changes)
+	orphan := metav1.DeletePropagationOrphan
+	err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Delete(context.TODO(), clusterInfo.Existing.Name, metav1.DeleteOptions{
+		PropagationPolicy: &orphan,
+		Preconditions:     &metav1.Preconditions{UID: &clusterInfo.Existing.UID},
+	})
+	if err != nil {
+		reqLogger.Error(err, "Failed in deleting statefulset for redis")
+		return
+	}
+	err = wait.PollImmediate(time.Second, statefulSetDeletionTimeout, func() (bool, error) {
+		_, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), clusterInfo.Existing.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		reqLogger.Info("Statefulset of redis is still being deleted, it will be created on the next reconcile", "StatefulSet.Name", clusterInfo.Existing.Name, "Reason", err.Error())
+		return
+	}
+	_, err = GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Create(context.TODO(), clusterInfo.Desired, metav1.CreateOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed in recreating statefulset for redis, it will be created on the next reconcile")
+	}
+}
+
+// isPodTemplateChanged reports whether the desired pod template differs from the existing one, the fields left unset
+// in the desired template are defaulted by the API server and ignored
+func isPodTemplateChanged(existing corev1.PodTemplateSpec, desired corev1.PodTemplateSpec) bool {
+	return !apiequality.Semantic.DeepDerivative(desired, existing)
+}
+
 // compareState method will compare the statefulsets
 func compareState(clusterInfo StatefulInterface) bool {
 	if apiequality.Semantic.DeepDerivative(clusterInfo.Existing.Spec, clusterInfo.Desired.Spec) {
@@ -323,11 +893,11 @@ func CreatePVCTemplate(cr *redisv1beta1.Redis, role string) corev1.PersistentVol
 	var pvcTemplate corev1.PersistentVolumeClaim
 
 	if storageSpec == nil {
-		reqLogger.Info("No storage is defined for redis", "Redis.Name", cr.ObjectMeta.Name)
+		reqLogger.Info("No storage is defined for redis", "Redis.Name", GetRedisName(cr))
 	} else {
 		pvcTemplate = storageSpec.VolumeClaimTemplate
 		pvcTemplate.CreationTimestamp = metav1.Time{}
-		pvcTemplate.Name = cr.ObjectMeta.Name + "-" + role
+		pvcTemplate.Name = GetRedisName(cr) + "-" + role
 		if storageSpec.VolumeClaimTemplate.Spec.AccessModes == nil {
 			pvcTemplate.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
 		} else {
@@ -339,3 +909,12 @@ func CreatePVCTemplate(cr *redisv1beta1.Redis, role string) corev1.PersistentVol
 	}
 	return pvcTemplate
 }
+
+// getRedisPasswordEnv returns the environment variable holding the redis password from its secret, none when redis
+// has no password
+func getRedisPasswordEnv(cr *redisv1beta1.Redis, name string) []corev1.EnvVar {
+	if !isRedisPasswordSet(cr) {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: getRedisPasswordSecretKeySelector(cr)}}}
+}
@@ -0,0 +1,507 @@
+package k8sutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+func newTestStorage(size string) *redisv1beta1.Storage {
+	return &redisv1beta1.Storage{
+		VolumeClaimTemplate: corev1.PersistentVolumeClaim{
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+		},
+	}
+}
+
+func TestCreateRedisMasterRecreatesOnVolumeClaimTemplateChange(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.Storage = newTestStorage("1Gi")
+	CreateRedisMaster(cr)
+
+	cr.Spec.Storage = newTestStorage("2Gi")
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected statefulset to be recreated: %v", err)
+	}
+	storage := sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	if storage.String() != "2Gi" {
+		t.Fatalf("expected volume claim template of 2Gi, got %s", storage.String())
+	}
+}
+
+func TestCreateRedisMasterWaitsForTheDeletedStatefulSet(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.Storage = newTestStorage("1Gi")
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := metav1.Now()
+	sts.DeletionTimestamp = &now
+	if _, err := client.AppsV1().StatefulSets("default").Update(context.TODO(), sts, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cr.Spec.Storage = newTestStorage("2Gi")
+	CreateRedisMaster(cr)
+	sts, err = client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	if sts.DeletionTimestamp == nil || storage.String() != "1Gi" {
+		t.Fatalf("expected the statefulset being deleted to be left alone, got %s", storage.String())
+	}
+}
+
+func TestIsStatefulSetRecreateSafeRefusesRenamedVolumeClaimTemplate(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.Storage = newTestStorage("1Gi")
+	CreateRedisMaster(cr)
+
+	existing, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desired := existing.DeepCopy()
+	desired.Spec.VolumeClaimTemplates[0].Name = "other"
+	if isStatefulSetRecreateSafe(existing, desired) {
+		t.Fatal("expected recreating with a renamed volume claim template to be refused")
+	}
+}
+
+func TestRestartedAtAnnotationRestartsSlavesBeforeMasters(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	CreateRedisSlave(cr)
+
+	cr.Annotations = map[string]string{RedisRestartedAtAnnotation: "2021-01-01T00:00:00Z"}
+	UpdateRedisPodRestarts(context.TODO(), cr)
+	if restartedAt := getPodRestart(cr, "slave").RestartedAt; restartedAt != "2021-01-01T00:00:00Z" {
+		t.Fatalf("expected the slaves to restart first, got restartedAt %q", restartedAt)
+	}
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected master statefulset: %v", err)
+	}
+	if restartedAt, ok := sts.Spec.Template.Annotations[RedisRestartedAtAnnotation]; ok {
+		t.Fatalf("expected masters to wait for the slaves to restart, got restartedAt %s", restartedAt)
+	}
+}
+
+func TestRestartedAtAnnotationRestartsStandalone(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(1)
+	cr.Spec.Mode = "standalone"
+	CreateRedisStandalone(cr)
+
+	cr.Annotations = map[string]string{RedisRestartedAtAnnotation: "2021-01-01T00:00:00Z"}
+	UpdateRedisPodRestarts(context.TODO(), cr)
+	CreateRedisStandalone(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-standalone", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected standalone statefulset: %v", err)
+	}
+	if restartedAt := sts.Spec.Template.Annotations[RedisRestartedAtAnnotation]; restartedAt != "2021-01-01T00:00:00Z" {
+		t.Fatalf("expected restartedAt to be propagated to the pod template, got %q", restartedAt)
+	}
+}
+
+func TestLegacyServiceStatefulSetIsMigratedToHeadlessService(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	legacy := GenerateStateFulSetsDef(cr, map[string]string{"app": "redis-master", "role": "master"}, "master", cr.Spec.Size)
+	legacy.Spec.ServiceName = "redis-master"
+	if _, err := client.AppsV1().StatefulSets("default").Create(context.TODO(), legacy, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if roles := GetLegacyServiceRoles(cr); len(roles) != 1 || roles[0] != "master" {
+		t.Fatalf("expected the master statefulset to be reported as legacy, got %v", roles)
+	}
+
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected statefulset to be recreated: %v", err)
+	}
+	if sts.Spec.ServiceName != "redis-master-headless" {
+		t.Fatalf("expected statefulset to be governed by the headless service, got %s", sts.Spec.ServiceName)
+	}
+	if roles := GetLegacyServiceRoles(cr); len(roles) != 0 {
+		t.Fatalf("expected no legacy statefulsets after the migration, got %v", roles)
+	}
+}
+
+func TestDNSWaitResolvesThePodRecord(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.DNSWait = &redisv1beta1.DNSWait{Enabled: true}
+	container := GenerateDNSWaitContainerDef(cr, "slave")
+	for _, env := range container.Env {
+		if env.Name == "POD_FQDN" {
+			if env.Value != "$(POD_NAME).redis-slave-headless.default.svc" {
+				t.Errorf("expected the record of the pod in the headless service, got %s", env.Value)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected the pod record in the environment, got %v", container.Env)
+}
+
+func TestStartupProbeIsEnabledForLargeStorage(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.Storage = newTestStorage("1Gi")
+	if probe := generateStartupProbe(cr, "master"); probe != nil {
+		t.Fatal("expected no startup probe for small storage")
+	}
+	cr.Spec.Storage = newTestStorage("50Gi")
+	if probe := generateStartupProbe(cr, "master"); probe == nil || probe.FailureThreshold != 360 {
+		t.Fatalf("expected the default startup probe for large storage, got %v", probe)
+	}
+	disabled := false
+	cr.Spec.StartupProbe = &redisv1beta1.StartupProbe{Enabled: &disabled}
+	if probe := generateStartupProbe(cr, "master"); probe != nil {
+		t.Fatal("expected the startup probe to be disabled")
+	}
+}
+
+func TestRoleProbesOverrideClusterProbes(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	clusterThreshold, slaveThreshold, slaveDelay := int32(4), int32(10), int32(60)
+	cr.Spec.Probes = &redisv1beta1.Probes{
+		Command:   []string{"redis-cli", "ping"},
+		Liveness:  &redisv1beta1.ProbeTiming{FailureThreshold: &clusterThreshold},
+		Readiness: &redisv1beta1.ProbeTiming{FailureThreshold: &clusterThreshold},
+	}
+	cr.Spec.Slave.Probes = &redisv1beta1.Probes{
+		Liveness: &redisv1beta1.ProbeTiming{FailureThreshold: &slaveThreshold, InitialDelaySeconds: &slaveDelay},
+	}
+
+	master := GenerateContainerDef(cr, "master")
+	if master.LivenessProbe.FailureThreshold != clusterThreshold || master.ReadinessProbe.FailureThreshold != clusterThreshold {
+		t.Fatalf("expected the master to fall back to the cluster probes, got %v and %v", master.LivenessProbe, master.ReadinessProbe)
+	}
+	if master.LivenessProbe.InitialDelaySeconds != graceTime {
+		t.Fatalf("expected the master to keep the default initial delay, got %d", master.LivenessProbe.InitialDelaySeconds)
+	}
+	slave := GenerateContainerDef(cr, "slave")
+	if slave.LivenessProbe.FailureThreshold != slaveThreshold || slave.LivenessProbe.InitialDelaySeconds != slaveDelay {
+		t.Fatalf("expected the slave liveness probe to use the slave overrides, got %v", slave.LivenessProbe)
+	}
+	if slave.ReadinessProbe.FailureThreshold != clusterThreshold {
+		t.Fatalf("expected the slave readiness probe to fall back to the cluster probes, got %v", slave.ReadinessProbe)
+	}
+	if command := slave.LivenessProbe.Exec.Command; len(command) != 2 || command[0] != "redis-cli" {
+		t.Fatalf("expected the slave to fall back to the cluster probe command, got %v", command)
+	}
+}
+
+func TestRoleStartupProbeOverridesClusterStartupProbe(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	enabled, clusterThreshold, slaveThreshold := true, int32(100), int32(1000)
+	cr.Spec.StartupProbe = &redisv1beta1.StartupProbe{Enabled: &enabled, FailureThreshold: &clusterThreshold}
+	cr.Spec.Slave.StartupProbe = &redisv1beta1.StartupProbe{FailureThreshold: &slaveThreshold}
+	if probe := generateStartupProbe(cr, "master"); probe == nil || probe.FailureThreshold != clusterThreshold {
+		t.Fatalf("expected the master to use the cluster startup probe, got %v", probe)
+	}
+	if probe := generateStartupProbe(cr, "slave"); probe == nil || probe.FailureThreshold != slaveThreshold || probe.PeriodSeconds != 10 {
+		t.Fatalf("expected the slave startup probe to override the failure threshold only, got %v", probe)
+	}
+	disabled := false
+	cr.Spec.Master.StartupProbe = &redisv1beta1.StartupProbe{Enabled: &disabled}
+	if probe := generateStartupProbe(cr, "master"); probe != nil {
+		t.Fatal("expected the master startup probe to be disabled by the master override")
+	}
+}
+
+func TestCreateRedisMasterRecreatesOnPodManagementPolicyChange(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+
+	cr.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected statefulset to be recreated: %v", err)
+	}
+	if sts.Spec.PodManagementPolicy != appsv1.ParallelPodManagement {
+		t.Fatalf("expected Parallel pod management policy, got %s", sts.Spec.PodManagementPolicy)
+	}
+}
+
+func TestCreateRedisMasterDefersScaleDownDuringRollout(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(5)
+	CreateRedisMaster(cr)
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sts.Status = appsv1.StatefulSetStatus{CurrentRevision: "redis-master-1", UpdateRevision: "redis-master-2", UpdatedReplicas: 2}
+	if _, err := client.AppsV1().StatefulSets("default").UpdateStatus(context.TODO(), sts, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cr = newTestRedisCluster(3)
+	CreateRedisMaster(cr)
+	sts, _ = client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if *sts.Spec.Replicas != 5 {
+		t.Fatalf("expected scale down to wait for the rollout, got %d replicas", *sts.Spec.Replicas)
+	}
+
+	sts.Status = appsv1.StatefulSetStatus{CurrentRevision: "redis-master-2", UpdateRevision: "redis-master-2", UpdatedReplicas: 5}
+	if _, err := client.AppsV1().StatefulSets("default").UpdateStatus(context.TODO(), sts, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	CreateRedisMaster(cr)
+	sts, _ = client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if *sts.Spec.Replicas != 3 {
+		t.Fatalf("expected scale down once the rollout completed, got %d replicas", *sts.Spec.Replicas)
+	}
+}
+
+func TestRedisResourcesFallBackToOperatorDefaults(t *testing.T) {
+	defaults := defaultRedisResources
+	t.Cleanup(func() { SetDefaultRedisResources(defaults) })
+	SetDefaultRedisResources(corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	})
+	cr := newTestRedisCluster(3)
+	resources := GenerateContainerDef(cr, "master").Resources
+	if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "100m" {
+		t.Fatalf("expected the default cpu request, got %s", cpu.String())
+	}
+	if _, ok := resources.Limits[corev1.ResourceCPU]; ok {
+		t.Fatal("expected no cpu limit without a default or a spec value")
+	}
+
+	cr.Spec.GlobalConfig.Resources = &redisv1beta1.Resources{
+		ResourceRequests: redisv1beta1.ResourceDescription{CPU: "500m"},
+	}
+	resources = GenerateContainerDef(cr, "master").Resources
+	if cpu := resources.Requests[corev1.ResourceCPU]; cpu.String() != "500m" {
+		t.Fatalf("expected the spec cpu request to take precedence, got %s", cpu.String())
+	}
+	if memory := resources.Requests[corev1.ResourceMemory]; memory.String() != "128Mi" {
+		t.Fatalf("expected the default memory request for the value missing from the spec, got %s", memory.String())
+	}
+}
+
+func TestEntrypointConfigMapRunsTheRedisImageEntrypoint(t *testing.T) {
+	useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.Master.EntrypointConfigMap = &redisv1beta1.EntrypointConfigMap{Name: "redis-entrypoint"}
+	master := GenerateContainerDef(cr, "master")
+	if len(master.Command) != 1 || master.Command[0] != entrypointMountPath+"/"+entrypointDefaultKey {
+		t.Fatalf("expected the master to run the entrypoint script, got %v", master.Command)
+	}
+	if len(master.Args) != 1 || master.Args[0] != redisImageEntrypoint {
+		t.Fatalf("expected the entrypoint script to get the redis image entrypoint, got %v", master.Args)
+	}
+	if slave := GenerateContainerDef(cr, "slave"); len(slave.Command) != 0 {
+		t.Fatalf("expected the slave to keep the image entrypoint, got %v", slave.Command)
+	}
+	statefulSet := GenerateStateFulSetsDef(cr, map[string]string{"app": "redis-master", "role": "master"}, "master", cr.Spec.Size)
+	found := false
+	for _, volume := range statefulSet.Spec.Template.Spec.Volumes {
+		found = found || (volume.Name == "entrypoint" && volume.ConfigMap != nil && volume.ConfigMap.Name == "redis-entrypoint")
+	}
+	if !found {
+		t.Fatal("expected the master statefulset to mount the entrypoint configmap")
+	}
+}
+
+func TestReplicationSyncReadinessProbe(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	if command := getReadinessProbeCommand(cr, "slave"); len(command) != 2 || command[0] != "bash" {
+		t.Fatalf("expected the cluster slaves to keep the healthcheck readiness probe, got %v", command)
+	}
+	cr.Spec.Mode = "standalone"
+	cr.Spec.ReplicaOf = &redisv1beta1.ReplicaOf{Host: "redis.example.com"}
+	command := getReadinessProbeCommand(cr, "standalone")
+	if len(command) != 3 || !strings.Contains(command[2], "master_link_status:up") || !strings.Contains(command[2], "master_sync_in_progress:0") {
+		t.Fatalf("expected the standalone replica readiness probe to wait for the sync, got %v", command)
+	}
+	if liveness := getProbeCommand(cr, "standalone"); len(liveness) != 2 {
+		t.Fatalf("expected the liveness probe to ignore the sync, got %v", liveness)
+	}
+	disabled := false
+	cr.Spec.Probes = &redisv1beta1.Probes{ReplicationSync: &disabled}
+	if command := getReadinessProbeCommand(cr, "standalone"); len(command) != 2 {
+		t.Fatalf("expected the sync check to be disabled, got %v", command)
+	}
+}
+
+func TestGeneratedPasswordIsResolvedWithoutChangingTheSpec(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.GlobalConfig.GeneratePassword = true
+	statefulSet := GenerateStateFulSetsDef(cr, map[string]string{"app": "redis-master", "role": "master"}, "master", cr.Spec.Size)
+	if cr.Spec.GlobalConfig.ExistingPasswordSecret != nil {
+		t.Fatal("expected the spec to be left unchanged")
+	}
+	var selector *corev1.SecretKeySelector
+	for _, env := range statefulSet.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "REDIS_PASSWORD" {
+			selector = env.ValueFrom.SecretKeyRef
+		}
+	}
+	if selector == nil || selector.Name != "redis-generated-password" || selector.Key != "password" {
+		t.Fatalf("expected the redis container to read the generated password, got %v", selector)
+	}
+	secret, err := client.CoreV1().Secrets("default").Get(context.TODO(), "redis-generated-password", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the generated password secret: %v", err)
+	}
+	if password := getRedisPassword(cr); password == "" || password != string(secret.Data["password"]) {
+		t.Fatalf("expected the generated password, got %q", password)
+	}
+}
+
+func TestRotatedPasswordRestartsSlavesBeforeMasters(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.GlobalConfig.GeneratePassword = true
+	UpdateRedisPodRestarts(context.TODO(), cr)
+	CreateRedisMaster(cr)
+	CreateRedisSlave(cr)
+	master, _ := client.AppsV1().StatefulSets("default").Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	checksum := master.Spec.Template.Annotations[secretChecksumAnnotation]
+	if checksum == "" {
+		t.Fatal("expected the secret checksum in the master pod template")
+	}
+
+	secret, _ := client.CoreV1().Secrets("default").Get(context.TODO(), "redis-generated-password", metav1.GetOptions{})
+	secret.Data["password"] = []byte("rotated")
+	if _, err := client.CoreV1().Secrets("default").Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	UpdateRedisPodRestarts(context.TODO(), cr)
+	if rotated := getPodRestart(cr, "slave").SecretChecksum; rotated == checksum || rotated == "" {
+		t.Fatalf("expected the slaves to roll to the rotated password, got checksum %q", rotated)
+	}
+	if kept := getPodRestart(cr, "master").SecretChecksum; kept != checksum {
+		t.Fatalf("expected the masters to wait for the slaves, got checksum %q", kept)
+	}
+}
+
+func TestExporterListenAddressIsOnlySetForCustomPort(t *testing.T) {
+	useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.RedisExporter.Enabled = true
+	listenAddress := func() string {
+		for _, container := range FinalContainerDef(cr, "master") {
+			for _, env := range container.Env {
+				if env.Name == "REDIS_EXPORTER_WEB_LISTEN_ADDRESS" {
+					return env.Value
+				}
+			}
+		}
+		return ""
+	}
+	if address := listenAddress(); address != "" {
+		t.Errorf("expected no listen address for the default exporter port, got %q", address)
+	}
+	port := int32(9500)
+	cr.Spec.RedisExporter.Port = &port
+	if address := listenAddress(); address != ":9500" {
+		t.Errorf("expected the exporter to listen on :9500, got %q", address)
+	}
+}
+
+func TestPodTemplateDefaultedByTheAPIServerIsUnchanged(t *testing.T) {
+	useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	desired := GenerateStateFulSetsDef(cr, map[string]string{"app": "redis-master", "role": "master"}, "master", cr.Spec.Size).Spec.Template
+	existing := *desired.DeepCopy()
+	existing.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	existing.Spec.DNSPolicy = corev1.DNSClusterFirst
+	existing.Spec.SchedulerName = corev1.DefaultSchedulerName
+	existing.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	existing.Spec.Containers[0].TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	if isPodTemplateChanged(existing, desired) {
+		t.Fatal("expected the fields defaulted by the API server not to change the pod template")
+	}
+	existing.Spec.Containers[0].Image = "quay.io/opstree/redis:v6.0"
+	if !isPodTemplateChanged(existing, desired) {
+		t.Fatal("expected a different image to change the pod template")
+	}
+}
+
+func TestCreateRedisMasterWithEmptyStatefulSetOnNotFound(t *testing.T) {
+	client := useFakeK8sClient(t)
+	// like the real typed client, a missing statefulset is returned as an empty object along with the error
+	client.PrependReactor("get", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &appsv1.StatefulSet{}, errors.NewNotFound(appsv1.Resource("statefulsets"), name)
+	})
+	cr := newTestRedisCluster(3)
+
+	CreateRedisMaster(cr)
+	if _, err := client.Tracker().Get(appsv1.SchemeGroupVersion.WithResource("statefulsets"), "default", "redis-master"); err != nil {
+		t.Fatalf("expected the statefulset to be created: %v", err)
+	}
+}
+
+func TestCreateRedisMasterRestoresAReplacedEntrypoint(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	cr.Spec.Master.EntrypointConfigMap = &redisv1beta1.EntrypointConfigMap{Name: "redis-entrypoint"}
+	CreateRedisMaster(cr)
+	statefulSets := client.AppsV1().StatefulSets("default")
+	existing, err := statefulSets.Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := existing.Spec.Template.Spec.Containers[0].Command
+	existing.Spec.Template.Spec.Containers[0].Command = []string{"/scripts/other.sh"}
+	if _, err := statefulSets.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	CreateRedisMaster(cr)
+	updated, err := statefulSets.Get(context.TODO(), "redis-master", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command := updated.Spec.Template.Spec.Containers[0].Command; len(command) != 1 || command[0] != want[0] {
+		t.Fatalf("expected the entrypoint with as many arguments to be restored, got %v", command)
+	}
+}
+
+func TestGetRolloutOperationToleratesStatefulSetsWithoutContainers(t *testing.T) {
+	useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	desired := GenerateStateFulSetsDef(cr, map[string]string{"app": "redis-master", "role": "master"}, "master", cr.Spec.Size)
+	if operation := getRolloutOperation(StatefulInterface{Existing: &appsv1.StatefulSet{}, Desired: desired}); operation != "rolling restart of redis-master" {
+		t.Errorf("expected a rolling restart for an existing statefulset without containers, got %q", operation)
+	}
+	existing := desired.DeepCopy()
+	existing.Spec.Template.Spec.Containers[0].Image = "quay.io/opstree/redis:v6.0"
+	if operation := getRolloutOperation(StatefulInterface{Existing: existing, Desired: desired}); operation != "upgrade of redis-master to quay.io/opstree/redis:v6.2" {
+		t.Errorf("expected an upgrade for a changed image, got %q", operation)
+	}
+}
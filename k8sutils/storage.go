@@ -0,0 +1,52 @@
+package k8sutils
+
+import (
+	"fmt"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+)
+
+// defaultStorageNearFullThreshold is the data directory usage percentage above which the storage is reported as nearly full
+const defaultStorageNearFullThreshold = 85
+
+// GetStorageNearFullThreshold returns the data directory usage percentage above which the storage is nearly full
+func GetStorageNearFullThreshold(cr *redisv1beta1.Redis) int {
+	if cr.Spec.Storage != nil && cr.Spec.Storage.NearFullThreshold != nil {
+		return int(*cr.Spec.Storage.NearFullThreshold)
+	}
+	return defaultStorageNearFullThreshold
+}
+
+// parseDiskUsage returns the used percentage of the filesystem from the output of df -P
+func parseDiskUsage(output string) (int, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %s", output)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 5 {
+		return 0, fmt.Errorf("unexpected df output: %s", output)
+	}
+	return strconv.Atoi(strings.TrimSuffix(fields[4], "%"))
+}
+
+// GetStorageUsage returns the used percentage of the data directory of every redis pod
+func GetStorageUsage(cr *redisv1beta1.Redis) map[string]int {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	usage := map[string]int{}
+	for _, pod := range getRedisPods(cr) {
+		output, err := executeCommandOutput(cr, []string{"df", "-P", "/data"}, pod.Name, GetRedisName(cr)+"-"+pod.Role)
+		if err != nil {
+			reqLogger.Info("Could not read the storage usage of redis", "Pod.Name", pod.Name, "Reason", strings.TrimSpace(output+" "+err.Error()))
+			continue
+		}
+		used, err := parseDiskUsage(output)
+		if err != nil {
+			reqLogger.Info("Could not parse the storage usage of redis", "Pod.Name", pod.Name, "Reason", err.Error())
+			continue
+		}
+		usage[pod.Name] = used
+	}
+	return usage
+}
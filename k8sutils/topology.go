@@ -0,0 +1,141 @@
+package k8sutils
+
+import (
+	"bufio"
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// nodeLookupForbidden is set once the operator is denied reading nodes, like when it runs with namespaced roles,
+// so that the zones of the redis pods are left out instead of failing every lookup
+var nodeLookupForbidden int32
+
+// redisClusterNode is a single entry of the CLUSTER NODES output
+type redisClusterNode struct {
+	ID          string
+	IP          string
+	Flags       string
+	MasterID    string
+	ConfigEpoch int64
+	LinkState   string
+	Slots       []string
+}
+
+// parseRedisClusterNodes parses the output of CLUSTER NODES command
+func parseRedisClusterNodes(output string) []redisClusterNode {
+	var nodes []redisClusterNode
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		address := strings.Split(strings.Split(fields[1], "@")[0], ",")[0]
+		node := redisClusterNode{
+			ID:       fields[0],
+			IP:       address[:strings.LastIndex(address, ":")],
+			Flags:    fields[2],
+			MasterID: fields[3],
+		}
+		if len(fields) > 6 {
+			node.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+		}
+		if len(fields) > 7 {
+			node.LinkState = fields[7]
+		}
+		if len(fields) > 8 {
+			node.Slots = fields[8:]
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// getNodePlacement returns the pod, node and zone information for a redis pod
+func getNodePlacement(cr *redisv1beta1.Redis, pod corev1.Pod) redisv1beta1.NodePlacement {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	placement := redisv1beta1.NodePlacement{
+		PodName:  pod.Name,
+		NodeName: pod.Spec.NodeName,
+	}
+	if pod.Spec.NodeName == "" || atomic.LoadInt32(&nodeLookupForbidden) == 1 {
+		return placement
+	}
+	node, err := GenerateK8sClient().CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+	if errors.IsForbidden(err) {
+		if atomic.CompareAndSwapInt32(&nodeLookupForbidden, 0, 1) {
+			reqLogger.Info("Operator is not allowed to read nodes, zones of the redis pods are not reported", "Reason", err.Error())
+		}
+		return placement
+	}
+	if err != nil {
+		reqLogger.Error(err, "Could not get node info", "Node.Name", pod.Spec.NodeName)
+		return placement
+	}
+	if zone, ok := node.Labels[corev1.LabelZoneFailureDomainStable]; ok {
+		placement.Zone = zone
+	} else {
+		placement.Zone = node.Labels[corev1.LabelZoneFailureDomain]
+	}
+	return placement
+}
+
+// getRedisPodsByIP returns the redis cluster pods indexed by their IP
+func getRedisPodsByIP(cr *redisv1beta1.Redis) (map[string]corev1.Pod, error) {
+	podsByIP := map[string]corev1.Pod{}
+	for _, role := range []string{"master", "slave"} {
+		pods, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: "app=" + GetRedisName(cr) + "-" + role,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			podsByIP[pod.Status.PodIP] = pod
+		}
+	}
+	return podsByIP, nil
+}
+
+// GetShardTopology returns the placement of masters and replicas for each redis cluster shard
+func GetShardTopology(ctx context.Context, cr *redisv1beta1.Redis) []redisv1beta1.ShardTopology {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return nil
+	}
+
+	nodes := parseRedisClusterNodes(checkRedisCluster(ctx, cr))
+	var shards []redisv1beta1.ShardTopology
+	shardIndex := map[string]int{}
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "master") {
+			continue
+		}
+		pod, ok := podsByIP[node.IP]
+		if !ok {
+			continue
+		}
+		shardIndex[node.ID] = len(shards)
+		shards = append(shards, redisv1beta1.ShardTopology{Master: getNodePlacement(cr, pod)})
+	}
+	for _, node := range nodes {
+		if !strings.Contains(node.Flags, "slave") {
+			continue
+		}
+		pod, ok := podsByIP[node.IP]
+		index, found := shardIndex[node.MasterID]
+		if !ok || !found {
+			continue
+		}
+		shards[index].Replicas = append(shards[index].Replicas, getNodePlacement(cr, pod))
+	}
+	return shards
+}
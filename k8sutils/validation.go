@@ -0,0 +1,726 @@
+package k8sutils
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// redisReservedFileDescriptors is the number of file descriptors redis keeps for its own use
+	redisReservedFileDescriptors = 32
+	// redisFileDescriptorLimit is the usual open files limit of containers
+	redisFileDescriptorLimit = 65536
+	// redisMinProtoMaxBulkLen is the smallest proto-max-bulk-len accepted by redis
+	redisMinProtoMaxBulkLen = 1024 * 1024
+	// redisDefaultReplBacklogSize is the default repl-backlog-size of redis
+	redisDefaultReplBacklogSize = 1024 * 1024
+	// defaultSomaxconn is the net.core.somaxconn of linux 5.4 and later, older kernels default to 128
+	defaultSomaxconn = 4096
+)
+
+// validateReplBacklogSize returns an error when the replication backlog size is not a positive redis memory value
+func validateReplBacklogSize(value string) []error {
+	backlogSize, err := parseRedisMemory(value)
+	if err != nil {
+		return []error{fmt.Errorf("replication.backlogSize %q is not a valid redis memory value: %v", value, err)}
+	}
+	if backlogSize <= 0 {
+		return []error{fmt.Errorf("replication.backlogSize must be positive")}
+	}
+	return nil
+}
+
+// redisMaxMemoryPolicies are the eviction policies of redis, mapped to the major and minor redis version introducing them
+var redisMaxMemoryPolicies = map[string][2]int{
+	"noeviction":      {0, 0},
+	"allkeys-lru":     {0, 0},
+	"allkeys-lfu":     {4, 0},
+	"allkeys-random":  {0, 0},
+	"volatile-lru":    {0, 0},
+	"volatile-lfu":    {4, 0},
+	"volatile-random": {0, 0},
+	"volatile-ttl":    {0, 0},
+}
+
+// redisKeyspaceEventClasses are the characters of the notify-keyspace-events classes
+const redisKeyspaceEventClasses = "KEg$lshzxetmndA"
+
+// clientOutputBufferLimitPattern matches the <hard limit> <soft limit> <soft seconds> of a client output buffer limit
+var clientOutputBufferLimitPattern = regexp.MustCompile(`(?i)^\d+([kmg]b?)? \d+([kmg]b?)? \d+$`)
+
+// getSomaxconn returns the net.core.somaxconn of the redis pods, set in the sysctls of the pod security context or
+// the kernel default
+func getSomaxconn(cr *redisv1beta1.Redis) int64 {
+	if cr.Spec.SecurityContext != nil {
+		for _, sysctl := range cr.Spec.SecurityContext.Sysctls {
+			if sysctl.Name != "net.core.somaxconn" {
+				continue
+			}
+			if value, err := strconv.ParseInt(sysctl.Value, 10, 64); err == nil {
+				return value
+			}
+		}
+	}
+	return defaultSomaxconn
+}
+
+// ValidateRedisSpec will validate the redis spec and return an error for every invalid setting
+func ValidateRedisSpec(cr *redisv1beta1.Redis) error {
+	var errs []error
+	if cr.Spec.Mode != "cluster" {
+		if cr.Spec.Master.EntrypointConfigMap != nil || cr.Spec.Slave.EntrypointConfigMap != nil {
+			errs = append(errs, fmt.Errorf("master.entrypointConfigMap and slave.entrypointConfigMap are only supported in cluster mode"))
+		}
+		if cr.Spec.Slave.ReplicaReadOnly != nil {
+			errs = append(errs, fmt.Errorf("slave.replicaReadOnly is only supported in cluster mode"))
+		}
+		if cr.Spec.WriteService != nil && cr.Spec.WriteService.Enabled {
+			errs = append(errs, fmt.Errorf("writeService is only supported in cluster mode"))
+		}
+		if cr.Spec.ZoneTopology != nil {
+			errs = append(errs, fmt.Errorf("zoneTopology is only supported in cluster mode"))
+		}
+		if cr.Spec.Slave.ReplicaServeStaleData != nil {
+			errs = append(errs, fmt.Errorf("slave.replicaServeStaleData is only supported in cluster mode"))
+		}
+		if cr.Spec.Slave.ReplicaPriority != nil {
+			errs = append(errs, fmt.Errorf("slave.replicaPriority is only supported in cluster mode"))
+		}
+	} else if cr.Spec.Slave.ReplicaPriority != nil {
+		errs = append(errs, validateReplicaPriority(cr)...)
+	}
+	errs = append(errs, validateResourceNames(cr)...)
+	if cr.Spec.GlobalConfig.GeneratePassword && (cr.Spec.GlobalConfig.Password != nil || cr.Spec.GlobalConfig.ExistingPasswordSecret != nil) {
+		errs = append(errs, fmt.Errorf("global.generatePassword can't be combined with global.password or global.existingPasswordSecret"))
+	}
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.PasswordFile {
+		if cr.Spec.GlobalConfig.Password == nil && cr.Spec.GlobalConfig.ExistingPasswordSecret == nil && !cr.Spec.GlobalConfig.GeneratePassword {
+			errs = append(errs, fmt.Errorf("redisExporter.passwordFile needs a redis password"))
+		}
+		major, minor, ok := parseImageVersion(cr.Spec.RedisExporter.Image)
+		if ok && (major < 1 || (major == 1 && minor < 28)) {
+			errs = append(errs, fmt.Errorf("redisExporter.passwordFile needs redis exporter v1.28 or later, image is %s", cr.Spec.RedisExporter.Image))
+		}
+	}
+	if cr.Spec.RedisExporter != nil && cr.Spec.RedisExporter.Probe != nil && cr.Spec.RedisExporter.Probe.Path != "" && !strings.HasPrefix(cr.Spec.RedisExporter.Probe.Path, "/") {
+		errs = append(errs, fmt.Errorf("redisExporter.probe.path must start with /"))
+	}
+	if cr.Spec.RedisExporter != nil {
+		errs = append(errs, validateExporterOverrides(cr.Spec.RedisExporter)...)
+	}
+	if port := getRedisExporterPort(cr); port == redisPort || port == redisClusterBusPort {
+		errs = append(errs, fmt.Errorf("redisExporter.port %d overlaps the redis port or the redis cluster bus port", port))
+	}
+	if len(cr.Spec.Sidecars) > 0 {
+		errs = append(errs, validateSidecars(cr)...)
+	}
+	if cr.Spec.Storage != nil && cr.Spec.Storage.NearFullThreshold != nil && (*cr.Spec.Storage.NearFullThreshold < 1 || *cr.Spec.Storage.NearFullThreshold > 100) {
+		errs = append(errs, fmt.Errorf("storage.nearFullThreshold must be between 1 and 100, got %d", *cr.Spec.Storage.NearFullThreshold))
+	}
+	errs = append(errs, validateRedisResources(cr)...)
+	errs = append(errs, validateTLSConfig(cr)...)
+	shards := map[int32]bool{}
+	for _, override := range cr.Spec.ShardOverrides {
+		if cr.Spec.Mode != "cluster" {
+			errs = append(errs, fmt.Errorf("shardOverrides are only supported in cluster mode"))
+			break
+		}
+		if cr.Spec.Size != nil && (override.Shard < 0 || override.Shard >= *cr.Spec.Size) {
+			errs = append(errs, fmt.Errorf("shardOverrides shard %d is out of range, the cluster has %d shards", override.Shard, *cr.Spec.Size))
+		}
+		if shards[override.Shard] {
+			errs = append(errs, fmt.Errorf("shardOverrides shard %d is listed more than once", override.Shard))
+		}
+		shards[override.Shard] = true
+	}
+	for _, podName := range cr.Spec.QuarantinePods {
+		if cr.Spec.Mode != "cluster" {
+			errs = append(errs, fmt.Errorf("quarantinePods are only supported in cluster mode"))
+			break
+		}
+		if podName == GetRedisName(cr)+"-master-0" {
+			errs = append(errs, fmt.Errorf("quarantinePods can't include %s, the operator manages the cluster through it", podName))
+			continue
+		}
+		if !isRedisClusterPodName(cr, podName) {
+			errs = append(errs, fmt.Errorf("quarantinePods pod %s is not a pod of the redis cluster", podName))
+		}
+	}
+	if limits := cr.Spec.ClientOutputBufferLimit; limits != nil {
+		classes := []string{"normal", "replica", "pubsub"}
+		for i, limit := range []*string{limits.Normal, limits.Replica, limits.PubSub} {
+			if limit != nil && !clientOutputBufferLimitPattern.MatchString(*limit) {
+				errs = append(errs, fmt.Errorf("clientOutputBufferLimit.%s %q must be formatted as <hard limit> <soft limit> <soft seconds>", classes[i], *limit))
+			}
+		}
+	}
+	if replication := cr.Spec.Replication; replication != nil {
+		if replication.DisklessSyncDelay != nil && *replication.DisklessSyncDelay < 0 {
+			errs = append(errs, fmt.Errorf("replication.disklessSyncDelay must not be negative"))
+		}
+		if replication.LagThresholdBytes != nil && *replication.LagThresholdBytes < 0 {
+			errs = append(errs, fmt.Errorf("replication.lagThresholdBytes must not be negative"))
+		}
+		if load := replication.DisklessLoad; load != nil && *load != "disabled" && *load != "on-empty-db" && *load != "swapdb" {
+			errs = append(errs, fmt.Errorf("replication.disklessLoad must be disabled, on-empty-db or swapdb, got %q", *load))
+		}
+		if replication.BacklogTTL != nil && *replication.BacklogTTL < 0 {
+			errs = append(errs, fmt.Errorf("replication.backlogTTL must not be negative"))
+		}
+		if replication.BacklogSize != nil {
+			errs = append(errs, validateReplBacklogSize(*replication.BacklogSize)...)
+		}
+	}
+	if defrag := cr.Spec.ActiveDefrag; defrag != nil {
+		errs = append(errs, validateActiveDefrag(defrag)...)
+	}
+	if cr.Spec.TCPKeepalive != nil && *cr.Spec.TCPKeepalive < 0 {
+		errs = append(errs, fmt.Errorf("tcpKeepalive must not be negative"))
+	}
+	if cr.Spec.ClientTimeout != nil && *cr.Spec.ClientTimeout < 0 {
+		errs = append(errs, fmt.Errorf("clientTimeout must not be negative"))
+	}
+	if cr.Spec.DegradedGracePeriodSeconds != nil && *cr.Spec.DegradedGracePeriodSeconds < 0 {
+		errs = append(errs, fmt.Errorf("degradedGracePeriodSeconds must not be negative"))
+	}
+	if cr.Spec.VolumeSnapshot != nil {
+		if cr.Spec.Storage == nil {
+			errs = append(errs, fmt.Errorf("volumeSnapshot needs storage to be configured"))
+		}
+		if cr.Spec.VolumeSnapshot.Interval != "" {
+			if interval, err := time.ParseDuration(cr.Spec.VolumeSnapshot.Interval); err != nil || interval < time.Minute {
+				errs = append(errs, fmt.Errorf("volumeSnapshot.interval %q must be a duration of at least 1m", cr.Spec.VolumeSnapshot.Interval))
+			}
+		}
+	}
+	if cr.Spec.RestoreFrom != nil {
+		if cr.Spec.Storage == nil {
+			errs = append(errs, fmt.Errorf("restoreFrom needs storage to be configured"))
+		}
+		masters := 1
+		if cr.Spec.Mode == "cluster" && cr.Spec.Size != nil {
+			masters = int(*cr.Spec.Size)
+		}
+		if len(cr.Spec.RestoreFrom.VolumeSnapshots) != masters {
+			errs = append(errs, fmt.Errorf("restoreFrom needs one volume snapshot for each of the %d masters, got %d", masters, len(cr.Spec.RestoreFrom.VolumeSnapshots)))
+		}
+	}
+	if cr.Spec.Proxy != nil {
+		if cr.Spec.Mode != "cluster" {
+			errs = append(errs, fmt.Errorf("proxy is only supported in cluster mode"))
+		}
+		if cr.Spec.Proxy.Port != nil && (*cr.Spec.Proxy.Port < 1 || *cr.Spec.Proxy.Port > 65535) {
+			errs = append(errs, fmt.Errorf("proxy.port must be between 1 and 65535"))
+		}
+		if cr.Spec.Proxy.Replicas != nil && *cr.Spec.Proxy.Replicas < 1 {
+			errs = append(errs, fmt.Errorf("proxy.replicas must be at least 1"))
+		}
+	}
+	if cr.Spec.Storage != nil && IsPersistenceDisabled(cr) {
+		errs = append(errs, fmt.Errorf("persistence.disableRDB with appendonly disabled leaves nothing to persist on the storage, remove storage for a cache only setup"))
+	}
+	if cr.Spec.Persistence != nil && cr.Spec.Persistence.AOFRewriteSchedule != nil {
+		if _, err := parseCronSchedule(cr.Spec.Persistence.AOFRewriteSchedule.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("persistence.aofRewriteSchedule.schedule is invalid: %v", err))
+		}
+		if !isAOFEnabled(cr) {
+			errs = append(errs, fmt.Errorf("persistence.aofRewriteSchedule needs appendonly to be enabled"))
+		}
+	}
+	if cr.Spec.MaintenanceWindow != nil {
+		if _, _, _, err := parseMaintenanceWindow(cr.Spec.MaintenanceWindow); err != nil {
+			errs = append(errs, fmt.Errorf("maintenanceWindow is invalid: %v", err))
+		}
+	}
+	if cr.Spec.Finalizer != nil {
+		if cr.Spec.Finalizer.FinalSnapshot && cr.Spec.VolumeSnapshot == nil {
+			errs = append(errs, fmt.Errorf("finalizer.finalSnapshot needs volumeSnapshot to be configured"))
+		}
+		if cr.Spec.Finalizer.StepTimeoutSeconds != nil && *cr.Spec.Finalizer.StepTimeoutSeconds < 1 {
+			errs = append(errs, fmt.Errorf("finalizer.stepTimeoutSeconds must be at least 1"))
+		}
+	}
+	if cr.Spec.ReplicaOf != nil {
+		// redis cluster nodes refuse REPLICAOF, they only replicate a master of their own cluster, which they
+		// follow with CLUSTER REPLICATE
+		if cr.Spec.Mode != "standalone" {
+			errs = append(errs, fmt.Errorf("replicaOf is only supported in standalone mode, redis cluster nodes can only replicate masters of their own cluster"))
+		}
+		if cr.Spec.ReplicaOf.Host == "" {
+			errs = append(errs, fmt.Errorf("replicaOf.host must be set"))
+		}
+		if secret := cr.Spec.ReplicaOf.PasswordSecret; secret != nil && (secret.Name == nil || secret.Key == nil) {
+			errs = append(errs, fmt.Errorf("replicaOf.passwordSecret needs a name and a key"))
+		}
+	}
+	if cr.Spec.Import != nil {
+		if cr.Spec.Mode != "cluster" {
+			errs = append(errs, fmt.Errorf("import is only supported in cluster mode"))
+		}
+		if cr.Spec.RestoreFrom != nil {
+			errs = append(errs, fmt.Errorf("import and restoreFrom can't be combined"))
+		}
+		// the host is part of the shell command of the import job
+		if host := cr.Spec.Import.Host; host == "" || strings.ContainsAny(host, " \t\n'\"$`;&|<>()\\") {
+			errs = append(errs, fmt.Errorf("import.host must be a hostname or IP address, got %q", host))
+		}
+		if cr.Spec.Import.Port != nil && (*cr.Spec.Import.Port < 1 || *cr.Spec.Import.Port > 65535) {
+			errs = append(errs, fmt.Errorf("import.port must be between 1 and 65535"))
+		}
+		if secret := cr.Spec.Import.PasswordSecret; secret != nil && (secret.Name == nil || secret.Key == nil) {
+			errs = append(errs, fmt.Errorf("import.passwordSecret needs a name and a key"))
+		}
+	}
+	if cr.Spec.Functions != nil && !redisVersionAtLeast(cr, 7, 0) {
+		errs = append(errs, fmt.Errorf("functions need redis 7.0 or later"))
+	}
+	if cr.Spec.MaxClients != nil && *cr.Spec.MaxClients < 1 {
+		errs = append(errs, fmt.Errorf("maxClients must be at least 1"))
+	}
+	if cr.Spec.TCPBacklog != nil && *cr.Spec.TCPBacklog < 1 {
+		errs = append(errs, fmt.Errorf("tcpBacklog must be at least 1"))
+	}
+	if pdb := cr.Spec.PodDisruptionBudget; pdb != nil {
+		if pdb.Scope != "" && pdb.Scope != "role" && pdb.Scope != "cluster" {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.scope must be role or cluster, got %q", pdb.Scope))
+		}
+		if pdb.MaxUnavailable != nil && *pdb.MaxUnavailable < 1 {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.maxUnavailable must be at least 1"))
+		}
+		if pdb.QuorumFormula != "" && pdb.QuorumFormula != "majority" && pdb.QuorumFormula != "all-but-one" && pdb.QuorumFormula != "custom" {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.quorumFormula must be majority, all-but-one or custom, got %q", pdb.QuorumFormula))
+		}
+		if pdb.QuorumFormula == "custom" && (pdb.MinAvailable == nil || *pdb.MinAvailable < 0) {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.quorumFormula custom needs a podDisruptionBudget.minAvailable of at least 0"))
+		}
+		if pdb.QuorumFormula != "custom" && pdb.MinAvailable != nil {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.minAvailable is only used with the custom quorumFormula"))
+		}
+		if pdb.Scope == "cluster" && pdb.QuorumFormula != "" {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.quorumFormula only applies to the role scope, the cluster scope uses maxUnavailable"))
+		}
+		if _, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: pdb.MatchExpressions}); err != nil {
+			errs = append(errs, fmt.Errorf("podDisruptionBudget.matchExpressions is invalid: %v", err))
+		}
+	}
+	if cr.Spec.MaxMemoryPolicy != nil {
+		errs = append(errs, validateMaxMemoryPolicy(cr)...)
+	}
+	if level := cr.Spec.LogLevel; level != nil && *level != "debug" && *level != "verbose" && *level != "notice" && *level != "warning" {
+		errs = append(errs, fmt.Errorf("logLevel must be debug, verbose, notice or warning, got %q", *level))
+	}
+	for _, role := range []string{"master", "slave"} {
+		entrypoint := getEntrypointConfigMap(cr, role)
+		if entrypoint == nil {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(entrypoint.Name) {
+			errs = append(errs, fmt.Errorf("%s.entrypointConfigMap.name %q is invalid: %s", role, entrypoint.Name, msg))
+		}
+		if entrypoint.Key != "" {
+			for _, msg := range validation.IsConfigMapKey(entrypoint.Key) {
+				errs = append(errs, fmt.Errorf("%s.entrypointConfigMap.key %q is invalid: %s", role, entrypoint.Key, msg))
+			}
+		}
+	}
+	if cr.Spec.Hz != nil && (*cr.Spec.Hz < 1 || *cr.Spec.Hz > 500) {
+		errs = append(errs, fmt.Errorf("hz must be between 1 and 500, got %d", *cr.Spec.Hz))
+	}
+	if mode := cr.Spec.Supervised; mode != nil && *mode != "no" && *mode != "upstart" && *mode != "systemd" && *mode != "auto" {
+		errs = append(errs, fmt.Errorf("supervised must be no, upstart, systemd or auto, got %q", *mode))
+	}
+	if cr.Spec.Databases != nil {
+		if *cr.Spec.Databases < 1 {
+			errs = append(errs, fmt.Errorf("databases must be at least 1, got %d", *cr.Spec.Databases))
+		} else if cr.Spec.Mode == "cluster" && *cr.Spec.Databases != 1 {
+			errs = append(errs, fmt.Errorf("databases must be 1 in cluster mode, redis cluster only supports database 0"))
+		}
+	}
+	if events := cr.Spec.NotifyKeyspaceEvents; events != nil {
+		for _, class := range *events {
+			if !strings.ContainsRune(redisKeyspaceEventClasses, class) {
+				errs = append(errs, fmt.Errorf("notifyKeyspaceEvents %q has the unknown event class %q, valid classes are %s", *events, class, redisKeyspaceEventClasses))
+				break
+			}
+		}
+	}
+	if cr.Spec.ProtoMaxBulkLen != nil {
+		if bulkLen, err := parseRedisMemory(*cr.Spec.ProtoMaxBulkLen); err != nil {
+			errs = append(errs, fmt.Errorf("protoMaxBulkLen %q is not a valid redis memory value: %v", *cr.Spec.ProtoMaxBulkLen, err))
+		} else if bulkLen < redisMinProtoMaxBulkLen {
+			errs = append(errs, fmt.Errorf("protoMaxBulkLen must be at least 1mb"))
+		}
+	}
+	if cr.Spec.ClusterInit != nil {
+		if cr.Spec.ClusterInit.TimeoutSeconds != nil && *cr.Spec.ClusterInit.TimeoutSeconds < 1 {
+			errs = append(errs, fmt.Errorf("clusterInit.timeoutSeconds must be at least 1, got %d", *cr.Spec.ClusterInit.TimeoutSeconds))
+		}
+		if cr.Spec.ClusterInit.MaxRetries != nil && *cr.Spec.ClusterInit.MaxRetries < 1 {
+			errs = append(errs, fmt.Errorf("clusterInit.maxRetries must be at least 1, got %d", *cr.Spec.ClusterInit.MaxRetries))
+		}
+	}
+	startupProbes := map[string]*redisv1beta1.StartupProbe{"startupProbe": cr.Spec.StartupProbe, "master.startupProbe": cr.Spec.Master.StartupProbe, "slave.startupProbe": cr.Spec.Slave.StartupProbe}
+	for _, name := range []string{"startupProbe", "master.startupProbe", "slave.startupProbe"} {
+		if probe := startupProbes[name]; probe != nil && probe.FailureThreshold != nil && *probe.FailureThreshold < 1 {
+			errs = append(errs, fmt.Errorf("%s.failureThreshold must be at least 1, got %d", name, *probe.FailureThreshold))
+		}
+	}
+	probes := map[string]*redisv1beta1.Probes{"probes": cr.Spec.Probes, "master.probes": cr.Spec.Master.Probes, "slave.probes": cr.Spec.Slave.Probes}
+	for _, name := range []string{"probes", "master.probes", "slave.probes"} {
+		if probes[name] != nil {
+			errs = append(errs, validateProbeTiming(name+".liveness", probes[name].Liveness)...)
+			errs = append(errs, validateProbeTiming(name+".readiness", probes[name].Readiness)...)
+		}
+	}
+	if cr.Spec.ShutdownTimeout != nil && *cr.Spec.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("shutdownTimeout must not be negative"))
+	}
+	if cr.Spec.ShutdownTimeout != nil && cr.Spec.TerminationGracePeriodSeconds != nil && *cr.Spec.TerminationGracePeriodSeconds < int64(*cr.Spec.ShutdownTimeout)+shutdownGracePeriodBuffer {
+		errs = append(errs, fmt.Errorf("terminationGracePeriodSeconds must be at least shutdownTimeout plus %d seconds", shutdownGracePeriodBuffer))
+	}
+	for key, value := range cr.Spec.GlobalConfig.Labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("global.labels key %q is invalid: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			errs = append(errs, fmt.Errorf("global.labels value %q is invalid: %s", value, msg))
+		}
+	}
+	for key := range cr.Spec.GlobalConfig.Annotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("global.annotations key %q is invalid: %s", key, msg))
+		}
+	}
+	for key := range cr.Spec.GlobalConfig.StatefulSetAnnotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("global.statefulSetAnnotations key %q is invalid: %s", key, msg))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// GetRedisSpecWarnings returns the settings of a valid redis spec which are accepted but likely to misbehave, so that
+// the caller can report them
+func GetRedisSpecWarnings(cr *redisv1beta1.Redis) []string {
+	var warnings []string
+	var memoryLimit *resource.Quantity
+	if len(validateRedisResources(cr)) == 0 {
+		if limit, ok := getRedisResources(cr).Limits[corev1.ResourceMemory]; ok {
+			memoryLimit = &limit
+		}
+	}
+	if cr.Spec.Replication != nil && cr.Spec.Replication.BacklogSize != nil {
+		if backlogSize, err := parseRedisMemory(*cr.Spec.Replication.BacklogSize); err == nil {
+			if backlogSize < redisDefaultReplBacklogSize {
+				warnings = append(warnings, fmt.Sprintf("replication.backlogSize %s is below the 1mb default of redis, replicas disconnected for a moment will need an expensive full resync", *cr.Spec.Replication.BacklogSize))
+			}
+			if memoryLimit != nil && backlogSize > memoryLimit.Value()/4 {
+				warnings = append(warnings, fmt.Sprintf("replication.backlogSize %s is more than a quarter of the memory limit %s, the backlog is allocated by the master on top of the dataset", *cr.Spec.Replication.BacklogSize, memoryLimit.String()))
+			}
+		}
+	}
+	if cr.Spec.ActiveDefrag != nil && cr.Spec.ActiveDefrag.Enabled != nil && *cr.Spec.ActiveDefrag.Enabled {
+		warnings = append(warnings, "Active defragmentation is enabled, it uses up to activeDefrag.cycleMax percent of the CPU of redis while memory is fragmented")
+	}
+	if cr.Spec.MaxClients != nil && *cr.Spec.MaxClients+redisReservedFileDescriptors > redisFileDescriptorLimit {
+		warnings = append(warnings, fmt.Sprintf("maxClients %d exceeds the usual file descriptor limit of containers, redis lowers it to fit the limit of the pod, %d with the usual limit", *cr.Spec.MaxClients, redisFileDescriptorLimit-redisReservedFileDescriptors))
+	}
+	if cr.Spec.TCPBacklog != nil {
+		if somaxconn := getSomaxconn(cr); int64(*cr.Spec.TCPBacklog) > somaxconn {
+			warnings = append(warnings, fmt.Sprintf("tcpBacklog %d exceeds net.core.somaxconn %d, the kernel caps the backlog of redis to it, set a matching sysctl in securityContext.sysctls", *cr.Spec.TCPBacklog, somaxconn))
+		}
+	}
+	if events := cr.Spec.NotifyKeyspaceEvents; events != nil && *events != "" && !strings.ContainsAny(*events, "KE") {
+		warnings = append(warnings, fmt.Sprintf("notifyKeyspaceEvents %q has neither K nor E, redis doesn't deliver any notification", *events))
+	}
+	if cr.Spec.ProtoMaxBulkLen != nil && memoryLimit != nil {
+		if bulkLen, err := parseRedisMemory(*cr.Spec.ProtoMaxBulkLen); err == nil && bulkLen > memoryLimit.Value()/2 {
+			warnings = append(warnings, fmt.Sprintf("protoMaxBulkLen %s is more than half of the memory limit %s, a single large request can get redis killed for running out of memory", *cr.Spec.ProtoMaxBulkLen, memoryLimit.String()))
+		}
+	}
+	if cr.Spec.MaxMemoryPolicy != nil && *cr.Spec.MaxMemoryPolicy == "noeviction" && (cr.Spec.Storage == nil || IsPersistenceDisabled(cr)) {
+		role := "standalone"
+		if cr.Spec.Mode == "cluster" {
+			role = "master"
+		}
+		maxMemory := generateRedisConfig(cr, role)["maxmemory"]
+		if limit, err := parseRedisMemory(maxMemory); err == nil && limit > 0 {
+			warnings = append(warnings, fmt.Sprintf("maxMemoryPolicy noeviction with a maxmemory of %s makes a cache reject writes once it is full, an allkeys policy evicts keys instead", maxMemory))
+		}
+	}
+	redisConfigs := map[string]map[string]string{"redisConfig": cr.Spec.RedisConfig, "master.redisConfig": cr.Spec.Master.RedisConfig, "slave.redisConfig": cr.Spec.Slave.RedisConfig}
+	for _, name := range []string{"redisConfig", "master.redisConfig", "slave.redisConfig"} {
+		// an empty logfile, quoted or not, logs to stdout
+		if logfile, ok := redisConfigs[name]["logfile"]; ok && logfile != "" && logfile != `""` {
+			warnings = append(warnings, fmt.Sprintf("%s.logfile %s makes redis log to a file, kubernetes only captures the logs written to stdout", name, logfile))
+		}
+	}
+	if cr.Spec.Mode == "cluster" && cr.Spec.Slave.ReplicaPriority != nil {
+		// redis cluster only honors a priority of 0, which keeps the replica from being promoted
+		priority := cr.Spec.Slave.ReplicaPriority
+		if priority.Default != nil && *priority.Default > 0 {
+			warnings = append(warnings, fmt.Sprintf("slave.replicaPriority.default %d is ignored by redis cluster failovers, only a priority of 0 is honored", *priority.Default))
+		}
+		for i, rule := range priority.Rules {
+			if rule.Priority > 0 {
+				warnings = append(warnings, fmt.Sprintf("slave.replicaPriority.rules[%d].priority %d is ignored by redis cluster failovers, only a priority of 0 is honored", i, rule.Priority))
+			}
+		}
+	}
+	if cr.Spec.Mode == "cluster" && cr.Spec.Slave.ReplicaPriority != nil && cr.Spec.Size != nil {
+		for i, rule := range cr.Spec.Slave.ReplicaPriority.Rules {
+			for _, ordinal := range rule.Ordinals {
+				if ordinal >= GetFollowerCount(cr) {
+					warnings = append(warnings, fmt.Sprintf("slave.replicaPriority.rules[%d] ordinal %d matches no slave pod, there are %d slaves", i, ordinal, GetFollowerCount(cr)))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// validateResourceNames will check that the names generated for the redis resources are valid kubernetes names
+func validateResourceNames(cr *redisv1beta1.Redis) []error {
+	var errs []error
+	roles := []string{"standalone"}
+	if cr.Spec.Mode == "cluster" {
+		roles = []string{"master", "slave"}
+	}
+	for _, role := range roles {
+		// statefulset names leave room for the controller-revision-hash label of their pods
+		statefulSetName := GetRedisName(cr) + "-" + role
+		if len(statefulSetName) > validation.DNS1123LabelMaxLength-11 {
+			errs = append(errs, fmt.Errorf("statefulset name %q must be no more than %d characters", statefulSetName, validation.DNS1123LabelMaxLength-11))
+		}
+		for _, name := range []string{getHeadlessServiceName(cr, role), GetRedisName(cr) + "-" + role + "-config"} {
+			for _, msg := range validation.IsDNS1123Label(name) {
+				errs = append(errs, fmt.Errorf("generated name %q is invalid: %s", name, msg))
+			}
+		}
+	}
+	return errs
+}
+
+// isRedisClusterPodName checks if the name is the name of a master or slave pod of the redis cluster
+func isRedisClusterPodName(cr *redisv1beta1.Redis, podName string) bool {
+	for _, pod := range getRedisPods(cr) {
+		if pod.Name == podName {
+			return true
+		}
+	}
+	return false
+}
+
+// exporterOperatorFlags are the redis exporter flags set by the operator, along with the environment variables
+// which the exporter reads them from
+var exporterOperatorFlags = map[string]string{
+	"redis.addr":          "REDIS_ADDR",
+	"redis.password":      "REDIS_PASSWORD",
+	"redis.password-file": "REDIS_PASSWORD_FILE",
+	"web.listen-address":  "REDIS_EXPORTER_WEB_LISTEN_ADDRESS",
+}
+
+// validateExporterOverrides checks that the extra arguments and environment variables of the redis exporter don't
+// override the connection and listen address set by the operator
+// validateTLSConfig rejects the tls directives of the redis configuration, the operator doesn't support TLS, so there
+// is no TLS secret mounted in the redis pods, and the probes, the exporter and the operator itself only speak plaintext
+func validateTLSConfig(cr *redisv1beta1.Redis) []error {
+	var errs []error
+	redisConfigs := map[string]map[string]string{"redisConfig": cr.Spec.RedisConfig, "master.redisConfig": cr.Spec.Master.RedisConfig, "slave.redisConfig": cr.Spec.Slave.RedisConfig}
+	for _, name := range []string{"redisConfig", "master.redisConfig", "slave.redisConfig"} {
+		var keys []string
+		for key := range redisConfigs[name] {
+			if strings.HasPrefix(strings.ToLower(key), "tls-") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			errs = append(errs, fmt.Errorf("%s.%s is not supported, the operator only connects to redis without TLS", name, key))
+		}
+	}
+	return errs
+}
+
+func validateExporterOverrides(exporter *redisv1beta1.RedisExporter) []error {
+	var errs []error
+	for _, arg := range exporter.ExtraArgs {
+		flag := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if _, ok := exporterOperatorFlags[flag]; ok && strings.HasPrefix(arg, "-") {
+			errs = append(errs, fmt.Errorf("redisExporter.extraArgs can't set --%s, it is set by the operator", flag))
+		}
+	}
+	for _, env := range exporter.Env {
+		for _, name := range exporterOperatorFlags {
+			if env.Name == name {
+				errs = append(errs, fmt.Errorf("redisExporter.env can't set %s, it is set by the operator", env.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// validateActiveDefrag checks that the active defragmentation thresholds and cycle limits are valid percentages
+func validateActiveDefrag(defrag *redisv1beta1.ActiveDefragConfig) []error {
+	var errs []error
+	percentages := map[string]*int32{
+		"thresholdLower": defrag.ThresholdLower,
+		"thresholdUpper": defrag.ThresholdUpper,
+		"cycleMin":       defrag.CycleMin,
+		"cycleMax":       defrag.CycleMax,
+	}
+	for _, name := range []string{"thresholdLower", "thresholdUpper", "cycleMin", "cycleMax"} {
+		if value := percentages[name]; value != nil && (*value < 1 || *value > 100) {
+			errs = append(errs, fmt.Errorf("activeDefrag.%s must be between 1 and 100, got %d", name, *value))
+		}
+	}
+	if defrag.ThresholdLower != nil && defrag.ThresholdUpper != nil && *defrag.ThresholdLower > *defrag.ThresholdUpper {
+		errs = append(errs, fmt.Errorf("activeDefrag.thresholdLower must not exceed activeDefrag.thresholdUpper"))
+	}
+	if defrag.CycleMin != nil && defrag.CycleMax != nil && *defrag.CycleMin > *defrag.CycleMax {
+		errs = append(errs, fmt.Errorf("activeDefrag.cycleMin must not exceed activeDefrag.cycleMax"))
+	}
+	if defrag.IgnoreBytes != nil {
+		if _, err := parseRedisMemory(*defrag.IgnoreBytes); err != nil {
+			errs = append(errs, fmt.Errorf("activeDefrag.ignoreBytes %q is not a valid redis memory value: %v", *defrag.IgnoreBytes, err))
+		}
+	}
+	return errs
+}
+
+// validateMaxMemoryPolicy checks that the eviction policy is known to the redis version
+func validateMaxMemoryPolicy(cr *redisv1beta1.Redis) []error {
+	policy := *cr.Spec.MaxMemoryPolicy
+	version, ok := redisMaxMemoryPolicies[policy]
+	if !ok {
+		var policies []string
+		for name := range redisMaxMemoryPolicies {
+			policies = append(policies, name)
+		}
+		sort.Strings(policies)
+		return []error{fmt.Errorf("maxMemoryPolicy must be one of %s, got %q", strings.Join(policies, ", "), policy)}
+	}
+	if !redisVersionAtLeast(cr, version[0], version[1]) {
+		return []error{fmt.Errorf("maxMemoryPolicy %s needs redis %d.%d or later", policy, version[0], version[1])}
+	}
+	return nil
+}
+
+// validateReplicaPriority checks that the replica priorities aren't negative
+func validateReplicaPriority(cr *redisv1beta1.Redis) []error {
+	var errs []error
+	priority := cr.Spec.Slave.ReplicaPriority
+	if priority.Default != nil && *priority.Default < 0 {
+		errs = append(errs, fmt.Errorf("slave.replicaPriority.default must be at least 0, got %d", *priority.Default))
+	}
+	for i, rule := range priority.Rules {
+		if rule.Priority < 0 {
+			errs = append(errs, fmt.Errorf("slave.replicaPriority.rules[%d].priority must be at least 0, got %d", i, rule.Priority))
+		}
+		for _, ordinal := range rule.Ordinals {
+			if ordinal < 0 {
+				errs = append(errs, fmt.Errorf("slave.replicaPriority.rules[%d].ordinals must be at least 0, got %d", i, ordinal))
+			}
+		}
+	}
+	return errs
+}
+
+// validateSidecars checks that the sidecar names don't collide with the containers managed by the operator, and that
+// the sidecars only mount the redis data volume read only so that they can't corrupt the data files. The data volume
+// is named after the role, so it can't be mounted by the sidecars shared by the masters and slaves.
+func validateSidecars(cr *redisv1beta1.Redis) []error {
+	var errs []error
+	roles := []string{"standalone"}
+	if cr.Spec.Mode == "cluster" {
+		roles = []string{"master", "slave"}
+	}
+	reserved := map[string]bool{constRedisExpoterName: true, constDNSWaitName: true}
+	dataVolumes := map[string]bool{}
+	for _, role := range roles {
+		reserved[GetRedisName(cr)+"-"+role] = true
+		dataVolumes[GetRedisName(cr)+"-"+role] = true
+	}
+	names := map[string]bool{}
+	for i, sidecar := range cr.Spec.Sidecars {
+		if reserved[sidecar.Name] {
+			errs = append(errs, fmt.Errorf("sidecars[%d].name %q is the name of a container managed by the operator", i, sidecar.Name))
+		} else if names[sidecar.Name] {
+			errs = append(errs, fmt.Errorf("sidecars[%d].name %q is used by another sidecar", i, sidecar.Name))
+		}
+		names[sidecar.Name] = true
+		for _, mount := range sidecar.VolumeMounts {
+			if !dataVolumes[mount.Name] {
+				continue
+			}
+			if len(roles) > 1 {
+				errs = append(errs, fmt.Errorf("sidecars[%d] can't mount the redis data volume %s in cluster mode, the sidecars of the masters and slaves are the same", i, mount.Name))
+			} else if !mount.ReadOnly {
+				errs = append(errs, fmt.Errorf("sidecars[%d] must mount the redis data volume %s read only", i, mount.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// validateProbeTiming checks that the probe timing is accepted by kubernetes
+func validateProbeTiming(name string, timing *redisv1beta1.ProbeTiming) []error {
+	if timing == nil {
+		return nil
+	}
+	var errs []error
+	if timing.InitialDelaySeconds != nil && *timing.InitialDelaySeconds < 0 {
+		errs = append(errs, fmt.Errorf("%s.initialDelaySeconds must not be negative", name))
+	}
+	minimums := map[string]*int32{"periodSeconds": timing.PeriodSeconds, "timeoutSeconds": timing.TimeoutSeconds, "failureThreshold": timing.FailureThreshold}
+	for _, field := range []string{"periodSeconds", "timeoutSeconds", "failureThreshold"} {
+		if value := minimums[field]; value != nil && *value < 1 {
+			errs = append(errs, fmt.Errorf("%s.%s must be at least 1, got %d", name, field, *value))
+		}
+	}
+	return errs
+}
+
+// validateRedisResources checks that the resources of the redis container are valid quantities, and that the
+// requests don't exceed the limits once the defaults of the operator fill in the values missing from the spec
+func validateRedisResources(cr *redisv1beta1.Redis) []error {
+	var errs []error
+	if spec := cr.Spec.GlobalConfig.Resources; spec != nil {
+		values := map[string]string{
+			"requests.cpu":    spec.ResourceRequests.CPU,
+			"requests.memory": spec.ResourceRequests.Memory,
+			"limits.cpu":      spec.ResourceLimits.CPU,
+			"limits.memory":   spec.ResourceLimits.Memory,
+		}
+		for _, name := range []string{"requests.cpu", "requests.memory", "limits.cpu", "limits.memory"} {
+			if values[name] == "" {
+				continue
+			}
+			if _, err := resource.ParseQuantity(values[name]); err != nil {
+				errs = append(errs, fmt.Errorf("global.resources.%s %q is not a valid quantity", name, values[name]))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	resources := getRedisResources(cr)
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := resources.Requests[name]
+		limit, hasLimit := resources.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			errs = append(errs, fmt.Errorf("global.resources %s request %s is more than its limit %s, including the defaults of the operator", name, request.String(), limit.String()))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,89 @@
+package k8sutils
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+func TestGetRedisSpecWarnings(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	if err := ValidateRedisSpec(cr); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := GetRedisSpecWarnings(cr); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	maxClients := int32(70000)
+	events := "x"
+	cr.Spec.MaxClients = &maxClients
+	cr.Spec.NotifyKeyspaceEvents = &events
+	if err := ValidateRedisSpec(cr); err != nil {
+		t.Fatalf("expected the warnings to leave the spec valid, got %v", err)
+	}
+	warnings := GetRedisSpecWarnings(cr)
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "maxClients") || !strings.HasPrefix(warnings[1], "notifyKeyspaceEvents") {
+		t.Errorf("expected the maxClients and notifyKeyspaceEvents warnings, got %v", warnings)
+	}
+}
+
+func TestReplicaPriorityWarnings(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	priority := int32(100)
+	cr.Spec.Slave.ReplicaPriority = &redisv1beta1.ReplicaPriority{
+		Default: &priority,
+		Rules:   []redisv1beta1.ReplicaPriorityRule{{Ordinals: []int32{2}, Priority: 0}},
+	}
+	warnings := GetRedisSpecWarnings(cr)
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "slave.replicaPriority.default 100 is ignored") {
+		t.Errorf("expected only the non-zero default priority to be reported, got %v", warnings)
+	}
+}
+
+func TestLogfileWarnings(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.RedisConfig = map[string]string{"logfile": ""}
+	cr.Spec.Master.RedisConfig = map[string]string{"logfile": `""`}
+	if err := ValidateRedisSpec(cr); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := GetRedisSpecWarnings(cr); len(warnings) != 0 {
+		t.Errorf("expected the empty logfiles to be accepted, got %v", warnings)
+	}
+	cr.Spec.Slave.RedisConfig = map[string]string{"logfile": "/data/redis.log"}
+	if err := ValidateRedisSpec(cr); err != nil {
+		t.Fatalf("expected a logfile to leave the spec valid, got %v", err)
+	}
+	if warnings := GetRedisSpecWarnings(cr); len(warnings) != 1 || !strings.HasPrefix(warnings[0], "slave.redisConfig.logfile") {
+		t.Errorf("expected the slave logfile warning, got %v", warnings)
+	}
+}
+
+func TestTLSConfigIsRejected(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	cr.Spec.RedisConfig = map[string]string{"maxmemory-policy": "allkeys-lru"}
+	if errs := validateTLSConfig(cr); len(errs) != 0 {
+		t.Errorf("expected a configuration without tls directives to be accepted, got %v", errs)
+	}
+	cr.Spec.Master.RedisConfig = map[string]string{"tls-port": "6380", "tls-cert-file": "/tls/tls.crt"}
+	errs := validateTLSConfig(cr)
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "master.redisConfig.tls-cert-file") {
+		t.Errorf("expected the master tls directives to be rejected, got %v", errs)
+	}
+	if err := ValidateRedisSpec(cr); err == nil {
+		t.Error("expected the tls directives to invalidate the spec")
+	}
+}
+
+func TestExporterOverridesRejectTheListenAddress(t *testing.T) {
+	exporter := &redisv1beta1.RedisExporter{
+		ExtraArgs: []string{"--web.listen-address=:9500", "--check-keys=session:*"},
+		Env:       []corev1.EnvVar{{Name: "REDIS_EXPORTER_WEB_LISTEN_ADDRESS", Value: ":9500"}},
+	}
+	if errs := validateExporterOverrides(exporter); len(errs) != 2 {
+		t.Errorf("expected the listen address flag and environment variable to be rejected, got %v", errs)
+	}
+}
@@ -0,0 +1,110 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strconv"
+	"strings"
+)
+
+// RedisSkipUpgradeValidationAnnotation skips the validation of the redis upgrade path when set to true
+const RedisSkipUpgradeValidationAnnotation = "redis.opstreelabs.in/skip-upgrade-validation"
+
+// getRedisVersion returns the major and minor redis version from the tag of the redis image
+func getRedisVersion(cr *redisv1beta1.Redis) (int, int, bool) {
+	return parseImageVersion(cr.Spec.GlobalConfig.Image)
+}
+
+// parseImageVersion returns the major and minor version from the tag of an image
+func parseImageVersion(image string) (int, int, bool) {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return 0, 0, false
+	}
+	return parseVersion(image[i+1:])
+}
+
+// parseVersion returns the major and minor version of a version string like v6.2 or 7.0.11
+func parseVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// redisVersionAtLeast checks the redis image version, an unknown version is assumed to be recent enough
+func redisVersionAtLeast(cr *redisv1beta1.Redis, major int, minor int) bool {
+	imageMajor, imageMinor, ok := getRedisVersion(cr)
+	if !ok {
+		return true
+	}
+	return imageMajor > major || (imageMajor == major && imageMinor >= minor)
+}
+
+// getRunningRedisVersion returns the major and minor version reported by INFO of the first redis pod
+func getRunningRedisVersion(ctx context.Context, cr *redisv1beta1.Redis) (int, int, bool) {
+	client := configureRedisClient(ctx, cr, getRedisPods(cr)[0].Name)
+	defer client.Close()
+	output, err := client.Info("server").Result()
+	if err != nil {
+		return 0, 0, false
+	}
+	return parseVersion(parseRedisInfo(output)["redis_version"])
+}
+
+// checkRedisUpgradePath checks that redis data written by the current version can be loaded by the target version
+func checkRedisUpgradePath(currentMajor int, currentMinor int, targetMajor int, targetMinor int) error {
+	if targetMajor < currentMajor || (targetMajor == currentMajor && targetMinor < currentMinor) {
+		return fmt.Errorf("downgrading redis from %d.%d to %d.%d is not supported, older versions can't load newer RDB and AOF files", currentMajor, currentMinor, targetMajor, targetMinor)
+	}
+	if targetMajor > currentMajor+1 {
+		return fmt.Errorf("upgrading redis from %d.%d to %d.%d skips a major version, upgrade through every major version in turn", currentMajor, currentMinor, targetMajor, targetMinor)
+	}
+	return nil
+}
+
+// ValidateRedisUpgrade will check that changing the redis image is a supported upgrade of the running redis version.
+// The running version is read with INFO, falling back to the image of the statefulset when redis isn't reachable.
+func ValidateRedisUpgrade(ctx context.Context, cr *redisv1beta1.Redis) error {
+	if cr.Annotations[RedisSkipUpgradeValidationAnnotation] == "true" {
+		return nil
+	}
+	role := "standalone"
+	if cr.Spec.Mode == "cluster" {
+		role = "master"
+	}
+	statefulset, err := GenerateK8sClient().AppsV1().StatefulSets(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-"+role, metav1.GetOptions{})
+	if err != nil || len(statefulset.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+	currentImage := statefulset.Spec.Template.Spec.Containers[0].Image
+	if currentImage == cr.Spec.GlobalConfig.Image {
+		return nil
+	}
+	targetMajor, targetMinor, ok := getRedisVersion(cr)
+	if !ok {
+		return nil
+	}
+	currentMajor, currentMinor, ok := getRunningRedisVersion(ctx, cr)
+	if !ok {
+		currentMajor, currentMinor, ok = parseImageVersion(currentImage)
+		if !ok {
+			return nil
+		}
+	}
+	return checkRedisUpgradePath(currentMajor, currentMinor, targetMajor, targetMinor)
+}
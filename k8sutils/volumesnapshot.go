@@ -0,0 +1,306 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVolumeSnapshotInterval = time.Hour * 24
+	// VolumeSnapshotTimeout is the time the masters are given to save their RDB file, the masters still pending then
+	// are not snapshotted in the run
+	VolumeSnapshotTimeout = time.Minute * 2
+)
+
+// volumeSnapshotResource is the CSI volume snapshot resource, which has no typed client in client-go
+var volumeSnapshotResource = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+
+// GetVolumeSnapshotInterval returns the interval between two volume snapshots of the redis masters
+func GetVolumeSnapshotInterval(cr *redisv1beta1.Redis) time.Duration {
+	if cr.Spec.VolumeSnapshot.Interval == "" {
+		return defaultVolumeSnapshotInterval
+	}
+	interval, err := time.ParseDuration(cr.Spec.VolumeSnapshot.Interval)
+	if err != nil {
+		return defaultVolumeSnapshotInterval
+	}
+	return interval
+}
+
+// getRedisMasterPods returns the pods currently serving as redis masters, which hold the data of every shard
+func getRedisMasterPods(ctx context.Context, cr *redisv1beta1.Redis) ([]corev1.Pod, error) {
+	if cr.Spec.Mode != "cluster" {
+		pod, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).Get(context.TODO(), GetRedisName(cr)+"-standalone-0", metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		return nil, err
+	}
+	var masters []corev1.Pod
+	for _, node := range parseRedisClusterNodes(checkRedisCluster(ctx, cr)) {
+		if pod, ok := podsByIP[node.IP]; ok && strings.Contains(node.Flags, "master") && len(node.Slots) > 0 {
+			masters = append(masters, pod)
+		}
+	}
+	return masters, nil
+}
+
+// triggerRedisBGSave will run BGSAVE on the redis pod, it returns the unix time of the previous save which the
+// BGSAVE is checked against
+func triggerRedisBGSave(ctx context.Context, cr *redisv1beta1.Redis, podName string) (int64, error) {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	lastSave, err := client.LastSave().Result()
+	if err != nil {
+		return 0, err
+	}
+	if err := client.BgSave().Err(); err != nil && !strings.Contains(err.Error(), "in progress") {
+		return 0, err
+	}
+	return lastSave, nil
+}
+
+// isRedisBGSaveDone returns whether the redis pod saved its RDB file after lastSave, a failed BGSAVE is returned as
+// an error
+func isRedisBGSaveDone(ctx context.Context, cr *redisv1beta1.Redis, podName string, lastSave int64) (bool, error) {
+	client := configureRedisClient(ctx, cr, podName)
+	defer client.Close()
+	output, err := client.Info("persistence").Result()
+	if err != nil {
+		return false, err
+	}
+	info := parseRedisInfo(output)
+	if info["rdb_bgsave_in_progress"] != "0" {
+		return false, nil
+	}
+	if info["rdb_last_bgsave_status"] != "ok" {
+		return false, fmt.Errorf("redis BGSAVE failed on pod %s", podName)
+	}
+	saved, err := client.LastSave().Result()
+	if err != nil {
+		return false, err
+	}
+	return saved > lastSave, nil
+}
+
+// generateVolumeSnapshotDef generates the volume snapshot of the persistent volume claim of the redis pod
+func generateVolumeSnapshotDef(cr *redisv1beta1.Redis, name string, pvcName string) *unstructured.Unstructured {
+	labels := map[string]interface{}{
+		"app": GetRedisName(cr),
+	}
+	for key, value := range cr.Spec.GlobalConfig.Labels {
+		labels[key] = value
+	}
+	// volume snapshots are not owned by the redis resource, so that deleting it keeps the backups
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": cr.Namespace,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"volumeSnapshotClassName": cr.Spec.VolumeSnapshot.VolumeSnapshotClassName,
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": pvcName,
+			},
+		},
+	}}
+}
+
+// createRedisVolumeSnapshot will take a volume snapshot of the volume of the redis pod
+func createRedisVolumeSnapshot(cr *redisv1beta1.Redis, podName string) (redisv1beta1.VolumeSnapshotRef, error) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	pod, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return redisv1beta1.VolumeSnapshotRef{}, err
+	}
+	now := metav1.Now()
+	pvcName := GetRedisName(cr) + "-" + pod.Labels["role"] + "-" + pod.Name
+	name := fmt.Sprintf("%s-%d", pod.Name, now.Unix())
+	_, err = GenerateK8sDynamicClient().Resource(volumeSnapshotResource).Namespace(cr.Namespace).Create(context.TODO(), generateVolumeSnapshotDef(cr, name, pvcName), metav1.CreateOptions{})
+	if err != nil {
+		return redisv1beta1.VolumeSnapshotRef{}, err
+	}
+	reqLogger.Info("Volume snapshot of redis is created", "VolumeSnapshot.Name", name, "PVC.Name", pvcName)
+	return redisv1beta1.VolumeSnapshotRef{Name: name, PodName: pod.Name, PVCName: pvcName, CreationTime: now}, nil
+}
+
+// StartRedisVolumeSnapshots will run BGSAVE on every redis master and returns the run, whose pending pods are
+// snapshotted by CreateSavedRedisVolumeSnapshots once their RDB file is written
+func StartRedisVolumeSnapshots(ctx context.Context, cr *redisv1beta1.Redis) (*redisv1beta1.VolumeSnapshotRun, error) {
+	masters, err := getRedisMasterPods(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	run := &redisv1beta1.VolumeSnapshotRun{StartTime: metav1.Now()}
+	for _, pod := range masters {
+		lastSave, err := triggerRedisBGSave(ctx, cr, pod.Name)
+		if err != nil {
+			return nil, err
+		}
+		run.PendingPods = append(run.PendingPods, redisv1beta1.PendingVolumeSnapshot{PodName: pod.Name, LastSave: lastSave})
+	}
+	return run, nil
+}
+
+// CreateSavedRedisVolumeSnapshots will take the volume snapshot of every pending pod of the run whose BGSAVE is done
+// and remove it from the pending pods, so that the RDB file in the snapshot is up to date. It doesn't wait for the
+// BGSAVE, the pods still saving stay pending, and a failed BGSAVE is run again. The snapshots taken are returned
+// along with the first error.
+func CreateSavedRedisVolumeSnapshots(ctx context.Context, cr *redisv1beta1.Redis, run *redisv1beta1.VolumeSnapshotRun) ([]redisv1beta1.VolumeSnapshotRef, error) {
+	var snapshots []redisv1beta1.VolumeSnapshotRef
+	var pending []redisv1beta1.PendingVolumeSnapshot
+	var firstErr error
+	for _, pod := range run.PendingPods {
+		done, err := isRedisBGSaveDone(ctx, cr, pod.PodName, pod.LastSave)
+		if err == nil && done {
+			var snapshot redisv1beta1.VolumeSnapshotRef
+			if snapshot, err = createRedisVolumeSnapshot(cr, pod.PodName); err == nil {
+				snapshots = append(snapshots, snapshot)
+				continue
+			}
+		} else if err != nil {
+			if lastSave, saveErr := triggerRedisBGSave(ctx, cr, pod.PodName); saveErr == nil {
+				pod.LastSave = lastSave
+			}
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		pending = append(pending, pod)
+	}
+	run.PendingPods = pending
+	return snapshots, firstErr
+}
+
+// CreateRedisVolumeSnapshots will take a volume snapshot of the volume of every redis master right after a BGSAVE,
+// waiting for the BGSAVE until the context is done. It is only used by the finalizer, whose steps are bounded.
+func CreateRedisVolumeSnapshots(ctx context.Context, cr *redisv1beta1.Redis) ([]redisv1beta1.VolumeSnapshotRef, error) {
+	run, err := StartRedisVolumeSnapshots(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []redisv1beta1.VolumeSnapshotRef
+	for {
+		taken, err := CreateSavedRedisVolumeSnapshots(ctx, cr, run)
+		snapshots = append(snapshots, taken...)
+		if len(run.PendingPods) == 0 {
+			return snapshots, err
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return snapshots, err
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// PruneRedisVolumeSnapshots will delete the volume snapshots of the redis resource beyond the retention, keeping the
+// most recent ones of every volume, and returns the names of the deleted snapshots
+func PruneRedisVolumeSnapshots(cr *redisv1beta1.Redis) ([]string, error) {
+	if cr.Spec.VolumeSnapshot.Retention == nil {
+		return nil, nil
+	}
+	client := GenerateK8sDynamicClient().Resource(volumeSnapshotResource).Namespace(cr.Namespace)
+	list, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: "app=" + GetRedisName(cr)})
+	if err != nil {
+		return nil, err
+	}
+	byVolume := map[string][]unstructured.Unstructured{}
+	for _, snapshot := range list.Items {
+		pvcName, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+		byVolume[pvcName] = append(byVolume[pvcName], snapshot)
+	}
+	var deleted []string
+	for _, snapshots := range byVolume {
+		sort.Slice(snapshots, func(i, j int) bool {
+			return snapshots[i].GetCreationTimestamp().Time.After(snapshots[j].GetCreationTimestamp().Time)
+		})
+		for i := int(*cr.Spec.VolumeSnapshot.Retention); i < len(snapshots); i++ {
+			if err := client.Delete(context.TODO(), snapshots[i].GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return deleted, err
+			}
+			deleted = append(deleted, snapshots[i].GetName())
+		}
+	}
+	sort.Strings(deleted)
+	return deleted, nil
+}
+
+// createRestoredPVCs will create the persistent volume claims of the masters from the volume snapshots before the
+// statefulset is created, the statefulset then uses them instead of creating empty ones
+func createRestoredPVCs(cr *redisv1beta1.Redis, role string) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	apiGroup := volumeSnapshotResource.Group
+	for ordinal, snapshot := range cr.Spec.RestoreFrom.VolumeSnapshots {
+		pvc := CreatePVCTemplate(cr, role)
+		pvc.Name = GetRedisName(cr) + "-" + role + "-" + GetRedisName(cr) + "-" + role + "-" + strconv.Itoa(ordinal)
+		pvc.Namespace = cr.Namespace
+		pvc.Labels = mergeStringMaps(cr.Spec.GlobalConfig.Labels, map[string]string{
+			"app":  GetRedisName(cr) + "-" + role,
+			"role": role,
+		})
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     "VolumeSnapshot",
+			Name:     snapshot,
+		}
+		if _, err := GenerateK8sClient().CoreV1().PersistentVolumeClaims(cr.Namespace).Get(context.TODO(), pvc.Name, metav1.GetOptions{}); err == nil {
+			continue
+		}
+		reqLogger.Info("Restoring redis volume from volume snapshot", "PVC.Name", pvc.Name, "VolumeSnapshot.Name", snapshot)
+		_, err := GenerateK8sClient().CoreV1().PersistentVolumeClaims(cr.Namespace).Create(context.TODO(), &pvc, metav1.CreateOptions{})
+		if err != nil {
+			reqLogger.Error(err, "Failed in creating restored persistent volume claim for redis", "PVC.Name", pvc.Name)
+		}
+	}
+}
+
+// RecoverRestoredRedisCluster will reassemble the masters restored from volume snapshots. They keep the node ids and
+// slots of the source cluster, so they meet each other on their new IPs, and forget the nodes of the source cluster
+// which were not restored, instead of creating a new cluster.
+func RecoverRestoredRedisCluster(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	masterPodName := GetRedisName(cr) + "-master-0"
+	for ordinal := 1; ordinal < int(*cr.Spec.Size); ordinal++ {
+		masterIP := getRedisServerIP(RedisDetails{PodName: GetRedisName(cr) + "-master-" + strconv.Itoa(ordinal), Namespace: cr.Namespace})
+		if err := runClusterCommand(ctx, cr, masterPodName, "meet", masterIP, "6379"); err != nil {
+			reqLogger.Error(err, "Restored redis master failed to meet the other masters")
+		}
+	}
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return
+	}
+	for _, node := range parseRedisClusterNodes(checkRedisCluster(ctx, cr)) {
+		if _, ok := podsByIP[node.IP]; ok || strings.Contains(node.Flags, "handshake") {
+			continue
+		}
+		for ordinal := 0; ordinal < int(*cr.Spec.Size); ordinal++ {
+			podName := GetRedisName(cr) + "-master-" + strconv.Itoa(ordinal)
+			if err := runClusterCommand(ctx, cr, podName, "forget", node.ID); err != nil {
+				reqLogger.Info("Restored redis master could not forget a node of the source cluster", "Pod.Name", podName, "Node.ID", node.ID, "Reason", err.Error())
+			}
+		}
+	}
+}
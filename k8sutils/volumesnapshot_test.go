@@ -0,0 +1,49 @@
+package k8sutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+func TestPruneRedisVolumeSnapshotsKeepsTheRetentionOfEveryVolume(t *testing.T) {
+	cr := newTestRedisCluster(2)
+	retention := int32(2)
+	cr.Spec.VolumeSnapshot = &redisv1beta1.VolumeSnapshotConfig{VolumeSnapshotClassName: "csi-snapclass", Retention: &retention}
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var objects []runtime.Object
+	for _, pod := range []string{"redis-master-0", "redis-master-1"} {
+		for day := 0; day < 3; day++ {
+			snapshot := generateVolumeSnapshotDef(cr, fmt.Sprintf("%s-%d", pod, day), "redis-master-"+pod)
+			snapshot.SetCreationTimestamp(metav1.NewTime(created.AddDate(0, 0, day)))
+			objects = append(objects, snapshot)
+		}
+	}
+	other := generateVolumeSnapshotDef(cr, "other-0", "other-master-other-master-0")
+	other.SetLabels(map[string]string{"app": "other"})
+	objects = append(objects, other)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	generateK8sDynamicClient := GenerateK8sDynamicClient
+	GenerateK8sDynamicClient = func() dynamic.Interface { return client }
+	t.Cleanup(func() { GenerateK8sDynamicClient = generateK8sDynamicClient })
+
+	deleted, err := PruneRedisVolumeSnapshots(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || deleted[0] != "redis-master-0-0" || deleted[1] != "redis-master-1-0" {
+		t.Errorf("expected the oldest snapshot of every volume to be deleted, got %v", deleted)
+	}
+	list, _ := client.Resource(volumeSnapshotResource).Namespace("default").List(context.TODO(), metav1.ListOptions{})
+	if len(list.Items) != 5 {
+		t.Errorf("expected 5 volume snapshots to be kept, got %d", len(list.Items))
+	}
+}
@@ -0,0 +1,126 @@
+package k8sutils
+
+import (
+	"context"
+	"encoding/json"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"strings"
+)
+
+const (
+	// RedisRoleLabel is the live role of a redis cluster pod, master or replica, which follows the failovers
+	RedisRoleLabel = "redis-role"
+	// RedisNameLabel is the redis setup of a pod labeled with its live role, so that the write service only selects
+	// the masters of its own redis cluster
+	RedisNameLabel = "redis.opstreelabs.in/name"
+)
+
+// isWriteServiceEnabled reports whether the redis cluster pods are labeled with their live role for the write service
+func isWriteServiceEnabled(cr *redisv1beta1.Redis) bool {
+	return cr.Spec.WriteService != nil && cr.Spec.WriteService.Enabled
+}
+
+// getWriteServiceName returns the name of the service selecting the current masters of the redis cluster
+func getWriteServiceName(cr *redisv1beta1.Redis) string {
+	return GetRedisName(cr) + "-write"
+}
+
+// getLiveRoles returns the live role of the redis pods in the cluster nodes, master or replica. Failing nodes and pods
+// which aren't part of the cluster have no role.
+func getLiveRoles(nodes []redisClusterNode, podsByIP map[string]corev1.Pod) map[string]string {
+	roles := map[string]string{}
+	for _, node := range nodes {
+		pod, ok := podsByIP[node.IP]
+		if !ok || strings.Contains(node.Flags, "fail") {
+			continue
+		}
+		if strings.Contains(node.Flags, "master") {
+			roles[pod.Name] = "master"
+		} else if strings.Contains(node.Flags, "slave") {
+			roles[pod.Name] = "replica"
+		}
+	}
+	return roles
+}
+
+// isPodLabeled reports whether the pod has the labels, a nil value being a label the pod doesn't have
+func isPodLabeled(pod corev1.Pod, labels map[string]*string) bool {
+	for key, value := range labels {
+		current, ok := pod.Labels[key]
+		if ok != (value != nil) || (ok && current != *value) {
+			return false
+		}
+	}
+	return true
+}
+
+// LabelRedisPodRoles will label every redis cluster pod with its live role, so that the write service follows the
+// failovers. The role label of the pods without a live role is removed, and the labels of every pod are removed once
+// the write service is disabled. The labels are merge patched, so that the other labels of the pods are kept.
+func LabelRedisPodRoles(ctx context.Context, cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	podsByIP, err := getRedisPodsByIP(cr)
+	if err != nil {
+		reqLogger.Error(err, "Could not list redis pods")
+		return
+	}
+	roles := map[string]string{}
+	if isWriteServiceEnabled(cr) {
+		roles = getLiveRoles(parseRedisClusterNodes(checkRedisCluster(ctx, cr)), podsByIP)
+	}
+	for _, pod := range podsByIP {
+		// a null label value removes the label with a merge patch
+		labels := map[string]*string{RedisNameLabel: nil, RedisRoleLabel: nil}
+		if isWriteServiceEnabled(cr) {
+			name := GetRedisName(cr)
+			labels[RedisNameLabel] = &name
+			if role, ok := roles[pod.Name]; ok {
+				labels[RedisRoleLabel] = &role
+			}
+		}
+		if isPodLabeled(pod, labels) {
+			continue
+		}
+		patch, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": labels},
+		})
+		reqLogger.Info("Labeling redis pod with its live role", "Pod.Name", pod.Name, "Role", roles[pod.Name])
+		if _, err := GenerateK8sClient().CoreV1().Pods(cr.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			reqLogger.Error(err, "Failed in updating role label of redis pod", "Pod.Name", pod.Name)
+		}
+	}
+}
+
+// CreateWriteService will create or update the service selecting the pods labeled as the current masters of the redis
+// cluster, and delete it once the write service is disabled
+func CreateWriteService(cr *redisv1beta1.Redis) {
+	reqLogger := log.WithValues("Request.Namespace", cr.Namespace, "Request.Name", cr.ObjectMeta.Name)
+	serviceBody, err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Get(context.TODO(), getWriteServiceName(cr), metav1.GetOptions{})
+	if !isWriteServiceEnabled(cr) {
+		if err == nil && metav1.IsControlledBy(serviceBody, cr) {
+			reqLogger.Info("Deleting write service for redis", "Service.Name", serviceBody.Name)
+			if err := GenerateK8sClient().CoreV1().Services(cr.Namespace).Delete(context.TODO(), serviceBody.Name, metav1.DeleteOptions{}); err != nil {
+				reqLogger.Error(err, "Failed in deleting write service for redis")
+			}
+		}
+		return
+	}
+	labels := map[string]string{
+		"app":  GetRedisName(cr) + "-write",
+		"role": "write",
+	}
+	serviceDefinition := GenerateServiceDef(cr, labels, int32(redisPort), "write", getWriteServiceName(cr), cr.Spec.WriteService.Service.Type)
+	serviceDefinition.Spec.Selector = map[string]string{
+		RedisNameLabel: GetRedisName(cr),
+		RedisRoleLabel: "master",
+	}
+	service := ServiceInterface{
+		ExistingService:      serviceBody,
+		NewServiceDefinition: serviceDefinition,
+		ServiceType:          "write",
+	}
+	CompareAndCreateService(cr, service, err)
+}
@@ -0,0 +1,61 @@
+package k8sutils
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetLiveRoles(t *testing.T) {
+	podsByIP := map[string]corev1.Pod{}
+	for ip, name := range map[string]string{"10.0.0.1": "redis-master-0", "10.0.0.2": "redis-slave-0", "10.0.0.3": "redis-master-1"} {
+		podsByIP[ip] = corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	nodes := []redisClusterNode{
+		{ID: "a", IP: "10.0.0.1", Flags: "slave", MasterID: "b"},
+		{ID: "b", IP: "10.0.0.2", Flags: "myself,master", Slots: []string{"0-16383"}},
+		{ID: "c", IP: "10.0.0.3", Flags: "master,fail"},
+		{ID: "d", IP: "10.0.0.4", Flags: "master"},
+	}
+	roles := getLiveRoles(nodes, podsByIP)
+	if roles["redis-master-0"] != "replica" || roles["redis-slave-0"] != "master" {
+		t.Errorf("expected the failed over roles, got %v", roles)
+	}
+	if _, ok := roles["redis-master-1"]; ok || len(roles) != 2 {
+		t.Errorf("expected no role for the failing node, got %v", roles)
+	}
+}
+
+func TestLabelRedisPodRolesRemovesTheLabelsOnceDisabled(t *testing.T) {
+	client := useFakeK8sClient(t)
+	cr := newTestRedisCluster(3)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "redis-master-0", Namespace: "default", Labels: map[string]string{
+			"app":          "redis-master",
+			"team":         "cache",
+			RedisNameLabel: "redis",
+			RedisRoleLabel: "master",
+		}},
+		Status: corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	if _, err := client.CoreV1().Pods("default").Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	LabelRedisPodRoles(context.TODO(), cr)
+	labeled, err := client.CoreV1().Pods("default").Get(context.TODO(), "redis-master-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := labeled.Labels[RedisRoleLabel]; ok {
+		t.Errorf("expected the role label to be removed, got %v", labeled.Labels)
+	}
+	if _, ok := labeled.Labels[RedisNameLabel]; ok {
+		t.Errorf("expected the name label to be removed, got %v", labeled.Labels)
+	}
+	if labeled.Labels["team"] != "cache" || labeled.Labels["app"] != "redis-master" {
+		t.Errorf("expected the other labels to be kept, got %v", labeled.Labels)
+	}
+}
@@ -0,0 +1,95 @@
+package k8sutils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	redisv1beta1 "redis-operator/api/v1beta1"
+)
+
+const (
+	// zoneTopologySpread places the replicas of a shard in other zones than its master, so that a zone outage
+	// leaves a replica to fail over to
+	zoneTopologySpread = "spread"
+	// zoneTopologyColocate places the replicas of a shard in the zone of its master, so that replication and
+	// replica reads don't cross zones
+	zoneTopologyColocate = "colocate"
+)
+
+// getZoneTopology returns the zone topology of the redis cluster shards, empty when the zones are ignored
+func getZoneTopology(cr *redisv1beta1.Redis) string {
+	if cr.Spec.Mode != "cluster" || cr.Spec.ZoneTopology == nil {
+		return ""
+	}
+	return *cr.Spec.ZoneTopology
+}
+
+// getRedisPodZones returns the zone of the node of every redis pod indexed by the pod IP, pods on nodes without a
+// zone are left out. Every node is only read once.
+func getRedisPodZones(cr *redisv1beta1.Redis, podsByIP map[string]corev1.Pod) map[string]string {
+	zones := map[string]string{}
+	nodeZones := map[string]string{}
+	for ip, pod := range podsByIP {
+		zone, ok := nodeZones[pod.Spec.NodeName]
+		if !ok {
+			zone = getNodePlacement(cr, pod).Zone
+			nodeZones[pod.Spec.NodeName] = zone
+		}
+		if zone != "" {
+			zones[ip] = zone
+		}
+	}
+	return zones
+}
+
+// isZoneTopologyMet reports whether a replica in the zone may replicate a master in the other zone. Any master is
+// allowed when a zone is unknown.
+func isZoneTopologyMet(topology string, replicaZone string, masterZone string) bool {
+	if replicaZone == "" || masterZone == "" {
+		return true
+	}
+	switch topology {
+	case zoneTopologySpread:
+		return replicaZone != masterZone
+	case zoneTopologyColocate:
+		return replicaZone == masterZone
+	}
+	return true
+}
+
+// getZoneAffinityTerm returns the preferred zone affinity term for the redis pods of the role
+func getZoneAffinityTerm(cr *redisv1beta1.Redis, role string) corev1.WeightedPodAffinityTerm {
+	return corev1.WeightedPodAffinityTerm{
+		Weight: 100,
+		PodAffinityTerm: corev1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": GetRedisName(cr) + "-" + role},
+			},
+			TopologyKey: corev1.LabelZoneFailureDomainStable,
+		},
+	}
+}
+
+// getRedisAffinity returns the affinity of the redis pods of the role. With a zone topology the masters, and the
+// slaves, prefer zones without pods of their role, and with colocate the slaves prefer the zones of the masters.
+// The terms are added to the affinity of the spec.
+func getRedisAffinity(cr *redisv1beta1.Redis, role string) *corev1.Affinity {
+	topology := getZoneTopology(cr)
+	if topology == "" || (role != "master" && role != "slave") {
+		return cr.Spec.Affinity
+	}
+	affinity := &corev1.Affinity{}
+	if cr.Spec.Affinity != nil {
+		affinity = cr.Spec.Affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, getZoneAffinityTerm(cr, role))
+	if topology == zoneTopologyColocate && role == "slave" {
+		if affinity.PodAffinity == nil {
+			affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution, getZoneAffinityTerm(cr, "master"))
+	}
+	return affinity
+}
@@ -0,0 +1,75 @@
+package k8sutils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetReplicaMasterWithZoneTopology(t *testing.T) {
+	nodes := []redisClusterNode{
+		{ID: "a", IP: "10.0.0.1", Flags: "master", Slots: []string{"0-5460"}},
+		{ID: "b", IP: "10.0.0.2", Flags: "master", Slots: []string{"5461-10922"}},
+		{ID: "c", IP: "10.0.0.3", Flags: "master", Slots: []string{"10923-16383"}},
+		{ID: "d", IP: "10.0.0.4", Flags: "slave", MasterID: "a"},
+	}
+	zones := map[string]string{"10.0.0.1": "zone-a", "10.0.0.2": "zone-b", "10.0.0.3": "zone-c", "10.0.0.5": "zone-a"}
+	for topology, want := range map[string]string{zoneTopologySpread: "b", zoneTopologyColocate: "a"} {
+		allowed := func(master redisClusterNode) bool {
+			return isZoneTopologyMet(topology, zones["10.0.0.5"], zones[master.IP])
+		}
+		if master, ok := getReplicaMaster(nodes, "10.0.0.2", allowed); !ok || master.ID != want {
+			t.Errorf("expected the %s replica to replicate master %s, got %+v", topology, want, master)
+		}
+	}
+	// without a master in an allowed zone the slave still gets the least replicated master
+	notAllowed := func(master redisClusterNode) bool { return false }
+	if master, ok := getReplicaMaster(nodes, "10.0.0.2", notAllowed); !ok || master.ID != "b" {
+		t.Errorf("expected the least replicated master without an allowed zone, got %+v", master)
+	}
+}
+
+func TestPlanReplicaMoveWithZoneTopology(t *testing.T) {
+	nodes := []redisClusterNode{
+		{ID: "m1", IP: "10.0.0.1", Flags: "master", Slots: []string{"0-8191"}},
+		{ID: "m2", IP: "10.0.0.2", Flags: "master", Slots: []string{"8192-16383"}},
+		{ID: "r1", IP: "10.0.0.3", Flags: "slave", MasterID: "m1"},
+		{ID: "r2", IP: "10.0.0.4", Flags: "slave", MasterID: "m1"},
+	}
+	zones := map[string]string{"10.0.0.1": "zone-a", "10.0.0.2": "zone-b", "10.0.0.3": "zone-b", "10.0.0.4": "zone-a"}
+	colocate := func(replica redisClusterNode, master redisClusterNode) bool {
+		return isZoneTopologyMet(zoneTopologyColocate, zones[replica.IP], zones[master.IP])
+	}
+	if replica, master := planReplicaMove(nodes, colocate); replica != "r1" || master != "m2" {
+		t.Errorf("expected r1 to move to m2 in its zone, got %q to %q", replica, master)
+	}
+	zones["10.0.0.3"] = "zone-a"
+	if replica, master := planReplicaMove(nodes, colocate); replica != "" || master != "" {
+		t.Errorf("expected no move out of the zone of the replicas, got %q to %q", replica, master)
+	}
+}
+
+func TestGetRedisAffinityWithZoneTopology(t *testing.T) {
+	cr := newTestRedisCluster(3)
+	if affinity := getRedisAffinity(cr, "slave"); affinity != nil {
+		t.Fatalf("expected no affinity without a zone topology, got %+v", affinity)
+	}
+	topology := zoneTopologyColocate
+	cr.Spec.ZoneTopology = &topology
+	cr.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	affinity := getRedisAffinity(cr, "slave")
+	if affinity.NodeAffinity == nil {
+		t.Error("expected the affinity of the spec to be kept")
+	}
+	spread := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(spread) != 1 || spread[0].PodAffinityTerm.LabelSelector.MatchLabels["app"] != "redis-slave" || spread[0].PodAffinityTerm.TopologyKey != corev1.LabelZoneFailureDomainStable {
+		t.Errorf("expected the slaves to spread across the zones, got %+v", spread)
+	}
+	colocate := affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(colocate) != 1 || colocate[0].PodAffinityTerm.LabelSelector.MatchLabels["app"] != "redis-master" {
+		t.Errorf("expected the slaves to prefer the zones of the masters, got %+v", colocate)
+	}
+	if cr.Spec.Affinity.PodAntiAffinity != nil {
+		t.Error("expected the affinity of the spec to be left unchanged")
+	}
+}
@@ -18,12 +18,17 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -33,6 +38,7 @@ import (
 
 	redisv1beta1 "redis-operator/api/v1beta1"
 	"redis-operator/controllers"
+	"redis-operator/k8sutils"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -49,21 +55,77 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var reconcileBaseDelay time.Duration
+	var reconcileMaxDelay time.Duration
+	var redisAdminCommandRate float64
+	var enableWebhooks bool
+	var watchNamespace string
+	var defaultCPURequest, defaultMemoryRequest, defaultCPULimit, defaultMemoryLimit string
+	var redisDialTimeout, redisReadTimeout, redisWriteTimeout, redisIdleTTL time.Duration
+	var pdbReconcileCache bool
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
+	flag.BoolVar(&enableLeaderElection, "leader-elect", true,
 		"Enable leader election for controller manager. "+
-			"Enabling this will ensure there is only one active controller manager.")
+			"Enabling this will ensure there is only one active controller manager. "+
+			"It can be disabled with --leader-elect=false when a single replica of the operator is running.")
+	flag.DurationVar(&reconcileBaseDelay, "reconcile-base-delay", time.Second,
+		"The initial delay before retrying a failed reconcile, doubled on each consecutive failure.")
+	flag.DurationVar(&reconcileMaxDelay, "reconcile-max-delay", time.Minute*5,
+		"The maximum delay before retrying a failed reconcile.")
+	flag.Float64Var(&redisAdminCommandRate, "redis-admin-command-rate", 0,
+		"The number of redis admin commands per second allowed for each redis pod, 0 disables the limit.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the defaulting and validating webhooks of the redis resources, which needs a serving certificate.")
+	flag.StringVar(&watchNamespace, "watch-namespace", "",
+		"The namespace whose redis resources are managed, all namespaces when empty. "+
+			"With a namespace the operator only needs namespaced roles.")
+	flag.StringVar(&defaultCPURequest, "default-redis-cpu-request", "",
+		"The CPU request of the redis containers whose spec doesn't set one.")
+	flag.StringVar(&defaultMemoryRequest, "default-redis-memory-request", "",
+		"The memory request of the redis containers whose spec doesn't set one.")
+	flag.StringVar(&defaultCPULimit, "default-redis-cpu-limit", "",
+		"The CPU limit of the redis containers whose spec doesn't set one.")
+	flag.StringVar(&defaultMemoryLimit, "default-redis-memory-limit", "",
+		"The memory limit of the redis containers whose spec doesn't set one.")
+	flag.DurationVar(&redisDialTimeout, "redis-dial-timeout", time.Second*5,
+		"The timeout for connecting to a redis pod to send admin commands.")
+	flag.DurationVar(&redisReadTimeout, "redis-read-timeout", time.Second*3,
+		"The timeout for reading the reply of a redis admin command.")
+	flag.DurationVar(&redisWriteTimeout, "redis-write-timeout", time.Second*3,
+		"The timeout for writing a redis admin command.")
+	flag.DurationVar(&redisIdleTTL, "redis-idle-ttl", time.Minute*5,
+		"How long the admin connections of a redis pod are kept open once they are no longer used.")
+	flag.BoolVar(&pdbReconcileCache, "pdb-reconcile-cache", false,
+		"Skip reading the pod disruption budgets which are unchanged since their last reconcile, which reduces the API "+
+			"server load of large deployments.")
 	opts := zap.Options{
 		Development: true,
+		// the encoder selected with --zap-encoder writes ISO8601 timestamps
+		EncoderConfigOptions: []zap.EncoderConfigOption{func(config *zapcore.EncoderConfig) {
+			config.EncodeTime = zapcore.ISO8601TimeEncoder
+		}},
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	k8sutils.SetAdminCommandRate(redisAdminCommandRate)
+	k8sutils.SetRedisClientTimeouts(redisDialTimeout, redisReadTimeout, redisWriteTimeout, redisIdleTTL)
+	k8sutils.SetPodDisruptionBudgetCache(pdbReconcileCache)
+	resources, err := parseDefaultRedisResources(defaultCPURequest, defaultMemoryRequest, defaultCPULimit, defaultMemoryLimit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	k8sutils.SetDefaultRedisResources(resources)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -72,6 +134,7 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "6cab913b.redis.opstreelabs.in",
+		Namespace:              watchNamespace,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -79,13 +142,22 @@ func main() {
 	}
 
 	if err = (&controllers.RedisReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Redis"),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("Redis"),
+		Scheme:      mgr.GetScheme(),
+		RateLimiter: controllers.NewJitterRateLimiter(reconcileBaseDelay, reconcileMaxDelay),
+		Recorder:    mgr.GetEventRecorderFor("redis-operator"),
+		WatchNodes:  watchNamespace == "",
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Redis")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&redisv1beta1.Redis{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Redis")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
@@ -103,3 +175,31 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseDefaultRedisResources parses the default CPU and memory requests and limits of the redis containers, the
+// values left empty have no default
+func parseDefaultRedisResources(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) (corev1.ResourceRequirements, error) {
+	resources := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	flags := []struct {
+		name     string
+		value    string
+		list     corev1.ResourceList
+		resource corev1.ResourceName
+	}{
+		{"default-redis-cpu-request", cpuRequest, resources.Requests, corev1.ResourceCPU},
+		{"default-redis-memory-request", memoryRequest, resources.Requests, corev1.ResourceMemory},
+		{"default-redis-cpu-limit", cpuLimit, resources.Limits, corev1.ResourceCPU},
+		{"default-redis-memory-limit", memoryLimit, resources.Limits, corev1.ResourceMemory},
+	}
+	for _, f := range flags {
+		if f.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(f.value)
+		if err != nil {
+			return resources, fmt.Errorf("invalid --%s %q: %v", f.name, f.value, err)
+		}
+		f.list[f.resource] = quantity
+	}
+	return resources, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Opstree Solutions.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	redisv1beta1 "redis-operator/api/v1beta1"
+	"redis-operator/k8sutils"
+)
+
+// runValidate runs the validate subcommand, which checks the redis resources of a manifest with the validation the
+// operator runs before reconciling them, without connecting to a cluster. It returns the exit code, 1 when a redis
+// resource is invalid.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	var file string
+	flags.StringVar(&file, "f", "", "The manifest holding the redis resources to validate, - reads it from stdin.")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if file == "" {
+		fmt.Fprintln(os.Stderr, "validate needs the manifest to validate with -f")
+		return 2
+	}
+	input := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read %s: %v\n", file, err)
+			return 2
+		}
+		defer f.Close()
+		input = f
+	}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(input))
+	found, invalid := 0, 0
+	for document := 1; ; document++ {
+		data, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read document %d of %s: %v\n", document, file, err)
+			return 2
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var object metav1.PartialObjectMetadata
+		if err := yaml.Unmarshal(data, &object); err != nil {
+			fmt.Printf("document %d: invalid\n  - %v\n", document, err)
+			invalid++
+			continue
+		}
+		if object.Kind != "Redis" || !strings.HasPrefix(object.APIVersion, redisv1beta1.GroupVersion.Group+"/") {
+			continue
+		}
+		found++
+		name := object.Name
+		if object.Namespace != "" {
+			name = object.Namespace + "/" + object.Name
+		}
+		warnings, errs := validateRedisManifest(data)
+		if len(errs) == 0 {
+			fmt.Printf("document %d, redis %s: valid\n", document, name)
+			for _, warning := range warnings {
+				fmt.Printf("  ! %s\n", warning)
+			}
+			continue
+		}
+		invalid++
+		fmt.Printf("document %d, redis %s: invalid\n", document, name)
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
+		}
+	}
+	if found == 0 {
+		fmt.Fprintf(os.Stderr, "no redis resources found in %s\n", file)
+		return 2
+	}
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateRedisManifest decodes a redis resource, rejecting unknown fields, and returns the warnings of a valid spec or
+// the errors of the webhook and of the operator validation
+func validateRedisManifest(data []byte) ([]string, []error) {
+	var instance redisv1beta1.Redis
+	if err := yaml.UnmarshalStrict(data, &instance); err != nil {
+		return nil, []error{err}
+	}
+	var errs []error
+	for _, err := range []error{instance.ValidateCreate(), k8sutils.ValidateRedisSpec(&instance)} {
+		if aggregate, ok := err.(utilerrors.Aggregate); ok {
+			errs = append(errs, aggregate.Errors()...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return k8sutils.GetRedisSpecWarnings(&instance), nil
+}
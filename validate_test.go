@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Opstree Solutions.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validRedisManifest = `apiVersion: redis.redis.opstreelabs.in/v1beta1
+kind: Redis
+metadata:
+  name: redis-cluster
+spec:
+  mode: cluster
+  size: 3
+  global:
+    image: quay.io/opstree/redis:v6.2
+`
+
+// writeManifest writes the manifest to a file of the temporary directory of the test and returns its path
+func writeManifest(t *testing.T, manifest string) string {
+	path := filepath.Join(t.TempDir(), "redis.yaml")
+	if err := ioutil.WriteFile(path, []byte(manifest), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateRedisManifest(t *testing.T) {
+	if _, errs := validateRedisManifest([]byte(validRedisManifest)); len(errs) != 0 {
+		t.Errorf("expected the manifest to be valid, got %v", errs)
+	}
+	invalid := strings.Replace(validRedisManifest, "  size: 3\n", "  size: 3\n  podDisruptionBudget:\n    enabled: true\n    scope: roles\n", 1)
+	if _, errs := validateRedisManifest([]byte(invalid)); len(errs) == 0 {
+		t.Error("expected an unknown pod disruption budget scope to be invalid")
+	}
+	unknown := strings.Replace(validRedisManifest, "  size: 3\n", "  size: 3\n  sise: 3\n", 1)
+	if _, errs := validateRedisManifest([]byte(unknown)); len(errs) == 0 {
+		t.Error("expected an unknown field to be rejected")
+	}
+}
+
+func TestRunValidateExitCodes(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n"
+	invalid := strings.Replace(validRedisManifest, "  size: 3\n", "  size: 3\n  podDisruptionBudget:\n    enabled: true\n    scope: roles\n", 1)
+	for name, test := range map[string]struct {
+		args []string
+		want int
+	}{
+		"valid":            {[]string{"-f", writeManifest(t, configMap+"---\n"+validRedisManifest)}, 0},
+		"invalid":          {[]string{"-f", writeManifest(t, validRedisManifest+"---\n"+invalid)}, 1},
+		"no redis":         {[]string{"-f", writeManifest(t, configMap)}, 2},
+		"missing file":     {[]string{"-f", filepath.Join(t.TempDir(), "missing.yaml")}, 2},
+		"missing manifest": {nil, 2},
+	} {
+		if got := runValidate(test.args); got != test.want {
+			t.Errorf("%s: expected exit code %d, got %d", name, test.want, got)
+		}
+	}
+}